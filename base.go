@@ -2,6 +2,8 @@ package pg
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
 	"io"
 	"time"
 
@@ -102,30 +104,144 @@ func (db *baseDB) initConn(ctx context.Context, cn *pool.Conn) error {
 	}
 	cn.Inited = true
 
-	if db.opt.TLSConfig != nil {
-		err := db.enableSSL(ctx, cn, db.opt.TLSConfig)
+	if err := db.connect(ctx, cn); err != nil {
+		return err
+	}
+	// Notices are handled once the connection is established.
+	cn.OnNotice = db.handleNotice
+
+	if db.opt.OnConnect != nil {
+		p := pool.NewSingleConnPool(db.pool, cn)
+		return db.opt.OnConnect(ctx, newConn(ctx, db.withPool(p)))
+	}
+
+	return nil
+}
+
+// connect starts a session on the connection. Hosts are tried in order
+// until the session matches TargetSessionAttrs.
+func (db *baseDB) connect(ctx context.Context, cn *pool.Conn) error {
+	if db.opt.TargetSessionAttrs != "prefer-standby" {
+		return db.connectHost(ctx, cn, db.opt.TargetSessionAttrs)
+	}
+
+	if err := db.connectHost(ctx, cn, "standby"); err == nil {
+		return nil
+	}
+	// There is no standby, so try all hosts again.
+	if _, err := db.redial(ctx, cn, 0); err != nil {
+		return err
+	}
+	return db.connectHost(ctx, cn, "any")
+}
+
+// connectHost starts a session with the attrs on the connection redialing
+// it to the next host if the session does not match.
+func (db *baseDB) connectHost(ctx context.Context, cn *pool.Conn, attrs string) error {
+	numAddr := len(db.opt.addrs())
+	index := hostIndex(cn.NetConn())
+	for {
+		err := db.startSession(ctx, cn, attrs)
+		if err == nil || index+1 >= numAddr {
+			return err
+		}
+
+		index, err = db.redial(ctx, cn, index+1)
 		if err != nil {
 			return err
 		}
 	}
+}
 
-	err := db.startup(ctx, cn, db.opt.User, db.opt.Password, db.opt.Database, db.opt.ApplicationName)
+func (db *baseDB) redial(ctx context.Context, cn *pool.Conn, index int) (int, error) {
+	netConn, err := db.opt.dialHost(ctx, db.opt.addrs(), index)
+	if err != nil {
+		return 0, err
+	}
+	_ = cn.NetConn().Close()
+	cn.SetNetConn(netConn)
+	return hostIndex(netConn), nil
+}
+
+func (db *baseDB) startSession(ctx context.Context, cn *pool.Conn, attrs string) error {
+	index := hostIndex(cn.NetConn())
+
+	useSSL := db.opt.TLSConfig != nil && db.opt.SSLMode != "allow"
+	err := db.openSession(ctx, cn, index, useSSL)
+
+	// With sslmode=allow retry using TLS and with sslmode=prefer retry
+	// without TLS when the server rejects the session.
+	if _, ok := err.(Error); ok && db.opt.TLSConfig != nil {
+		_, isTLS := cn.NetConn().(*tls.Conn)
+		if (db.opt.SSLMode == "allow" && !isTLS) || (db.opt.SSLMode == "prefer" && isTLS) {
+			index, redialErr := db.redial(ctx, cn, index)
+			if redialErr != nil {
+				return redialErr
+			}
+			err = db.openSession(ctx, cn, index, !isTLS)
+		}
+	}
 	if err != nil {
 		return err
 	}
 
-	if db.opt.OnConnect != nil {
-		p := pool.NewSingleConnPool(db.pool, cn)
-		return db.opt.OnConnect(ctx, newConn(ctx, db.withPool(p)))
+	return db.checkSessionAttrs(ctx, cn, attrs)
+}
+
+// openSession sends the startup message and authenticates to the host
+// with the index optionally enabling TLS first.
+func (db *baseDB) openSession(ctx context.Context, cn *pool.Conn, index int, useSSL bool) error {
+	if useSSL {
+		err := db.enableSSL(ctx, cn)
+		// With sslmode=prefer continue on the same connection without TLS.
+		if err != nil && !(err == errSSLNotSupported && db.opt.SSLMode == "prefer") {
+			return err
+		}
+	}
+
+	password := db.opt.hostPassword(index)
+	return db.startup(ctx, cn, db.opt.User, password, db.opt.Database, db.opt.ApplicationName)
+}
+
+func (db *baseDB) checkSessionAttrs(ctx context.Context, cn *pool.Conn, attrs string) error {
+	var query, wanted string
+	switch attrs {
+	case "", "any":
+		return nil
+	case "read-write":
+		query, wanted = "SHOW transaction_read_only", "off"
+	case "read-only":
+		query, wanted = "SHOW transaction_read_only", "on"
+	case "primary":
+		query, wanted = "SELECT pg_is_in_recovery()", "f"
+	case "standby":
+		query, wanted = "SELECT pg_is_in_recovery()", "t"
+	default:
+		return fmt.Errorf("pg: target_session_attrs=%q is not supported", attrs)
+	}
+
+	wb := pool.GetWriteBuffer()
+	defer pool.PutWriteBuffer(wb)
+
+	if err := writeQueryMsg(wb, db.fmter, query); err != nil {
+		return err
 	}
 
+	var value string
+	if _, err := db.simpleQueryData(ctx, cn, Scan(&value), wb); err != nil {
+		return err
+	}
+	if value != wanted {
+		return fmt.Errorf("pg: session on %s does not match target_session_attrs=%s",
+			cn.RemoteAddr(), attrs)
+	}
 	return nil
 }
 
 func (db *baseDB) releaseConn(ctx context.Context, cn *pool.Conn, err error) {
 	if bad, code := isBadConn(err, false); bad {
 		if code != "25P02" { // canceling statement if it is a bad conn expect 25P02 (current transaction is aborted)
-			err := db.cancelRequest(cn.ProcessID, cn.SecretKey)
+			err := db.cancelRequest(cn)
 			if err != nil {
 				internal.Logger.Printf(ctx, "cancelRequest failed: %s", err)
 			}
@@ -151,7 +267,7 @@ func (db *baseDB) withConn(
 			select {
 			case <-fnDone: // fn has finished, skip cancel
 			case <-ctx.Done():
-				err := db.cancelRequest(cn.ProcessID, cn.SecretKey)
+				err := db.cancelRequest(cn)
 				if err != nil {
 					internal.Logger.Printf(ctx, "cancelRequest failed: %s", err)
 				}
@@ -218,6 +334,8 @@ func (db *baseDB) Close() error {
 
 // Exec executes a query ignoring returned rows. The params are for any
 // placeholders in the query.
+// Queries with $1, $2, ... placeholders send the params separately using
+// the extended query protocol and the statement cache.
 func (db *baseDB) Exec(query interface{}, params ...interface{}) (res Result, err error) {
 	return db.exec(db.db.Context(), query, params...)
 }
@@ -230,11 +348,13 @@ func (db *baseDB) exec(ctx context.Context, query interface{}, params ...interfa
 	wb := pool.GetWriteBuffer()
 	defer pool.PutWriteBuffer(wb)
 
-	if err := writeQueryMsg(wb, db.fmter, query, params...); err != nil {
+	bindParams := db.bindsParams(query, params)
+	fmtedQuery, args, err := db.writeQuery(wb, bindParams, query, params...)
+	if err != nil {
 		return nil, err
 	}
 
-	ctx, evt, err := db.beforeQuery(ctx, db.db, nil, query, params, wb.Query())
+	ctx, evt, err := db.beforeQuery(ctx, db.db, nil, query, params, fmtedQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -249,7 +369,15 @@ func (db *baseDB) exec(ctx context.Context, query interface{}, params ...interfa
 		}
 
 		lastErr = db.withConn(ctx, func(ctx context.Context, cn *pool.Conn) error {
-			res, err = db.simpleQuery(ctx, cn, wb)
+			if bindParams {
+				res, err = db.extQuery(ctx, cn, fmtedQuery, args)
+				if isStmtInvalidated(err) {
+					// The statement was evicted from the cache and is prepared again.
+					res, err = db.extQuery(ctx, cn, fmtedQuery, args)
+				}
+			} else {
+				res, err = db.simpleQuery(ctx, cn, wb)
+			}
 			return err
 		})
 		if !db.shouldRetry(lastErr) {
@@ -288,6 +416,8 @@ func (db *baseDB) execOne(c context.Context, query interface{}, params ...interf
 
 // Query executes a query that returns rows, typically a SELECT.
 // The params are for any placeholders in the query.
+// Queries with $1, $2, ... placeholders send the params separately using
+// the extended query protocol and the statement cache.
 func (db *baseDB) Query(model, query interface{}, params ...interface{}) (res Result, err error) {
 	return db.query(db.db.Context(), model, query, params...)
 }
@@ -300,11 +430,13 @@ func (db *baseDB) query(ctx context.Context, model, query interface{}, params ..
 	wb := pool.GetWriteBuffer()
 	defer pool.PutWriteBuffer(wb)
 
-	if err := writeQueryMsg(wb, db.fmter, query, params...); err != nil {
+	bindParams := db.bindsParams(query, params)
+	fmtedQuery, args, err := db.writeQuery(wb, bindParams, query, params...)
+	if err != nil {
 		return nil, err
 	}
 
-	ctx, evt, err := db.beforeQuery(ctx, db.db, model, query, params, wb.Query())
+	ctx, evt, err := db.beforeQuery(ctx, db.db, model, query, params, fmtedQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -319,7 +451,15 @@ func (db *baseDB) query(ctx context.Context, model, query interface{}, params ..
 		}
 
 		lastErr = db.withConn(ctx, func(ctx context.Context, cn *pool.Conn) error {
-			res, err = db.simpleQueryData(ctx, cn, model, wb)
+			if bindParams {
+				res, err = db.extQueryData(ctx, cn, model, fmtedQuery, args)
+				if isStmtInvalidated(err) {
+					// The statement was evicted from the cache and is prepared again.
+					res, err = db.extQueryData(ctx, cn, model, fmtedQuery, args)
+				}
+			} else {
+				res, err = db.simpleQueryData(ctx, cn, model, wb)
+			}
 			return err
 		})
 		if !db.shouldRetry(lastErr) {
@@ -358,7 +498,8 @@ func (db *baseDB) queryOne(ctx context.Context, model, query interface{}, params
 	return res, nil
 }
 
-// CopyFrom copies data from reader to a table.
+// CopyFrom copies data from reader to a table. When reading fails,
+// COPY is aborted and the reader error is returned.
 func (db *baseDB) CopyFrom(r io.Reader, query interface{}, params ...interface{}) (res Result, err error) {
 	c := db.db.Context()
 	err = db.withConn(c, func(c context.Context, cn *pool.Conn) error {
@@ -397,6 +538,9 @@ func (db *baseDB) copyFrom(
 			err = afterQueryErr
 		}
 	}()
+	defer func() {
+		attachNotices(ctx, res, err)
+	}()
 
 	err = cn.WithWriter(ctx, db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
 		return writeQueryMsg(wb, db.fmter, query, params...)
@@ -411,13 +555,21 @@ func (db *baseDB) copyFrom(
 	}
 
 	for {
+		var readErr error
 		err = cn.WithWriter(ctx, db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
-			return writeCopyData(wb, r)
+			readErr = writeCopyData(wb, r)
+			return readErr
 		})
 		if err != nil {
 			if err == io.EOF {
 				break
 			}
+			if readErr != nil {
+				// Abort COPY, so the connection can be used again.
+				if err := db.copyFail(ctx, cn, readErr); err != nil {
+					return nil, err
+				}
+			}
 			return nil, err
 		}
 	}
@@ -441,6 +593,27 @@ func (db *baseDB) copyFrom(
 	return res, nil
 }
 
+// copyFail aborts COPY FROM STDIN with the reader error
+// and waits until the server is ready for the next query.
+func (db *baseDB) copyFail(ctx context.Context, cn *pool.Conn, readErr error) error {
+	err := cn.WithWriter(ctx, db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
+		writeCopyFail(wb, readErr.Error())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return cn.WithReader(ctx, db.opt.ReadTimeout, func(rd *pool.ReaderContext) error {
+		_, err := readReadyForQuery(rd)
+		if _, ok := err.(Error); ok {
+			// The server reports that COPY failed.
+			return nil
+		}
+		return err
+	})
+}
+
 // CopyTo copies data from a table to writer.
 func (db *baseDB) CopyTo(w io.Writer, query interface{}, params ...interface{}) (res Result, err error) {
 	c := db.db.Context()
@@ -479,6 +652,9 @@ func (db *baseDB) copyTo(
 			err = afterQueryErr
 		}
 	}()
+	defer func() {
+		attachNotices(ctx, res, err)
+	}()
 
 	err = cn.WithWriter(ctx, db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
 		return writeQueryMsg(wb, db.fmter, query, params...)
@@ -523,31 +699,95 @@ func (db *baseDB) Formatter() orm.QueryFormatter {
 	return db.fmter
 }
 
-func (db *baseDB) cancelRequest(processID, secretKey int32) error {
+func (db *baseDB) cancelRequest(cn *pool.Conn) error {
 	c := context.TODO()
 
-	cn, err := db.pool.NewConn(c)
+	// With multiple hosts the request must be sent to the host of the conn.
+	if len(db.opt.addrs()) > 1 {
+		netConn, err := db.opt.Dialer(c, db.opt.Network, cn.RemoteAddr().String())
+		if err != nil {
+			return err
+		}
+		defer netConn.Close()
+
+		return pool.NewConn(netConn).WithWriter(c, db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
+			writeCancelRequestMsg(wb, cn.ProcessID, cn.SecretKey)
+			return nil
+		})
+	}
+
+	cancelConn, err := db.pool.NewConn(c)
 	if err != nil {
 		return err
 	}
 	defer func() {
-		_ = db.pool.CloseConn(cn)
+		_ = db.pool.CloseConn(cancelConn)
 	}()
 
-	return cn.WithWriter(c, db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
-		writeCancelRequestMsg(wb, processID, secretKey)
+	return cancelConn.WithWriter(c, db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
+		writeCancelRequestMsg(wb, cn.ProcessID, cn.SecretKey)
 		return nil
 	})
 }
 
+// bindsParams reports whether values of the query are sent to the server
+// separately from the query. ORM queries bind params when it is enabled
+// and raw queries bind params when they use $1, $2, ... placeholders.
+func (db *baseDB) bindsParams(query interface{}, params []interface{}) bool {
+	if isRawBindQuery(query, params) {
+		return true
+	}
+
+	var q *orm.Query
+	switch query := query.(type) {
+	case orm.QueryCommand:
+		q = query.Query()
+	case *orm.Query:
+		// Query is appended as a select query.
+		q = query
+	default:
+		return false
+	}
+
+	if db.opt.BindParams {
+		return true
+	}
+	return q != nil && q.BindsParams()
+}
+
+// writeQuery writes the query using the simple query protocol unless
+// bindParams is true. Queries that bind params are only formatted into
+// the buffer, because their messages depend on the connection. It returns
+// the formatted query and the bound values.
+func (db *baseDB) writeQuery(
+	wb *pool.WriteBuffer, bindParams bool, query interface{}, params ...interface{},
+) ([]byte, []interface{}, error) {
+	if bindParams {
+		b, args, err := appendBindQuery(db.fmter, wb.Bytes[:0], query, params...)
+		if err != nil {
+			return nil, nil, err
+		}
+		wb.Bytes = b
+		return b, args, nil
+	}
+
+	if err := writeQueryMsg(wb, db.fmter, query, params...); err != nil {
+		return nil, nil, err
+	}
+	return wb.Query(), nil, nil
+}
+
 func (db *baseDB) simpleQuery(
 	c context.Context, cn *pool.Conn, wb *pool.WriteBuffer,
-) (*result, error) {
+) (res *result, err error) {
+	defer func() {
+		attachNotices(c, res, err)
+	}()
+
 	if err := cn.WriteBuffer(c, db.opt.WriteTimeout, wb); err != nil {
 		return nil, err
 	}
 
-	var res *result
 	if err := cn.WithReader(c, db.opt.ReadTimeout, func(rd *pool.ReaderContext) error {
 		var err error
 		res, err = readSimpleQuery(rd)
@@ -561,12 +801,15 @@ func (db *baseDB) simpleQuery(
 
 func (db *baseDB) simpleQueryData(
 	c context.Context, cn *pool.Conn, model interface{}, wb *pool.WriteBuffer,
-) (*result, error) {
+) (res *result, err error) {
+	defer func() {
+		attachNotices(c, res, err)
+	}()
+
 	if err := cn.WriteBuffer(c, db.opt.WriteTimeout, wb); err != nil {
 		return nil, err
 	}
 
-	var res *result
 	if err := cn.WithReader(c, db.opt.ReadTimeout, func(rd *pool.ReaderContext) error {
 		var err error
 		res, err = readSimpleQueryData(c, rd, model)
@@ -578,9 +821,140 @@ func (db *baseDB) simpleQueryData(
 	return res, nil
 }
 
+func (db *baseDB) extQuery(
+	c context.Context, cn *pool.Conn, q []byte, args []interface{},
+) (res *result, err error) {
+	defer func() {
+		attachNotices(c, res, err)
+	}()
+
+	stmt, err := db.writeExtQuery(c, cn, q, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cn.WithReader(c, db.opt.ReadTimeout, func(rd *pool.ReaderContext) error {
+		var err error
+		res, err = readExtQuery(rd)
+		return err
+	}); err != nil {
+		if stmt != nil && isStmtInvalidated(err) {
+			db.evictStmt(c, cn, q)
+		}
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (db *baseDB) extQueryData(
+	c context.Context, cn *pool.Conn, model interface{}, q []byte, args []interface{},
+) (res *result, err error) {
+	defer func() {
+		attachNotices(c, res, err)
+	}()
+
+	stmt, err := db.writeExtQuery(c, cn, q, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []types.ColumnInfo
+	if stmt != nil {
+		columns = stmt.Columns
+	}
+
+	if err := cn.WithReader(c, db.opt.ReadTimeout, func(rd *pool.ReaderContext) error {
+		var err error
+		res, err = readExtQueryData(c, rd, model, columns)
+		return err
+	}); err != nil {
+		if stmt != nil && isStmtInvalidated(err) {
+			db.evictStmt(c, cn, q)
+		}
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// writeExtQuery executes the query using the statement cached on the
+// connection or, when the cache is disabled, the unnamed statement.
+func (db *baseDB) writeExtQuery(
+	c context.Context, cn *pool.Conn, q []byte, args []interface{},
+) (*pool.CachedStmt, error) {
+	stmt, err := db.cachedStmt(c, cn, q)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cn.WithWriter(c, db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
+		if stmt != nil {
+			return writeBindExecuteMsg(wb, stmt.Name, stmt.Columns, args...)
+		}
+		if err := writeParseBindExecuteMsg(wb, q, args); err != nil {
+			return err
+		}
+		writeSyncMsg(wb)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
+// cachedStmt returns the statement prepared on the connection for the query
+// preparing it if necessary. It returns nil when the cache is disabled.
+func (db *baseDB) cachedStmt(c context.Context, cn *pool.Conn, q []byte) (*pool.CachedStmt, error) {
+	if db.opt.StatementCacheSize <= 0 {
+		return nil, nil
+	}
+
+	if cn.StmtCache == nil {
+		cn.StmtCache = pool.NewStmtCache(db.opt.StatementCacheSize)
+	} else if stmt, ok := cn.StmtCache.Get(q); ok {
+		return stmt, nil
+	}
+
+	query := string(q)
+	name, columns, err := db.prepare(c, cn, query)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := &pool.CachedStmt{
+		Query:   query,
+		Name:    name,
+		Columns: columns,
+	}
+	if evicted := cn.StmtCache.Put(stmt); evicted != nil {
+		if err := db.closeStmt(c, cn, evicted.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	return stmt, nil
+}
+
+func (db *baseDB) evictStmt(c context.Context, cn *pool.Conn, q []byte) {
+	stmt, ok := cn.StmtCache.Remove(q)
+	if !ok {
+		return
+	}
+	if err := db.closeStmt(c, cn, stmt.Name); err != nil {
+		internal.Logger.Printf(c, "closeStmt failed: %s", err)
+	}
+}
+
 // Prepare creates a prepared statement for later queries or
 // executions. Multiple queries or executions may be run concurrently
 // from the returned statement.
+//
+// Results of prepared statements are received in the binary format
+// when it is supported by the column type. timestamptz values are
+// then scanned in UTC instead of the session time zone.
 func (db *baseDB) Prepare(q string) (*Stmt, error) {
 	return prepareStmt(db.withPool(pool.NewStickyConnPool(db.pool)), q)
 }
@@ -606,6 +980,12 @@ func (db *baseDB) prepare(
 		return "", nil, err
 	}
 
+	for i := range columns {
+		if types.SupportsBinaryFormat(columns[i].DataType) {
+			columns[i].Format = types.BinaryFormat
+		}
+	}
+
 	return name, columns, nil
 }
 
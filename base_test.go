@@ -1,13 +1,19 @@
 package pg
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
+	"errors"
+	"io"
 	"net"
+	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/go-pg/pg/v10/internal/pool"
+	"github.com/go-pg/pg/v10/orm"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -135,3 +141,111 @@ func (m *mockConn) SetReadDeadline(t time.Time) error {
 func (m *mockConn) SetWriteDeadline(t time.Time) error {
 	return nil
 }
+
+func TestIsRawBindQuery(t *testing.T) {
+	cases := []struct {
+		query  interface{}
+		params []interface{}
+		wanted bool
+	}{
+		{"SELECT $1", []interface{}{1}, true},
+		{"SELECT * FROM t WHERE a = $1 AND b = $2", []interface{}{1, 2}, true},
+		{"SELECT $1", nil, false},
+		{"SELECT ?", []interface{}{1}, false},
+		{"SELECT '$1', \"col$1\"", []interface{}{1}, false},
+		{"SELECT a$1 FROM t", []interface{}{1}, false},
+		{"SELECT 'it''s $1', $1", []interface{}{1}, true},
+		{`SELECT E'\' $1', 2`, []interface{}{1}, false},
+		{`SELECT E'\\', $1`, []interface{}{1}, true},
+		{`SELECT '\', $1`, []interface{}{1}, true},
+		{"SELECT $$ $1 $$, $body$ $1 $$ $body$", []interface{}{1}, false},
+		{"SELECT $body$ $1 $body$, $1", []interface{}{1}, true},
+		{"SELECT $$ $1", []interface{}{1}, false},
+		{"SELECT 1 -- $1\n", []interface{}{1}, false},
+		{"SELECT 1 -- $1\n, $1", []interface{}{1}, true},
+		{"SELECT /* $1 /* $2 */ $3 */ 1", []interface{}{1}, false},
+		{"SELECT /* $1 /* $2 */ $3 */ $1", []interface{}{1}, true},
+		{"SELECT 4/2, $1", []interface{}{1}, true},
+		{[]byte("SELECT $1"), []interface{}{1}, false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.wanted, isRawBindQuery(c.query, c.params), "%v", c.query)
+	}
+}
+
+func TestAppendExtQueryBindsParams(t *testing.T) {
+	db := Connect(&Options{BindParams: true})
+	defer db.Close()
+
+	cases := []struct {
+		query  interface{}
+		params []interface{}
+		wanted string
+		args   []interface{}
+	}{
+		{db.Model().ColumnExpr("?::text", "it's"), nil, "SELECT $1::text", []interface{}{"it's"}},
+		{db.Model().ColumnExpr("?::int", 1).Where("? > 0", 2), nil, "SELECT $1::int WHERE ($2 > 0)", []interface{}{1, 2}},
+		{"SELECT $1::int", []interface{}{1}, "SELECT $1::int", []interface{}{1}},
+	}
+	for _, c := range cases {
+		b, args, err := db.appendExtQuery(nil, c.query, c.params...)
+		assert.NoError(t, err)
+		assert.Equal(t, c.wanted, string(b))
+		assert.Equal(t, c.args, args)
+	}
+}
+
+func TestCopyFromReaderError(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+
+	failMsg := make(chan string, 1)
+	go func() {
+		defer close(failMsg)
+		defer serverConn.Close()
+		for {
+			c, msg, err := readTestFrontendMsg(serverConn)
+			if err != nil {
+				return
+			}
+			switch c {
+			case 'Q':
+				writeTestBackendMsg(serverConn, 'G', []byte{0, 0, 1, 0, 0})
+			case 'f':
+				failMsg <- string(bytes.TrimSuffix(msg, []byte{0}))
+				writeTestBackendMsg(serverConn, 'E', []byte("SERROR\x00C57014\x00MCOPY from stdin failed\x00\x00"))
+				writeTestBackendMsg(serverConn, 'Z', []byte{'I'})
+			case 'c':
+				t.Error("got CopyDone")
+				return
+			}
+		}
+	}()
+
+	db := &baseDB{opt: &Options{}, fmter: orm.NewFormatter()}
+	readErr := errors.New("read failed")
+	r := io.MultiReader(strings.NewReader("1\n2\n"), iotest.ErrReader(readErr))
+
+	_, err := db.copyFrom(context.Background(), pool.NewConn(clientConn), r, "COPY t FROM STDIN")
+	assert.Equal(t, readErr, err)
+
+	clientConn.Close()
+	assert.Equal(t, "read failed", <-failMsg)
+}
+
+func readTestFrontendMsg(r io.Reader) (byte, []byte, error) {
+	var hdr [5]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return 0, nil, err
+	}
+	msg := make([]byte, binary.BigEndian.Uint32(hdr[1:])-4)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return 0, nil, err
+	}
+	return hdr[0], msg, nil
+}
+
+func writeTestBackendMsg(w io.Writer, c byte, msg []byte) {
+	b := []byte{c, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(b[1:], uint32(len(msg)+4))
+	_, _ = w.Write(append(b, msg...))
+}
@@ -0,0 +1,184 @@
+package pg
+
+import (
+	"context"
+
+	"github.com/go-pg/pg/v10/internal"
+	"github.com/go-pg/pg/v10/internal/pool"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// Batch is a list of queries that are sent to the server together and
+// executed in a single round trip. Use DB.SendBatch or Tx.SendBatch to
+// execute the batch.
+//
+// Queries in the batch are executed using the extended query protocol, so
+// each query must contain a single statement.
+type Batch struct {
+	queries []batchQuery
+}
+
+type batchQuery struct {
+	model  interface{}
+	query  interface{}
+	params []interface{}
+}
+
+// Queue adds the query to the batch. Returned rows are scanned into the
+// model. For ORM queries a nil model means the model of the query;
+// otherwise rows are discarded.
+func (b *Batch) Queue(query, model interface{}, params ...interface{}) {
+	b.queries = append(b.queries, batchQuery{
+		model:  model,
+		query:  query,
+		params: params,
+	})
+}
+
+// Len returns the number of queued queries.
+func (b *Batch) Len() int {
+	return len(b.queries)
+}
+
+func (q *batchQuery) scanModel() interface{} {
+	if q.model != nil {
+		return q.model
+	}
+
+	var query *orm.Query
+	switch v := q.query.(type) {
+	case *orm.Query:
+		query = v
+	case orm.QueryCommand:
+		query = v.Query()
+	}
+	if query != nil && query.TableModel() != nil {
+		return query.TableModel()
+	}
+	return Discard
+}
+
+// SendBatch sends all queries in the batch to the server followed by a
+// single SYNC message and scans the results in order. Unless the batch is
+// sent in a transaction, the queries run in an implicit transaction,
+// so an error in any query rolls back all of them.
+func (db *baseDB) SendBatch(ctx context.Context, batch *Batch) ([]Result, error) {
+	return db.sendBatch(ctx, db.db, batch, db.opt.MaxRetries, db.withConn)
+}
+
+// SendBatch is an alias for DB.SendBatch.
+func (tx *Tx) SendBatch(ctx context.Context, batch *Batch) ([]Result, error) {
+	return tx.db.sendBatch(ctx, tx, batch, 0, tx.withConn)
+}
+
+func (db *baseDB) sendBatch(
+	ctx context.Context,
+	ormDB orm.DB,
+	batch *Batch,
+	maxRetries int,
+	withConn func(context.Context, func(context.Context, *pool.Conn) error) error,
+) ([]Result, error) {
+	if batch.Len() == 0 {
+		return nil, nil
+	}
+
+	wb := pool.GetWriteBuffer()
+	defer pool.PutWriteBuffer(wb)
+
+	models := make([]interface{}, len(batch.queries))
+	events := make([]*QueryEvent, 0, len(batch.queries))
+	for i := range batch.queries {
+		q := &batch.queries[i]
+		models[i] = q.scanModel()
+
+		fmtedQuery, args, err := db.appendExtQuery(nil, q.query, q.params...)
+		if err == nil {
+			err = writeParseBindExecuteMsg(wb, fmtedQuery, args)
+		}
+		if err != nil {
+			db.afterBatch(ctx, events, nil, err)
+			return nil, err
+		}
+
+		var evt *QueryEvent
+		ctx, evt, err = db.beforeQuery(ctx, ormDB, models[i], q.query, q.params, fmtedQuery)
+		if err != nil {
+			db.afterBatch(ctx, events, nil, err)
+			return nil, err
+		}
+		if evt != nil {
+			events = append(events, evt)
+		}
+	}
+	writeSyncMsg(wb)
+
+	var results []*result
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			if err := internal.Sleep(ctx, db.retryBackoff(attempt-1)); err != nil {
+				return nil, err
+			}
+		}
+
+		lastErr = withConn(ctx, func(ctx context.Context, cn *pool.Conn) error {
+			if err := cn.WriteBuffer(ctx, db.opt.WriteTimeout, wb); err != nil {
+				return err
+			}
+			err := cn.WithReader(ctx, db.opt.ReadTimeout, func(rd *pool.ReaderContext) error {
+				var err error
+				results, err = readBatch(ctx, rd, models)
+				return err
+			})
+			attachBatchNotices(ctx, results, err)
+			return err
+		})
+		if !db.shouldRetry(lastErr) {
+			break
+		}
+	}
+
+	if lastErr != nil {
+		results = nil
+	}
+	if err := db.afterBatch(ctx, events, results, lastErr); err != nil {
+		return nil, err
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+
+	res := make([]Result, len(results))
+	for i, r := range results {
+		res[i] = r
+	}
+	return res, nil
+}
+
+func (db *baseDB) afterBatch(
+	ctx context.Context, events []*QueryEvent, results []*result, err error,
+) error {
+	var firstErr error
+	for i, evt := range events {
+		var res Result
+		if results != nil {
+			res = results[i]
+		}
+		if err := db.afterQuery(ctx, evt, res, err); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// appendExtQuery formats the query for the extended query protocol
+// binding params if they are enabled for the query.
+func (db *baseDB) appendExtQuery(
+	b []byte, query interface{}, params ...interface{},
+) ([]byte, []interface{}, error) {
+	if db.bindsParams(query, params) {
+		return appendBindQuery(db.fmter, b, query, params...)
+	}
+	b, err := appendQuery(db.fmter, b, query, params...)
+	return b, nil, err
+}
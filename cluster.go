@@ -0,0 +1,405 @@
+package pg
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-pg/pg/v10/internal"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// ReplicaBalancing is a policy of selecting a replica for a query.
+type ReplicaBalancing int
+
+const (
+	// RoundRobin selects replicas in turn.
+	RoundRobin ReplicaBalancing = iota
+	// LeastConns selects the replica with the fewest connections in use.
+	LeastConns
+)
+
+// ClusterOptions are used to configure a ClusterDB.
+type ClusterOptions struct {
+	// Policy of selecting a replica for a query.
+	// Default is RoundRobin.
+	Balancing ReplicaBalancing
+
+	// Frequency of replica health checks. Queries are not routed to
+	// replicas that failed the last check.
+	// Default is 5 seconds. -1 disables health checks.
+	HealthCheckFrequency time.Duration
+	// Timeout of a replica health check.
+	// Default is 1 second.
+	HealthCheckTimeout time.Duration
+}
+
+func (opt *ClusterOptions) init() {
+	switch opt.HealthCheckFrequency {
+	case -1:
+		opt.HealthCheckFrequency = 0
+	case 0:
+		opt.HealthCheckFrequency = 5 * time.Second
+	}
+	if opt.HealthCheckTimeout == 0 {
+		opt.HealthCheckTimeout = time.Second
+	}
+}
+
+type usePrimaryKey struct{}
+
+// UsePrimary returns a copy of the context that makes ClusterDB send
+// all queries to the primary.
+func UsePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, usePrimaryKey{}, true)
+}
+
+func usesPrimary(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	v, _ := ctx.Value(usePrimaryKey{}).(bool)
+	return v
+}
+
+type clusterReplica struct {
+	db      *DB
+	healthy uint32 // atomic
+}
+
+func (r *clusterReplica) isHealthy() bool {
+	return atomic.LoadUint32(&r.healthy) == 1
+}
+
+func (r *clusterReplica) setHealthy(healthy bool) {
+	var v uint32
+	if healthy {
+		v = 1
+	}
+	atomic.StoreUint32(&r.healthy, v)
+}
+
+// ClusterDB routes queries to a primary database and its replicas.
+// Select queries, Query and CopyTo are sent to replicas; all other queries
+// and transactions are sent to the primary. Selects that lock rows, e.g.
+// with FOR UPDATE or Query.For, are sent to the primary too. Use UsePrimary
+// or Query.UsePrimary to send a read query to the primary.
+//
+// ClusterDB is safe for concurrent use by multiple goroutines.
+type ClusterDB struct {
+	*cluster
+	ctx context.Context
+}
+
+type cluster struct {
+	opt      *ClusterOptions
+	primary  *DB
+	replicas []*clusterReplica
+	next     uint32 // atomic
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+var _ orm.DB = (*ClusterDB)(nil)
+
+// NewClusterDB returns a ClusterDB for the primary and the replicas.
+// Closing the ClusterDB closes all of them.
+func NewClusterDB(opt *ClusterOptions, primary *DB, replicas ...*DB) *ClusterDB {
+	if opt == nil {
+		opt = new(ClusterOptions)
+	}
+	opt.init()
+
+	db := &ClusterDB{
+		cluster: &cluster{
+			opt:     opt,
+			primary: primary,
+			closed:  make(chan struct{}),
+		},
+		ctx: context.Background(),
+	}
+	for _, replica := range replicas {
+		r := &clusterReplica{db: replica}
+		r.setHealthy(true)
+		db.replicas = append(db.replicas, r)
+	}
+
+	if opt.HealthCheckFrequency > 0 && len(db.replicas) > 0 {
+		go db.healthCheckLoop()
+	}
+
+	return db
+}
+
+// Primary returns the primary database.
+func (db *ClusterDB) Primary() *DB {
+	return db.primary
+}
+
+// Replicas returns the replica databases.
+func (db *ClusterDB) Replicas() []*DB {
+	replicas := make([]*DB, len(db.replicas))
+	for i, r := range db.replicas {
+		replicas[i] = r.db
+	}
+	return replicas
+}
+
+// Close closes the primary and the replicas.
+func (db *ClusterDB) Close() error {
+	var firstErr error
+	db.closeOnce.Do(func() {
+		close(db.closed)
+
+		firstErr = db.primary.Close()
+		for _, r := range db.replicas {
+			if err := r.db.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	})
+	return firstErr
+}
+
+func (db *ClusterDB) healthCheckLoop() {
+	db.healthCheck()
+
+	ticker := time.NewTicker(db.opt.HealthCheckFrequency)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			db.healthCheck()
+		case <-db.closed:
+			return
+		}
+	}
+}
+
+func (db *ClusterDB) healthCheck() {
+	var wg sync.WaitGroup
+	for _, r := range db.replicas {
+		wg.Add(1)
+		go func(r *clusterReplica) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), db.opt.HealthCheckTimeout)
+			defer cancel()
+
+			err := r.db.Ping(ctx)
+			if err != nil && r.isHealthy() {
+				internal.Logger.Printf(ctx, "pg: replica %s is unhealthy: %s", r.db.opt.Addr, err)
+			}
+			r.setHealthy(err == nil)
+		}(r)
+	}
+	wg.Wait()
+}
+
+// replica returns a healthy replica or the primary if there are none.
+func (db *ClusterDB) replica() *DB {
+	switch db.opt.Balancing {
+	case LeastConns:
+		var best *DB
+		var bestConns uint32
+		for _, r := range db.replicas {
+			if !r.isHealthy() {
+				continue
+			}
+			stats := r.db.PoolStats()
+			conns := stats.TotalConns - stats.IdleConns
+			if best == nil || conns < bestConns {
+				best, bestConns = r.db, conns
+			}
+		}
+		if best != nil {
+			return best
+		}
+	default:
+		n := uint32(len(db.replicas))
+		start := atomic.AddUint32(&db.next, 1) - 1
+		for i := uint32(0); i < n; i++ {
+			r := db.replicas[(start+i)%n]
+			if r.isHealthy() {
+				return r.db
+			}
+		}
+	}
+	return db.primary
+}
+
+// route returns the database for the query. Read queries are sent to
+// replicas, but ORM queries are routed by their operation. Selects that
+// lock rows with FOR UPDATE or FOR SHARE are sent to the primary, because
+// they can't run on a hot standby.
+func (db *ClusterDB) route(ctx context.Context, query interface{}, read bool) *DB {
+	if usesPrimary(ctx) {
+		return db.primary
+	}
+
+	if q, ok := query.(*orm.Query); ok {
+		// Query is appended as a select query.
+		query = orm.NewSelectQuery(q)
+	}
+	if q, ok := query.(orm.QueryCommand); ok {
+		if q.Operation() != orm.SelectOp {
+			return db.primary
+		}
+		if q := q.Query(); q != nil && q.UsesPrimary() {
+			return db.primary
+		}
+		return db.replica()
+	}
+
+	if read && !isLockingSelect(query) {
+		return db.replica()
+	}
+	return db.primary
+}
+
+var lockingClauseRe = regexp.MustCompile(`(?i)\bFOR\s+(UPDATE|NO\s+KEY\s+UPDATE|SHARE|KEY\s+SHARE)\b`)
+
+// isLockingSelect reports whether the raw query contains a locking clause.
+func isLockingSelect(query interface{}) bool {
+	q, ok := query.(string)
+	return ok && lockingClauseRe.MatchString(q)
+}
+
+// Context returns ClusterDB context.
+func (db *ClusterDB) Context() context.Context {
+	return db.ctx
+}
+
+// WithContext returns a copy of the ClusterDB that uses the ctx.
+func (db *ClusterDB) WithContext(ctx context.Context) *ClusterDB {
+	return &ClusterDB{
+		cluster: db.cluster,
+		ctx:     ctx,
+	}
+}
+
+// Formatter returns the formatter of the primary.
+func (db *ClusterDB) Formatter() orm.QueryFormatter {
+	return db.primary.Formatter()
+}
+
+// AddQueryHook adds a hook into query processing of the primary and the replicas.
+func (db *ClusterDB) AddQueryHook(hook QueryHook) {
+	db.primary.AddQueryHook(hook)
+	for _, r := range db.replicas {
+		r.db.AddQueryHook(hook)
+	}
+}
+
+// Model returns new query for the model.
+func (db *ClusterDB) Model(model ...interface{}) *Query {
+	return orm.NewQuery(db, model...)
+}
+
+func (db *ClusterDB) ModelContext(c context.Context, model ...interface{}) *Query {
+	return orm.NewQueryContext(c, db, model...)
+}
+
+// Exec executes a query on the primary unless it is a select ORM query.
+func (db *ClusterDB) Exec(query interface{}, params ...interface{}) (Result, error) {
+	return db.ExecContext(db.ctx, query, params...)
+}
+
+func (db *ClusterDB) ExecContext(c context.Context, query interface{}, params ...interface{}) (Result, error) {
+	return db.route(c, query, false).ExecContext(c, query, params...)
+}
+
+// ExecOne acts like Exec, but query must affect only one row.
+func (db *ClusterDB) ExecOne(query interface{}, params ...interface{}) (Result, error) {
+	return db.ExecOneContext(db.ctx, query, params...)
+}
+
+func (db *ClusterDB) ExecOneContext(c context.Context, query interface{}, params ...interface{}) (Result, error) {
+	return db.route(c, query, false).ExecOneContext(c, query, params...)
+}
+
+// Query executes a query on a replica unless it is a non-select ORM query.
+func (db *ClusterDB) Query(model, query interface{}, params ...interface{}) (Result, error) {
+	return db.QueryContext(db.ctx, model, query, params...)
+}
+
+func (db *ClusterDB) QueryContext(c context.Context, model, query interface{}, params ...interface{}) (Result, error) {
+	return db.route(c, query, true).QueryContext(c, model, query, params...)
+}
+
+// QueryOne acts like Query, but query must return only one row.
+func (db *ClusterDB) QueryOne(model, query interface{}, params ...interface{}) (Result, error) {
+	return db.QueryOneContext(db.ctx, model, query, params...)
+}
+
+func (db *ClusterDB) QueryOneContext(
+	c context.Context, model, query interface{}, params ...interface{},
+) (Result, error) {
+	return db.route(c, query, true).QueryOneContext(c, model, query, params...)
+}
+
+// CopyFrom copies data from reader to a table on the primary.
+func (db *ClusterDB) CopyFrom(r io.Reader, query interface{}, params ...interface{}) (Result, error) {
+	return db.primary.CopyFrom(r, query, params...)
+}
+
+// CopyTo copies data from a table on a replica to writer.
+func (db *ClusterDB) CopyTo(w io.Writer, query interface{}, params ...interface{}) (Result, error) {
+	return db.route(db.ctx, query, true).CopyTo(w, query, params...)
+}
+
+// Begin starts a transaction on the primary.
+func (db *ClusterDB) Begin() (*Tx, error) {
+	return db.primary.BeginContext(db.ctx)
+}
+
+// BeginContext starts a transaction on the primary.
+func (db *ClusterDB) BeginContext(ctx context.Context) (*Tx, error) {
+	return db.primary.BeginContext(ctx)
+}
+
+// BeginTx starts a transaction with the options on the primary.
+func (db *ClusterDB) BeginTx(ctx context.Context, opt *TxOptions) (*Tx, error) {
+	return db.primary.BeginTx(ctx, opt)
+}
+
+// RunInTransaction runs a function in a transaction on the primary.
+func (db *ClusterDB) RunInTransaction(ctx context.Context, fn func(*Tx) error) error {
+	return db.primary.RunInTransaction(ctx, fn)
+}
+
+// RunInTransactionTx runs a function in a transaction with the options
+// on the primary.
+func (db *ClusterDB) RunInTransactionTx(ctx context.Context, opt *TxOptions, fn func(*Tx) error) error {
+	return db.primary.RunInTransactionTx(ctx, opt, fn)
+}
+
+// CommitPrepared commits the prepared transaction on the primary.
+func (db *ClusterDB) CommitPrepared(ctx context.Context, gid string) error {
+	return db.primary.CommitPrepared(ctx, gid)
+}
+
+// RollbackPrepared rolls back the prepared transaction on the primary.
+func (db *ClusterDB) RollbackPrepared(ctx context.Context, gid string) error {
+	return db.primary.RollbackPrepared(ctx, gid)
+}
+
+// ListPrepared returns the prepared transactions on the primary.
+func (db *ClusterDB) ListPrepared(ctx context.Context) ([]PreparedTx, error) {
+	return db.primary.ListPrepared(ctx)
+}
+
+// Prepare creates a prepared statement on the primary.
+func (db *ClusterDB) Prepare(q string) (*Stmt, error) {
+	return db.primary.Prepare(q)
+}
+
+// SendBatch sends the batch to the primary.
+func (db *ClusterDB) SendBatch(ctx context.Context, batch *Batch) ([]Result, error) {
+	return db.primary.SendBatch(ctx, batch)
+}
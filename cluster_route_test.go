@@ -0,0 +1,48 @@
+package pg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClusterDB(replicas int) *ClusterDB {
+	primary := Connect(&Options{})
+	dbs := make([]*DB, replicas)
+	for i := range dbs {
+		dbs[i] = Connect(&Options{})
+	}
+	return NewClusterDB(&ClusterOptions{HealthCheckFrequency: -1}, primary, dbs...)
+}
+
+func TestClusterReplicaRoundRobin(t *testing.T) {
+	db := newTestClusterDB(3)
+	defer db.Close()
+
+	for i := 0; i < 6; i++ {
+		assert.Same(t, db.replicas[i%3].db, db.replica())
+	}
+
+	db.replicas[1].setHealthy(false)
+	assert.Same(t, db.replicas[0].db, db.replica())
+	assert.Same(t, db.replicas[2].db, db.replica())
+	assert.Same(t, db.replicas[2].db, db.replica())
+}
+
+func TestClusterRouteLockingSelect(t *testing.T) {
+	db := newTestClusterDB(1)
+	defer db.Close()
+
+	ctx := context.Background()
+	replica := db.replicas[0].db
+
+	assert.Same(t, replica, db.route(ctx, "SELECT * FROM t", true))
+	assert.Same(t, db.primary, db.route(ctx, "SELECT * FROM t FOR UPDATE", true))
+	assert.Same(t, db.primary, db.route(ctx, "select * from t for no key update nowait", true))
+	assert.Same(t, db.primary, db.route(ctx, "SELECT * FROM t FOR KEY SHARE", true))
+
+	q := db.Model().Table("t")
+	assert.Same(t, replica, db.route(ctx, q, true))
+	assert.Same(t, db.primary, db.route(ctx, q.For("UPDATE"), true))
+}
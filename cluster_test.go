@@ -0,0 +1,172 @@
+package pg_test
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/go-pg/pg/v10"
+)
+
+type countingHook struct {
+	count int32
+}
+
+var _ pg.QueryHook = (*countingHook)(nil)
+
+func (h *countingHook) BeforeQuery(ctx context.Context, _ *pg.QueryEvent) (context.Context, error) {
+	atomic.AddInt32(&h.count, 1)
+	return ctx, nil
+}
+
+func (h *countingHook) AfterQuery(context.Context, *pg.QueryEvent) error {
+	return nil
+}
+
+func (h *countingHook) reset() int {
+	return int(atomic.SwapInt32(&h.count, 0))
+}
+
+var _ = Describe("ClusterDB", func() {
+	type ClusterModel struct {
+		Id int
+	}
+
+	var db *pg.ClusterDB
+	var primaryHook, replicaHook *countingHook
+
+	BeforeEach(func() {
+		primary := pg.Connect(pgOptions())
+		replica := pg.Connect(pgOptions())
+
+		primaryHook = new(countingHook)
+		primary.AddQueryHook(primaryHook)
+		replicaHook = new(countingHook)
+		replica.AddQueryHook(replicaHook)
+
+		db = pg.NewClusterDB(&pg.ClusterOptions{
+			HealthCheckFrequency: -1,
+		}, primary, replica)
+
+		_, err := db.Exec("DROP TABLE IF EXISTS cluster_models")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = db.Model((*ClusterModel)(nil)).CreateTable(nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		primaryHook.reset()
+		replicaHook.reset()
+	})
+
+	AfterEach(func() {
+		_, err := db.Exec("DROP TABLE cluster_models")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(db.Close()).NotTo(HaveOccurred())
+	})
+
+	It("sends writes to the primary", func() {
+		_, err := db.Model(&ClusterModel{Id: 1}).Insert()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = db.Model(&ClusterModel{Id: 1}).WherePK().Update()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = db.Model(&ClusterModel{Id: 1}).WherePK().Delete()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(primaryHook.reset()).To(Equal(3))
+		Expect(replicaHook.reset()).To(Equal(0))
+	})
+
+	It("sends reads to replicas", func() {
+		var models []ClusterModel
+		err := db.Model(&models).Select()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = db.Model(&models).Count()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = db.Model(&models).Exists()
+		Expect(err).NotTo(HaveOccurred())
+
+		var n int
+		_, err = db.QueryOne(pg.Scan(&n), "SELECT 1")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(primaryHook.reset()).To(Equal(0))
+		Expect(replicaHook.reset()).To(Equal(4))
+	})
+
+	It("sends reads to the primary on request", func() {
+		var models []ClusterModel
+		err := db.Model(&models).UsePrimary().Select()
+		Expect(err).NotTo(HaveOccurred())
+
+		var n int
+		_, err = db.QueryOneContext(pg.UsePrimary(ctx), pg.Scan(&n), "SELECT 1")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(primaryHook.reset()).To(Equal(2))
+		Expect(replicaHook.reset()).To(Equal(0))
+	})
+
+	It("sends locking selects to the primary", func() {
+		err := db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+			var models []ClusterModel
+			return tx.Model(&models).For("UPDATE").Select()
+		})
+		Expect(err).NotTo(HaveOccurred())
+		primaryHook.reset()
+
+		var models []ClusterModel
+		err = db.Model(&models).For("SHARE").Select()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = db.Query(&models, "SELECT * FROM cluster_models FOR UPDATE")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(primaryHook.reset()).To(Equal(2))
+		Expect(replicaHook.reset()).To(Equal(0))
+	})
+
+	It("sends transactions to the primary", func() {
+		err := db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+			var models []ClusterModel
+			return tx.Model(&models).Select()
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(primaryHook.reset()).To(Equal(3))
+		Expect(replicaHook.reset()).To(Equal(0))
+	})
+})
+
+var _ = Describe("ClusterDB health checks", func() {
+	It("does not use unhealthy replicas", func() {
+		primary := pg.Connect(pgOptions())
+		primaryHook := new(countingHook)
+		primary.AddQueryHook(primaryHook)
+
+		opt := pgOptions()
+		opt.Addr = "127.0.0.1:1"
+		opt.MaxRetries = 0
+		replica := pg.Connect(opt)
+
+		db := pg.NewClusterDB(&pg.ClusterOptions{
+			Balancing:            pg.LeastConns,
+			HealthCheckFrequency: 10 * time.Millisecond,
+		}, primary, replica)
+		defer db.Close()
+
+		Eventually(func() error {
+			var n int
+			_, err := db.QueryOne(pg.Scan(&n), "SELECT 1")
+			return err
+		}).ShouldNot(HaveOccurred())
+		Expect(primaryHook.reset()).To(BeNumerically(">=", 1))
+	})
+})
@@ -0,0 +1,123 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-pg/pg/v10/internal/pool"
+)
+
+var errCopyBothClosed = errors.New("pg: CopyBoth is closed")
+
+// CopyBoth is a bidirectional COPY stream started with the CopyBoth
+// sub-protocol that is used by streaming replication.
+// It's NOT safe for concurrent use by multiple goroutines
+// except that SendData and Close may be called concurrently
+// with ReceiveData.
+type CopyBoth struct {
+	db *DB
+	cn *pool.Conn
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// CopyBoth runs a query that starts a CopyBoth stream, for example
+// START_REPLICATION on a replication connection. The stream uses
+// a dedicated connection that is closed with CopyBoth.Close.
+func (db *DB) CopyBoth(ctx context.Context, query interface{}, params ...interface{}) (*CopyBoth, error) {
+	cn, err := db.pool.NewConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.initConn(ctx, cn); err != nil {
+		_ = db.pool.CloseConn(cn)
+		return nil, err
+	}
+
+	cn.LockReader()
+
+	err = cn.WithWriter(ctx, db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
+		return writeQueryMsg(wb, db.fmter, query, params...)
+	})
+	if err == nil {
+		err = cn.WithReader(ctx, db.opt.ReadTimeout, readCopyBothResponse)
+	}
+	if err != nil {
+		_ = db.pool.CloseConn(cn)
+		return nil, err
+	}
+
+	return &CopyBoth{
+		db: db,
+		cn: cn,
+	}, nil
+}
+
+// ReceiveData waits for the next CopyData message and returns its payload.
+// It returns io.EOF when the server ends the stream.
+func (c *CopyBoth) ReceiveData(ctx context.Context) ([]byte, error) {
+	return c.ReceiveDataTimeout(ctx, 0)
+}
+
+// ReceiveDataTimeout is like ReceiveData, but waits until timeout is reached.
+// A timeout in the middle of a message leaves the stream unusable.
+func (c *CopyBoth) ReceiveDataTimeout(ctx context.Context, timeout time.Duration) ([]byte, error) {
+	if c.isClosed() {
+		return nil, errCopyBothClosed
+	}
+
+	var b []byte
+	err := c.cn.WithReader(ctx, timeout, func(rd *pool.ReaderContext) error {
+		var err error
+		b, err = readCopyBothData(rd)
+		return err
+	})
+	if err != nil {
+		if c.isClosed() {
+			return nil, errCopyBothClosed
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+// SendData sends a CopyData message with the payload.
+func (c *CopyBoth) SendData(ctx context.Context, b []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return errCopyBothClosed
+	}
+
+	return c.cn.WithWriter(ctx, c.db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
+		wb.StartMessage(copyDataMsg)
+		_, _ = wb.Write(b)
+		wb.FinishMessage()
+		return nil
+	})
+}
+
+func (c *CopyBoth) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// Close closes the stream and its connection. Blocked ReceiveData
+// calls return an error.
+func (c *CopyBoth) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return errCopyBothClosed
+	}
+	c.closed = true
+
+	return c.db.pool.CloseConn(c.cn)
+}
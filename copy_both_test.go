@@ -0,0 +1,100 @@
+package pg
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyBoth(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	go serveCopyBoth(ln)
+
+	db := Connect(&Options{
+		Addr:    ln.Addr().String(),
+		SSLMode: "disable",
+	})
+	defer db.Close()
+
+	ctx := context.Background()
+	cb, err := db.CopyBoth(ctx, "START_REPLICATION SLOT ? LOGICAL 0/0", Ident("test"))
+	assert.NoError(t, err)
+
+	b, err := cb.ReceiveData(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+
+	assert.NoError(t, cb.SendData(ctx, []byte("world")))
+	b, err = cb.ReceiveData(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "world", string(b))
+
+	_, err = cb.ReceiveData(ctx)
+	assert.Equal(t, io.EOF, err)
+
+	assert.NoError(t, cb.Close())
+	_, err = cb.ReceiveData(ctx)
+	assert.Equal(t, errCopyBothClosed, err)
+	assert.Equal(t, errCopyBothClosed, cb.SendData(ctx, nil))
+}
+
+// serveCopyBoth accepts a startup message and a query, then sends
+// a CopyData message, echoes one CopyData message and ends the copy.
+func serveCopyBoth(ln net.Listener) {
+	cn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer cn.Close()
+
+	readMsg := func(hasType bool) (byte, []byte, error) {
+		var c [1]byte
+		if hasType {
+			if _, err := io.ReadFull(cn, c[:]); err != nil {
+				return 0, nil, err
+			}
+		}
+		var size [4]byte
+		if _, err := io.ReadFull(cn, size[:]); err != nil {
+			return 0, nil, err
+		}
+		b := make([]byte, binary.BigEndian.Uint32(size[:])-4)
+		_, err := io.ReadFull(cn, b)
+		return c[0], b, err
+	}
+	writeMsg := func(c byte, b []byte) {
+		msg := []byte{c, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(msg[1:], uint32(len(b)+4))
+		_, _ = cn.Write(append(msg, b...))
+	}
+
+	if _, _, err := readMsg(false); err != nil {
+		return
+	}
+	writeMsg('R', []byte{0, 0, 0, 0})
+	writeMsg('Z', []byte{'I'})
+
+	if c, b, err := readMsg(true); err != nil || c != 'Q' ||
+		string(b) != `START_REPLICATION SLOT "test" LOGICAL 0/0`+"\x00" {
+		return
+	}
+	writeMsg('W', []byte{0, 0, 0})
+	writeMsg('d', []byte("hello"))
+
+	c, b, err := readMsg(true)
+	if err != nil || c != 'd' {
+		return
+	}
+	writeMsg('d', b)
+	writeMsg('c', nil)
+
+	_, _ = io.Copy(io.Discard, cn)
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto/tls"
 	"database/sql"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -749,6 +750,220 @@ var _ = Describe("CopyFrom/CopyTo", func() {
 	})
 })
 
+var _ = Describe("Cursor", func() {
+	type CursorModel struct {
+		tableName struct{} `pg:"cursor_models"`
+
+		Id int
+	}
+
+	var db *pg.DB
+	var tx *pg.Tx
+
+	BeforeEach(func() {
+		db = pg.Connect(pgOptions())
+
+		var err error
+		tx, err = db.Begin()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = tx.Exec(`
+			CREATE TEMP TABLE cursor_models AS
+			SELECT id FROM generate_series(1, 10) AS id`)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(tx.Rollback()).NotTo(HaveOccurred())
+		Expect(db.Close()).NotTo(HaveOccurred())
+	})
+
+	It("fetches rows in batches", func() {
+		cur, err := tx.Model((*CursorModel)(nil)).Where("id > 2").Order("id").Cursor(ctx, 3)
+		Expect(err).NotTo(HaveOccurred())
+
+		var ids []int
+		var models []CursorModel
+		for cur.Next(&models) {
+			Expect(len(models)).To(BeNumerically("<=", 3))
+			for _, m := range models {
+				ids = append(ids, m.Id)
+			}
+		}
+		Expect(cur.Err()).NotTo(HaveOccurred())
+		Expect(ids).To(Equal([]int{3, 4, 5, 6, 7, 8, 9, 10}))
+
+		var n int
+		_, err = tx.QueryOne(pg.Scan(&n), "SELECT count(*) FROM pg_cursors")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(0))
+	})
+
+	It("closes cursor", func() {
+		cur, err := tx.Model((*CursorModel)(nil)).Cursor(ctx, 3)
+		Expect(err).NotTo(HaveOccurred())
+
+		var models []CursorModel
+		Expect(cur.Next(&models)).To(BeTrue())
+		Expect(cur.Close()).NotTo(HaveOccurred())
+		Expect(cur.Next(&models)).To(BeFalse())
+		Expect(cur.Err()).NotTo(HaveOccurred())
+	})
+})
+
+var _ = Describe("CopyFromModel/CopyToModel", func() {
+	type CopyModel struct {
+		tableName struct{} `pg:"copy_models"`
+
+		Id    int
+		Value string
+		Tags  []string `pg:",array"`
+	}
+
+	var db *pg.DB
+
+	BeforeEach(func() {
+		db = pg.Connect(pgOptions())
+
+		_, err := db.Exec("CREATE TEMP TABLE copy_models(id int, value text, tags text[])")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(db.Close()).NotTo(HaveOccurred())
+	})
+
+	It("copies slice model", func() {
+		rows := []CopyModel{
+			{Id: 1, Value: "tab\tnewline\nbackslash\\", Tags: []string{"a", "b"}},
+			{Id: 2},
+		}
+		res, err := db.Model(&rows).CopyFromModel()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RowsAffected()).To(Equal(2))
+
+		var got []CopyModel
+		err = db.Model(&got).Order("id").Select()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got).To(Equal(rows))
+	})
+
+	It("copies rows from channel", func() {
+		ch := make(chan *CopyModel)
+		go func() {
+			for i := 1; i <= 1000; i++ {
+				ch <- &CopyModel{Id: i}
+			}
+			close(ch)
+		}()
+
+		res, err := db.Model((*CopyModel)(nil)).Column("id").CopyFromModel(ch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RowsAffected()).To(Equal(1000))
+
+		count, err := db.Model((*CopyModel)(nil)).Count()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count).To(Equal(1000))
+	})
+
+	It("copies slice model in binary format", func() {
+		rows := []CopyModel{
+			{Id: 1, Value: "tab\tnewline\nbackslash\\", Tags: []string{"a", "b"}},
+			{Id: 2},
+		}
+		res, err := db.Model(&rows).CopyBinary().CopyFromModel()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RowsAffected()).To(Equal(2))
+
+		var got []CopyModel
+		_, err = db.Model(&got).Order("id").CopyBinary().CopyToModel()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got).To(Equal(rows))
+	})
+
+	It("aborts COPY when iterator fails", func() {
+		tx, err := db.Begin()
+		Expect(err).NotTo(HaveOccurred())
+		defer tx.Rollback()
+
+		var i int
+		next := func() (interface{}, error) {
+			if i == 3 {
+				return nil, errors.New("iterator failed")
+			}
+			i++
+			return CopyModel{Id: i}, nil
+		}
+		_, err = tx.Model((*CopyModel)(nil)).Column("id").CopyFromModel(next)
+		Expect(err).To(MatchError("iterator failed"))
+
+		// The connection is ready for the next query in the aborted tx.
+		_, err = tx.Exec("SELECT 1")
+		Expect(err).To(MatchError("ERROR #25P02 current transaction is aborted, " +
+			"commands ignored until end of transaction block"))
+		Expect(tx.Rollback()).NotTo(HaveOccurred())
+
+		i = 0
+		_, err = db.Model((*CopyModel)(nil)).Column("id").CopyFromModel(next)
+		Expect(err).To(MatchError("iterator failed"))
+
+		count, err := db.Model((*CopyModel)(nil)).Count()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count).To(Equal(0))
+	})
+
+	It("rejects zero values of columns with a default", func() {
+		type SerialModel struct {
+			tableName struct{} `pg:"copy_serial_models"`
+
+			Id    int
+			Value string
+		}
+
+		_, err := db.Exec("CREATE TEMP TABLE copy_serial_models(id serial, value text)")
+		Expect(err).NotTo(HaveOccurred())
+
+		rows := []SerialModel{{Value: "foo"}, {Value: "bar"}}
+		_, err = db.Model(&rows).CopyFromModel()
+		Expect(err).To(MatchError(`pg: CopyFromModel can't use the default of column="id" ` +
+			"for zero SerialModel.Id (set the field, exclude the column or use the use_zero tag option)"))
+
+		res, err := db.Model(&rows).ExcludeColumn("id").CopyFromModel()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RowsAffected()).To(Equal(2))
+
+		var ids []int
+		err = db.Model((*SerialModel)(nil)).Column("id").Order("id").Select(&ids)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids).To(Equal([]int{1, 2}))
+	})
+
+	It("copies table into slice model", func() {
+		rows := []CopyModel{
+			{Id: 1, Value: "tab\tnewline\nbackslash\\", Tags: []string{"a", "b"}},
+			{Id: 2},
+			{Id: 3, Value: "foo"},
+		}
+		_, err := db.Model(&rows).CopyFromModel()
+		Expect(err).NotTo(HaveOccurred())
+
+		var got []CopyModel
+		res, err := db.Model(&got).Where("id < 3").Order("id").CopyToModel()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RowsAffected()).To(Equal(2))
+		Expect(got).To(Equal(rows[:2]))
+
+		var ids []int
+		_, err = db.Model((*CopyModel)(nil)).Column("id").Order("id").
+			CopyToForEach(func(row *CopyModel) error {
+				ids = append(ids, row.Id)
+				return nil
+			})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids).To(Equal([]int{1, 2, 3}))
+	})
+})
+
 var _ = Describe("CountEstimate", func() {
 	var db *pg.DB
 
@@ -1030,6 +1245,462 @@ var _ = Describe("DB.Delete", func() {
 	})
 })
 
+var _ = Describe("multiple hosts", func() {
+	It("skips unreachable hosts", func() {
+		opt := pgOptions()
+		opt.Addr = "127.0.0.1:1,localhost:5432"
+		opt.TargetSessionAttrs = "read-write"
+		db := pg.Connect(opt)
+		defer db.Close()
+
+		err := db.Ping(ctx)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("skips hosts that do not match target_session_attrs", func() {
+		opt := pgOptions()
+		opt.Addr = "localhost:5432,127.0.0.1:5432"
+		opt.TargetSessionAttrs = "standby"
+		db := pg.Connect(opt)
+		defer db.Close()
+
+		err := db.Ping(ctx)
+		Expect(err).To(MatchError(
+			"pg: session on 127.0.0.1:5432 does not match target_session_attrs=standby"))
+	})
+
+	It("falls back to any host with prefer-standby", func() {
+		opt := pgOptions()
+		opt.Addr = "localhost:5432,127.0.0.1:5432"
+		opt.TargetSessionAttrs = "prefer-standby"
+		db := pg.Connect(opt)
+		defer db.Close()
+
+		err := db.Ping(ctx)
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
+
+var _ = Describe("ServerInfo", func() {
+	var db *pg.DB
+
+	BeforeEach(func() {
+		db = pg.Connect(pgOptions())
+	})
+
+	AfterEach(func() {
+		Expect(db.Close()).NotTo(HaveOccurred())
+	})
+
+	It("returns server parameters and backend process", func() {
+		info, err := db.ServerInfo(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.ServerVersion).NotTo(BeEmpty())
+		Expect(info.ServerVersionNum).To(BeNumerically(">=", 90600))
+		Expect(info.IntegerDatetimes).To(BeTrue())
+		Expect(info.StandardConformingStrings).To(BeTrue())
+		Expect(info.Params).To(HaveKey("client_encoding"))
+
+		var versionNum int
+		_, err = db.QueryOne(pg.Scan(&versionNum), "SHOW server_version_num")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.ServerVersionNum).To(Equal(versionNum))
+	})
+
+	It("tracks parameter changes on the connection", func() {
+		conn := db.Conn()
+		defer conn.Close()
+
+		_, err := conn.Exec("SET TimeZone = 'Europe/Berlin'")
+		Expect(err).NotTo(HaveOccurred())
+
+		info, err := conn.ServerInfo(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.TimeZone).To(Equal("Europe/Berlin"))
+
+		var pid int32
+		_, err = conn.QueryOne(pg.Scan(&pid), "SELECT pg_backend_pid()")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.ProcessID).To(Equal(pid))
+	})
+})
+
+var _ = Describe("OnNotice", func() {
+	var db *pg.DB
+	var notices []pg.Notice
+
+	BeforeEach(func() {
+		notices = nil
+
+		opt := pgOptions()
+		opt.OnNotice = func(ctx context.Context, notice pg.Notice) {
+			notices = append(notices, notice)
+		}
+		db = pg.Connect(opt)
+	})
+
+	AfterEach(func() {
+		Expect(db.Close()).NotTo(HaveOccurred())
+	})
+
+	It("calls OnNotice and collects notices into the result", func() {
+		c := pg.CollectNotices(ctx)
+		res, err := db.ExecContext(c, `DO $$
+		BEGIN
+			RAISE NOTICE 'hello %', 'world' USING HINT = 'some hint';
+			RAISE WARNING 'careful';
+		END $$`)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(notices).To(HaveLen(2))
+		Expect(notices[0].Severity).To(Equal("NOTICE"))
+		Expect(notices[0].Message).To(Equal("hello world"))
+		Expect(notices[0].Hint).To(Equal("some hint"))
+		Expect(notices[1].Severity).To(Equal("WARNING"))
+		Expect(notices[1].Code).To(Equal("01000"))
+
+		Expect(pg.Notices(res)).To(Equal(notices))
+
+		res, err = db.ExecContext(c, "SELECT 1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pg.Notices(res)).To(BeEmpty())
+	})
+
+	It("does not attach notices of a failed query to the next result", func() {
+		c := pg.CollectNotices(ctx)
+		_, err := db.ExecContext(c, `DO $$
+		BEGIN
+			RAISE NOTICE 'before error';
+			RAISE EXCEPTION 'failed';
+		END $$`)
+		Expect(err).To(HaveOccurred())
+
+		errNotices := pg.ErrorNotices(c)
+		Expect(errNotices).To(HaveLen(1))
+		Expect(errNotices[0].Message).To(Equal("before error"))
+
+		res, err := db.ExecContext(c, "SELECT 1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pg.Notices(res)).To(BeEmpty())
+		Expect(pg.ErrorNotices(c)).To(BeEmpty())
+	})
+
+	It("does not collect notices without CollectNotices", func() {
+		res, err := db.Exec("DO $$ BEGIN RAISE NOTICE 'hello'; END $$")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(notices).To(HaveLen(1))
+		Expect(pg.Notices(res)).To(BeEmpty())
+	})
+})
+
+var _ = Describe("RuntimeParams", func() {
+	var db *pg.DB
+
+	BeforeEach(func() {
+		opt := pgOptions()
+		opt.RuntimeParams = map[string]string{
+			"search_path":       "pg_catalog",
+			"statement_timeout": "1234",
+			"options":           "-c lock_timeout=5s",
+		}
+		db = pg.Connect(opt)
+	})
+
+	AfterEach(func() {
+		Expect(db.Close()).NotTo(HaveOccurred())
+	})
+
+	It("sets run-time parameters in the startup message", func() {
+		var searchPath, statementTimeout, lockTimeout string
+		_, err := db.QueryOne(pg.Scan(&searchPath, &statementTimeout, &lockTimeout),
+			"SELECT current_setting('search_path'), current_setting('statement_timeout'), "+
+				"current_setting('lock_timeout')")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(searchPath).To(Equal("pg_catalog"))
+		Expect(statementTimeout).To(Equal("1234ms"))
+		Expect(lockTimeout).To(Equal("5s"))
+	})
+
+	It("returns an error for unknown parameters", func() {
+		opt := pgOptions()
+		opt.RuntimeParams = map[string]string{"no_such_param": "1"}
+		db := pg.Connect(opt)
+		defer db.Close()
+
+		err := db.Ping(ctx)
+		Expect(err).To(HaveOccurred())
+		Expect(err.(pg.Error).Field('C')).To(Equal("42704"))
+	})
+})
+
+var _ = Describe("BindParams", func() {
+	type BindParamsModel struct {
+		Id    int
+		Name  string
+		Tags  []string
+		Attrs map[string]string
+	}
+
+	var db *pg.DB
+
+	BeforeEach(func() {
+		opt := pgOptions()
+		opt.BindParams = true
+		db = pg.Connect(opt)
+
+		err := db.Model((*BindParamsModel)(nil)).CreateTable(&orm.CreateTableOptions{
+			Temp: true,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(db.Close()).NotTo(HaveOccurred())
+	})
+
+	It("inserts, updates, selects and deletes models", func() {
+		model := &BindParamsModel{
+			Id:    1,
+			Name:  "it's; DROP TABLE",
+			Tags:  []string{"a", "b"},
+			Attrs: map[string]string{"k": "v"},
+		}
+		_, err := db.Model(model).Insert()
+		Expect(err).NotTo(HaveOccurred())
+
+		model.Name = `\?`
+		_, err = db.Model(model).WherePK().Update()
+		Expect(err).NotTo(HaveOccurred())
+
+		got := new(BindParamsModel)
+		err = db.Model(got).Where("name = ?", model.Name).Select()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got).To(Equal(model))
+
+		res, err := db.Model(got).WherePK().Delete()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RowsAffected()).To(Equal(1))
+	})
+
+	It("sends placeholders in the query", func() {
+		hook := &bindParamsHook{}
+		db.AddQueryHook(hook)
+
+		var n int
+		err := db.Model((*BindParamsModel)(nil)).
+			ColumnExpr("count(*)").
+			Where("id = ?", 1).
+			Select(&n)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(0))
+		Expect(hook.query).To(ContainSubstring(`WHERE (id = $1)`))
+	})
+
+	It("binds params only for marked queries", func() {
+		db := pg.Connect(pgOptions())
+		defer db.Close()
+
+		var n int
+		err := db.Model().ColumnExpr("?::int + 1", 1).BindParams().Select(pg.Scan(&n))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(2))
+	})
+})
+
+var _ = Describe("StatementCacheSize", func() {
+	type StmtCacheModel struct {
+		Id   int
+		Name string
+	}
+
+	var db *pg.DB
+
+	BeforeEach(func() {
+		opt := pgOptions()
+		opt.BindParams = true
+		opt.StatementCacheSize = 2
+		opt.PoolSize = 1
+		db = pg.Connect(opt)
+
+		err := db.Model((*StmtCacheModel)(nil)).CreateTable(&orm.CreateTableOptions{
+			Temp: true,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(db.Close()).NotTo(HaveOccurred())
+	})
+
+	It("reuses and evicts statements", func() {
+		for i := 1; i <= 3; i++ {
+			_, err := db.Model(&StmtCacheModel{Id: i, Name: "name"}).Insert()
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, column := range []string{"id", "name", "id"} {
+				var models []StmtCacheModel
+				err = db.Model(&models).Column(column).Where("id = ?", i).Select()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(models).To(HaveLen(1))
+			}
+		}
+
+		var n int
+		_, err := db.QueryOne(pg.Scan(&n), "SELECT count(*) FROM pg_prepared_statements")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(2))
+	})
+
+	It("prepares statement again when result type changes", func() {
+		_, err := db.Model(&StmtCacheModel{Id: 1, Name: "name"}).Insert()
+		Expect(err).NotTo(HaveOccurred())
+
+		var models []StmtCacheModel
+		err = db.Model(&models).Where("id = ?", 1).Select()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = db.Exec("ALTER TABLE stmt_cache_models ALTER COLUMN name TYPE varchar(100)")
+		Expect(err).NotTo(HaveOccurred())
+
+		models = nil
+		err = db.Model(&models).Where("id = ?", 1).Select()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(models).To(Equal([]StmtCacheModel{{Id: 1, Name: "name"}}))
+	})
+
+	It("caches raw queries with $n placeholders", func() {
+		for i := 1; i <= 3; i++ {
+			_, err := db.Exec("INSERT INTO stmt_cache_models (id, name) VALUES ($1, $2)", i, "name")
+			Expect(err).NotTo(HaveOccurred())
+
+			var name string
+			_, err = db.QueryOne(pg.Scan(&name), "SELECT name FROM stmt_cache_models WHERE id = $1", i)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(name).To(Equal("name"))
+		}
+
+		var queries []string
+		_, err := db.Query(&queries, "SELECT statement FROM pg_prepared_statements ORDER BY statement")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(queries).To(Equal([]string{
+			"INSERT INTO stmt_cache_models (id, name) VALUES ($1, $2)",
+			"SELECT name FROM stmt_cache_models WHERE id = $1",
+		}))
+	})
+})
+
+var _ = Describe("Batch", func() {
+	type BatchModel struct {
+		Id   int
+		Name string
+	}
+
+	var db *pg.DB
+
+	BeforeEach(func() {
+		db = pg.Connect(pgOptions())
+
+		err := db.Model((*BatchModel)(nil)).CreateTable(&orm.CreateTableOptions{
+			Temp: true,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(db.Close()).NotTo(HaveOccurred())
+	})
+
+	It("executes queued queries in order", func() {
+		var batch pg.Batch
+		batch.Queue("INSERT INTO batch_models VALUES (?, ?), (?, ?)", nil, 1, "one", 2, "two")
+		batch.Queue("UPDATE batch_models SET name = upper(name)", nil)
+
+		var models []BatchModel
+		batch.Queue(db.Model(&models).Order("id"), nil)
+
+		var count int
+		batch.Queue("SELECT count(*) FROM batch_models", pg.Scan(&count))
+
+		results, err := db.SendBatch(ctx, &batch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(4))
+		Expect(results[0].RowsAffected()).To(Equal(2))
+		Expect(results[1].RowsAffected()).To(Equal(2))
+		Expect(results[2].RowsReturned()).To(Equal(2))
+		Expect(models).To(Equal([]BatchModel{{1, "ONE"}, {2, "TWO"}}))
+		Expect(count).To(Equal(2))
+	})
+
+	It("rolls back the batch on error", func() {
+		var batch pg.Batch
+		batch.Queue("INSERT INTO batch_models VALUES (1, 'one')", nil)
+		batch.Queue("SELECT 1/0", nil)
+
+		_, err := db.SendBatch(ctx, &batch)
+		Expect(err).To(MatchError("ERROR #22012 division by zero"))
+
+		n, err := db.Model((*BatchModel)(nil)).Count()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(0))
+	})
+
+	It("binds params", func() {
+		opt := pgOptions()
+		opt.BindParams = true
+		db := pg.Connect(opt)
+		defer db.Close()
+
+		hook := new(bindParamsHook)
+		db.AddQueryHook(hook)
+
+		var name string
+		var batch pg.Batch
+		batch.Queue(db.Model().ColumnExpr("?::text", "it's"), pg.Scan(&name))
+
+		_, err := db.SendBatch(ctx, &batch)
+		Expect(err).NotTo(HaveOccurred())
+		// The value is not in the query, so it was sent as a bound param.
+		Expect(hook.query).To(Equal(`SELECT $1::text`))
+		Expect(name).To(Equal("it's"))
+	})
+
+	It("binds params of queries marked with BindParams", func() {
+		db := pg.Connect(pgOptions())
+		defer db.Close()
+
+		hook := new(bindParamsHook)
+		db.AddQueryHook(hook)
+
+		var n int
+		var batch pg.Batch
+		batch.Queue(db.Model().ColumnExpr("?::int + 1", 1).BindParams(), pg.Scan(&n))
+
+		_, err := db.SendBatch(ctx, &batch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hook.query).To(Equal(`SELECT $1::int + 1`))
+		Expect(n).To(Equal(2))
+	})
+})
+
+type bindParamsHook struct {
+	query string
+}
+
+var _ pg.QueryHook = (*bindParamsHook)(nil)
+
+func (h *bindParamsHook) BeforeQuery(ctx context.Context, evt *pg.QueryEvent) (context.Context, error) {
+	b, err := evt.FormattedQuery()
+	if err != nil {
+		return nil, err
+	}
+	h.query = string(b)
+	return ctx, nil
+}
+
+func (h *bindParamsHook) AfterQuery(context.Context, *pg.QueryEvent) error {
+	return nil
+}
+
 var _ = Describe("errors", func() {
 	var db *pg.DB
 
@@ -2124,6 +2795,52 @@ var _ = Describe("ORM", func() {
 		Expect(ids).To(Equal([]int{100, 101, 102}))
 	})
 
+	It("maps constraint violations to fields", func() {
+		_, err := db.Model(&Author{ID: 13, Name: "author 1"}).Insert()
+		Expect(pg.IsUniqueViolation(err)).To(BeTrue())
+
+		var constraintErr *pg.ConstraintError
+		Expect(errors.As(err, &constraintErr)).To(BeTrue())
+		Expect(constraintErr.FieldNames()).To(Equal([]string{"Name"}))
+		Expect(constraintErr.Constraint).To(Equal("authors_name_key"))
+
+		_, err = db.Model(&Translation{BookID: 100, Lang: "ru"}).Insert()
+		Expect(err).NotTo(HaveOccurred())
+		_, err = db.Model(&Translation{BookID: 100, Lang: "ru"}).Insert()
+		Expect(errors.As(err, &constraintErr)).To(BeTrue())
+		Expect(constraintErr.FieldNames()).To(Equal([]string{"BookID", "Lang"}))
+	})
+
+	It("maps violations of constraints with custom names to fields", func() {
+		type CustomConstraint struct {
+			ID    int
+			Email string
+		}
+
+		_, err := db.Exec(`CREATE TABLE custom_constraints (
+			id serial PRIMARY KEY,
+			email text,
+			CONSTRAINT custom_email_uniq UNIQUE (email)
+		)`)
+		Expect(err).NotTo(HaveOccurred())
+		defer func() {
+			_, err := db.Exec("DROP TABLE custom_constraints")
+			Expect(err).NotTo(HaveOccurred())
+		}()
+
+		_, err = db.Model(&CustomConstraint{Email: "a@example.com"}).Insert()
+		Expect(err).NotTo(HaveOccurred())
+		_, err = db.Model(&CustomConstraint{Email: "a@example.com"}).Insert()
+		Expect(pg.IsUniqueViolation(err)).To(BeTrue())
+
+		// The key columns are parsed from the detail, which requires
+		// the server to send messages in English.
+		var constraintErr *pg.ConstraintError
+		Expect(errors.As(err, &constraintErr)).To(BeTrue())
+		Expect(constraintErr.Constraint).To(Equal("custom_email_uniq"))
+		Expect(constraintErr.FieldNames()).To(Equal([]string{"Email"}))
+	})
+
 	It("supports Exec & Query", func() {
 		_, err := db.Model((*Book)(nil)).Exec("DROP TABLE ?TableName CASCADE")
 		Expect(err).NotTo(HaveOccurred())
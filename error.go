@@ -1,9 +1,13 @@
 package pg
 
 import (
+	"errors"
 	"net"
+	"strings"
 
 	"github.com/go-pg/pg/v10/internal"
+	"github.com/go-pg/pg/v10/orm"
+	"github.com/go-pg/pg/v10/pgerrcode"
 )
 
 // ErrNoRows is returned by QueryOne and ExecOne when query returned zero rows
@@ -35,6 +39,87 @@ type Error interface {
 
 var _ Error = (*internal.PGError)(nil)
 
+// PGError is the error returned by PostgreSQL server with the fields
+// of the ErrorResponse message. It can be extracted from wrapped errors
+// with errors.As:
+//
+//	var pgErr pg.PGError
+//	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+//		fmt.Println(pgErr.Constraint)
+//	}
+type PGError = internal.PGError
+
+// ConstraintError is returned by Insert and Update when the query violates
+// a unique, not-null or foreign key constraint of the model table.
+// See orm.ConstraintError.
+type ConstraintError = orm.ConstraintError
+
+// ErrorCode returns the SQLSTATE code of the PostgreSQL error in the err
+// chain or an empty string. Codes are defined in the pgerrcode package.
+func ErrorCode(err error) string {
+	var pgErr Error
+	if errors.As(err, &pgErr) {
+		return pgErr.Field('C')
+	}
+	return ""
+}
+
+// IsUniqueViolation reports whether err is a unique_violation (23505) error.
+func IsUniqueViolation(err error) bool {
+	return ErrorCode(err) == pgerrcode.UniqueViolation
+}
+
+// IsForeignKeyViolation reports whether err is a foreign_key_violation
+// (23503) error.
+func IsForeignKeyViolation(err error) bool {
+	return ErrorCode(err) == pgerrcode.ForeignKeyViolation
+}
+
+// IsNotNullViolation reports whether err is a not_null_violation (23502) error.
+func IsNotNullViolation(err error) bool {
+	return ErrorCode(err) == pgerrcode.NotNullViolation
+}
+
+// IsCheckViolation reports whether err is a check_violation (23514) error.
+func IsCheckViolation(err error) bool {
+	return ErrorCode(err) == pgerrcode.CheckViolation
+}
+
+// IsExclusionViolation reports whether err is an exclusion_violation
+// (23P01) error.
+func IsExclusionViolation(err error) bool {
+	return ErrorCode(err) == pgerrcode.ExclusionViolation
+}
+
+// IsIntegrityConstraintViolation reports whether err is of the
+// Integrity Constraint Violation class (23).
+func IsIntegrityConstraintViolation(err error) bool {
+	return pgerrcode.IsIntegrityConstraintViolation(ErrorCode(err))
+}
+
+// IsSerializationFailure reports whether err is a serialization_failure
+// (40001) error.
+func IsSerializationFailure(err error) bool {
+	return ErrorCode(err) == pgerrcode.SerializationFailure
+}
+
+// IsDeadlockDetected reports whether err is a deadlock_detected (40P01) error.
+func IsDeadlockDetected(err error) bool {
+	return ErrorCode(err) == pgerrcode.DeadlockDetected
+}
+
+// IsTransactionRollback reports whether err is of the Transaction Rollback
+// class (40), e.g. a serialization failure or a deadlock.
+func IsTransactionRollback(err error) bool {
+	return pgerrcode.IsTransactionRollback(ErrorCode(err))
+}
+
+// IsQueryCanceled reports whether err is a query_canceled (57014) error,
+// e.g. because of statement_timeout.
+func IsQueryCanceled(err error) bool {
+	return ErrorCode(err) == pgerrcode.QueryCanceled
+}
+
 func isBadConn(err error, allowTimeout bool) (bool, string) {
 	if err == nil {
 		return false, ""
@@ -63,6 +148,22 @@ func isBadConn(err error, allowTimeout bool) (bool, string) {
 	return true, ""
 }
 
+// isStmtInvalidated reports whether a cached prepared statement can't be
+// used anymore, e.g. because the table it selects from was altered.
+func isStmtInvalidated(err error) bool {
+	pgErr, ok := err.(Error)
+	if !ok {
+		return false
+	}
+	switch pgErr.Field('C') {
+	case "0A000": // feature_not_supported
+		return strings.Contains(pgErr.Field('M'), "cached plan must not change result type")
+	case "26000": // invalid_sql_statement_name
+		return true
+	}
+	return false
+}
+
 //------------------------------------------------------------------------------
 
 type timeoutError interface {
@@ -0,0 +1,71 @@
+package pg
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-pg/pg/v10/internal"
+	"github.com/go-pg/pg/v10/pgerrcode"
+)
+
+func TestPGErrorFields(t *testing.T) {
+	err := fmt.Errorf("insert failed: %w", internal.NewPGError(map[byte]string{
+		'S': "ERREUR",
+		'V': "ERROR",
+		'C': "23505",
+		'M': `duplicate key value violates unique constraint "users_email_key"`,
+		'D': "Key (email)=(a@example.com) already exists.",
+		'P': "15",
+		's': "public",
+		't': "users",
+		'n': "users_email_key",
+		'F': "nbtinsert.c",
+		'L': "656",
+		'R': "_bt_check_unique",
+	}))
+
+	var pgErr PGError
+	assert.True(t, errors.As(err, &pgErr))
+	assert.Equal(t, pgerrcode.UniqueViolation, pgErr.Code)
+	assert.Equal(t, "ERROR", pgErr.Severity)
+	assert.Equal(t, "Key (email)=(a@example.com) already exists.", pgErr.Detail)
+	assert.Equal(t, 15, pgErr.Position)
+	assert.Equal(t, "public", pgErr.Schema)
+	assert.Equal(t, "users", pgErr.Table)
+	assert.Equal(t, "users_email_key", pgErr.Constraint)
+	assert.Equal(t, "nbtinsert.c", pgErr.File)
+	assert.Equal(t, 656, pgErr.Line)
+	assert.Equal(t, "_bt_check_unique", pgErr.Routine)
+	assert.Equal(t, "23505", pgErr.Field('C'))
+	assert.True(t, pgErr.IntegrityViolation())
+
+	pgErr = internal.NewPGError(map[byte]string{'S': "FATAL"})
+	assert.Equal(t, "FATAL", pgErr.Severity)
+}
+
+func TestErrorHelpers(t *testing.T) {
+	newErr := func(code string) error {
+		return fmt.Errorf("wrapped: %w", internal.NewPGError(map[byte]string{'C': code}))
+	}
+
+	assert.Equal(t, "23503", ErrorCode(newErr("23503")))
+	assert.Equal(t, "", ErrorCode(errors.New("not a pg error")))
+	assert.Equal(t, "", ErrorCode(nil))
+
+	assert.True(t, IsUniqueViolation(newErr(pgerrcode.UniqueViolation)))
+	assert.False(t, IsUniqueViolation(newErr(pgerrcode.ForeignKeyViolation)))
+	assert.True(t, IsForeignKeyViolation(newErr(pgerrcode.ForeignKeyViolation)))
+	assert.True(t, IsNotNullViolation(newErr(pgerrcode.NotNullViolation)))
+	assert.True(t, IsCheckViolation(newErr(pgerrcode.CheckViolation)))
+	assert.True(t, IsExclusionViolation(newErr(pgerrcode.ExclusionViolation)))
+	assert.True(t, IsIntegrityConstraintViolation(newErr(pgerrcode.RestrictViolation)))
+	assert.False(t, IsIntegrityConstraintViolation(newErr(pgerrcode.SerializationFailure)))
+	assert.True(t, IsSerializationFailure(newErr(pgerrcode.SerializationFailure)))
+	assert.True(t, IsDeadlockDetected(newErr(pgerrcode.DeadlockDetected)))
+	assert.True(t, IsTransactionRollback(newErr(pgerrcode.DeadlockDetected)))
+	assert.True(t, IsQueryCanceled(newErr(pgerrcode.QueryCanceled)))
+	assert.False(t, IsQueryCanceled(errors.New("canceled")))
+}
@@ -227,6 +227,18 @@ func ExampleDB_Prepare() {
 	// Output: foo bar
 }
 
+func ExampleDB_SendBatch() {
+	var one, two int
+	var batch pg.Batch
+	batch.Queue("SELECT ?", pg.Scan(&one), 1)
+	batch.Queue("SELECT ?", pg.Scan(&two), 2)
+
+	results, err := pgdb.SendBatch(ctx, &batch)
+	panicIf(err)
+	fmt.Println(len(results), one, two)
+	// Output: 2 1 2
+}
+
 func ExampleDB_Model_createTable() {
 	type Model1 struct {
 		Id int
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"fmt"
+	"strconv"
 )
 
 var (
@@ -21,12 +22,59 @@ func (err Error) Error() string {
 	return err.s
 }
 
+// PGError is an error returned by the PostgreSQL server in an
+// ErrorResponse message. Fields that are not sent by the server are empty.
+//
+// https://www.postgresql.org/docs/current/protocol-error-fields.html
 type PGError struct {
+	// Code is the SQLSTATE code of the error, e.g. 23505.
+	Code string
+	// Severity is ERROR, FATAL or PANIC. It is not localized.
+	Severity string
+	Message  string
+	Detail   string
+	Hint     string
+	// Position is the 1-based character position of the error
+	// in the query or 0.
+	Position   int
+	Schema     string
+	Table      string
+	Column     string
+	Constraint string
+	DataType   string
+	Where      string
+	File       string
+	Line       int
+	Routine    string
+
 	m map[byte]string
 }
 
 func NewPGError(m map[byte]string) PGError {
+	severity := m['V']
+	if severity == "" {
+		// Servers before 9.6 only send the localized severity.
+		severity = m['S']
+	}
+	position, _ := strconv.Atoi(m['P'])
+	line, _ := strconv.Atoi(m['L'])
 	return PGError{
+		Code:       m['C'],
+		Severity:   severity,
+		Message:    m['M'],
+		Detail:     m['D'],
+		Hint:       m['H'],
+		Position:   position,
+		Schema:     m['s'],
+		Table:      m['t'],
+		Column:     m['c'],
+		Constraint: m['n'],
+		DataType:   m['d'],
+		Where:      m['W'],
+		File:       m['F'],
+		Line:       line,
+		Routine:    m['R'],
+
 		m: m,
 	}
 }
@@ -36,7 +84,7 @@ func (err PGError) Field(k byte) string {
 }
 
 func (err PGError) IntegrityViolation() bool {
-	switch err.Field('C') {
+	switch err.Code {
 	case "23000", "23001", "23502", "23503", "23505", "23514", "23P01":
 		return true
 	default:
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net"
 	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -18,6 +19,17 @@ type Conn struct {
 	SecretKey int32
 	lastID    int64
 
+	paramsMu sync.RWMutex
+	params   map[string]string
+
+	// OnNotice is called with the fields of every NoticeResponse
+	// message received on the connection.
+	OnNotice func(ctx context.Context, fields map[byte]string)
+
+	// StmtCache caches statements prepared on the connection.
+	// It is nil when the cache is disabled.
+	StmtCache *StmtCache
+
 	createdAt time.Time
 	usedAt    uint32 // atomic
 	pooled    bool
@@ -65,6 +77,36 @@ func (cn *Conn) NetConn() net.Conn {
 	return cn.netConn
 }
 
+// ParameterStatus returns the value of the run-time parameter
+// reported by the server using ParameterStatus message.
+func (cn *Conn) ParameterStatus(name string) string {
+	cn.paramsMu.RLock()
+	defer cn.paramsMu.RUnlock()
+	return cn.params[name]
+}
+
+// ParameterStatuses returns a copy of all run-time parameters
+// reported by the server.
+func (cn *Conn) ParameterStatuses() map[string]string {
+	cn.paramsMu.RLock()
+	defer cn.paramsMu.RUnlock()
+
+	params := make(map[string]string, len(cn.params))
+	for name, value := range cn.params {
+		params[name] = value
+	}
+	return params
+}
+
+func (cn *Conn) SetParameterStatus(name, value string) {
+	cn.paramsMu.Lock()
+	if cn.params == nil {
+		cn.params = make(map[string]string)
+	}
+	cn.params[name] = value
+	cn.paramsMu.Unlock()
+}
+
 func (cn *Conn) NextID() string {
 	cn.lastID++
 	return strconv.FormatInt(cn.lastID, 10)
@@ -86,6 +128,8 @@ func (cn *Conn) WithReader(
 	}
 
 	rd.bytesRead = 0
+	rd.cn = cn
+	rd.ctx = ctx
 
 	if err := fn(rd); err != nil {
 		return err
@@ -0,0 +1,29 @@
+package pool_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/go-pg/pg/v10/internal/pool"
+)
+
+var _ = Describe("Conn", func() {
+	It("tracks parameter statuses", func() {
+		cn := pool.NewConn(nil)
+		Expect(cn.ParameterStatus("server_version")).To(Equal(""))
+
+		cn.SetParameterStatus("server_version", "16.2")
+		cn.SetParameterStatus("TimeZone", "UTC")
+		cn.SetParameterStatus("TimeZone", "Europe/Berlin")
+
+		Expect(cn.ParameterStatus("server_version")).To(Equal("16.2"))
+		params := cn.ParameterStatuses()
+		Expect(params).To(Equal(map[string]string{
+			"server_version": "16.2",
+			"TimeZone":       "Europe/Berlin",
+		}))
+
+		params["TimeZone"] = "UTC"
+		Expect(cn.ParameterStatus("TimeZone")).To(Equal("Europe/Berlin"))
+	})
+})
@@ -1,6 +1,7 @@
 package pool
 
 import (
+	"context"
 	"sync"
 )
 
@@ -23,6 +24,7 @@ type Reader interface {
 type ColumnInfo struct {
 	Index    int16
 	DataType int32
+	Format   int16
 	Name     string
 }
 
@@ -53,6 +55,22 @@ func (c *ColumnAlloc) Columns() []ColumnInfo {
 type ReaderContext struct {
 	*BufReader
 	ColumnAlloc *ColumnAlloc
+
+	cn  *Conn
+	ctx context.Context
+}
+
+// Conn returns the connection the reader reads from or nil.
+func (rd *ReaderContext) Conn() *Conn {
+	return rd.cn
+}
+
+// Notice passes the fields of a NoticeResponse message
+// to the OnNotice hook of the connection.
+func (rd *ReaderContext) Notice(fields map[byte]string) {
+	if rd.cn != nil && rd.cn.OnNotice != nil {
+		rd.cn.OnNotice(rd.ctx, fields)
+	}
 }
 
 func NewReaderContext() *ReaderContext {
@@ -76,5 +94,7 @@ func GetReaderContext() *ReaderContext {
 
 func PutReaderContext(rd *ReaderContext) {
 	rd.ColumnAlloc.Reset()
+	rd.cn = nil
+	rd.ctx = nil
 	readerPool.Put(rd)
 }
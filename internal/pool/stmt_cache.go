@@ -0,0 +1,74 @@
+package pool
+
+import "container/list"
+
+// CachedStmt is a statement prepared on a connection.
+type CachedStmt struct {
+	Query   string
+	Name    string
+	Columns []ColumnInfo
+}
+
+// StmtCache is an LRU cache of prepared statements keyed by the query.
+// It is not safe for concurrent use, because a connection is used
+// by one goroutine at a time.
+type StmtCache struct {
+	size  int
+	ll    *list.List
+	stmts map[string]*list.Element
+}
+
+func NewStmtCache(size int) *StmtCache {
+	return &StmtCache{
+		size:  size,
+		ll:    list.New(),
+		stmts: make(map[string]*list.Element, size),
+	}
+}
+
+// Len returns the number of cached statements.
+func (c *StmtCache) Len() int {
+	return c.ll.Len()
+}
+
+// Get returns the statement for the query and marks it as recently used.
+func (c *StmtCache) Get(query []byte) (*CachedStmt, bool) {
+	el, ok := c.stmts[string(query)]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*CachedStmt), true
+}
+
+// Put adds the statement to the cache. It returns the least recently used
+// statement if it was evicted to make room for the new one.
+func (c *StmtCache) Put(stmt *CachedStmt) *CachedStmt {
+	if el, ok := c.stmts[stmt.Query]; ok {
+		c.ll.MoveToFront(el)
+		el.Value = stmt
+		return nil
+	}
+
+	c.stmts[stmt.Query] = c.ll.PushFront(stmt)
+	if c.ll.Len() <= c.size {
+		return nil
+	}
+
+	el := c.ll.Back()
+	c.ll.Remove(el)
+	evicted := el.Value.(*CachedStmt)
+	delete(c.stmts, evicted.Query)
+	return evicted
+}
+
+// Remove removes the statement for the query from the cache.
+func (c *StmtCache) Remove(query []byte) (*CachedStmt, bool) {
+	el, ok := c.stmts[string(query)]
+	if !ok {
+		return nil, false
+	}
+	c.ll.Remove(el)
+	delete(c.stmts, string(query))
+	return el.Value.(*CachedStmt), true
+}
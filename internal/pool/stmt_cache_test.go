@@ -0,0 +1,45 @@
+package pool_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/go-pg/pg/v10/internal/pool"
+)
+
+var _ = Describe("StmtCache", func() {
+	var cache *pool.StmtCache
+
+	BeforeEach(func() {
+		cache = pool.NewStmtCache(2)
+	})
+
+	It("evicts least recently used statements", func() {
+		Expect(cache.Put(&pool.CachedStmt{Query: "q1", Name: "1"})).To(BeNil())
+		Expect(cache.Put(&pool.CachedStmt{Query: "q2", Name: "2"})).To(BeNil())
+
+		stmt, ok := cache.Get([]byte("q1"))
+		Expect(ok).To(BeTrue())
+		Expect(stmt.Name).To(Equal("1"))
+
+		evicted := cache.Put(&pool.CachedStmt{Query: "q3", Name: "3"})
+		Expect(evicted).NotTo(BeNil())
+		Expect(evicted.Name).To(Equal("2"))
+		Expect(cache.Len()).To(Equal(2))
+
+		_, ok = cache.Get([]byte("q2"))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("removes statements", func() {
+		cache.Put(&pool.CachedStmt{Query: "q1", Name: "1"})
+
+		stmt, ok := cache.Remove([]byte("q1"))
+		Expect(ok).To(BeTrue())
+		Expect(stmt.Name).To(Equal("1"))
+		Expect(cache.Len()).To(Equal(0))
+
+		_, ok = cache.Remove([]byte("q1"))
+		Expect(ok).To(BeFalse())
+	})
+})
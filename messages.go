@@ -10,10 +10,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"sort"
 	"strings"
 
-	"mellium.im/sasl"
-
 	"github.com/go-pg/pg/v10/internal"
 	"github.com/go-pg/pg/v10/internal/pool"
 	"github.com/go-pg/pg/v10/orm"
@@ -67,10 +67,12 @@ const (
 	closeMsg         = 'C'
 	closeCompleteMsg = '3'
 
-	copyInResponseMsg  = 'G'
-	copyOutResponseMsg = 'H'
-	copyDataMsg        = 'd'
-	copyDoneMsg        = 'c'
+	copyInResponseMsg   = 'G'
+	copyOutResponseMsg  = 'H'
+	copyBothResponseMsg = 'W'
+	copyDataMsg         = 'd'
+	copyDoneMsg         = 'c'
+	copyFailMsg         = 'f'
 )
 
 var errEmptyQuery = internal.Errorf("pg: query is empty")
@@ -79,7 +81,7 @@ func (db *baseDB) startup(
 	c context.Context, cn *pool.Conn, user, password, database, appName string,
 ) error {
 	err := cn.WithWriter(c, db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
-		writeStartupMsg(wb, user, database, appName)
+		writeStartupMsg(wb, user, database, appName, db.opt.RuntimeParams)
 		return nil
 	})
 	if err != nil {
@@ -106,7 +108,7 @@ func (db *baseDB) startup(
 				cn.ProcessID = processID
 				cn.SecretKey = secretKey
 			case parameterStatusMsg:
-				if err := logParameterStatus(rd, msgLen); err != nil {
+				if err := readParameterStatus(rd); err != nil {
 					return err
 				}
 			case authenticationOKMsg:
@@ -139,7 +141,11 @@ func (db *baseDB) startup(
 	})
 }
 
-func (db *baseDB) enableSSL(c context.Context, cn *pool.Conn, tlsConf *tls.Config) error {
+func (db *baseDB) enableSSL(c context.Context, cn *pool.Conn) error {
+	if db.opt.SSLNegotiation == "direct" {
+		return db.enableDirectSSL(c, cn)
+	}
+
 	err := cn.WithWriter(c, db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
 		writeSSLMsg(wb)
 		return nil
@@ -153,19 +159,64 @@ func (db *baseDB) enableSSL(c context.Context, cn *pool.Conn, tlsConf *tls.Confi
 		if err != nil {
 			return err
 		}
-		if c != 'S' {
-			return errors.New("pg: SSL is not enabled on the server")
+		switch c {
+		case 'S':
+			return nil
+		case 'N':
+			return errSSLNotSupported
+		default:
+			return fmt.Errorf("pg: received invalid response to SSL negotiation: %q", c)
 		}
-		return nil
 	})
 	if err != nil {
 		return err
 	}
 
-	cn.SetNetConn(tls.Client(cn.NetConn(), tlsConf))
+	cn.SetNetConn(tls.Client(cn.NetConn(), db.tlsConfig(cn)))
+	return nil
+}
+
+// enableDirectSSL starts the TLS handshake without sending SSLRequest
+// first. The server must accept the postgresql ALPN protocol.
+func (db *baseDB) enableDirectSSL(c context.Context, cn *pool.Conn) error {
+	tlsConf := db.tlsConfig(cn).Clone()
+	tlsConf.NextProtos = []string{"postgresql"}
+
+	tlsConn := tls.Client(cn.NetConn(), tlsConf)
+	cn.SetNetConn(tlsConn)
+
+	if err := tlsConn.HandshakeContext(c); err != nil {
+		return err
+	}
+	if tlsConn.ConnectionState().NegotiatedProtocol != "postgresql" {
+		return errors.New("pg: server did not negotiate the postgresql ALPN protocol")
+	}
 	return nil
 }
 
+// tlsConfig returns the TLS config for the connection
+// with ServerName set to the name of the host.
+func (db *baseDB) tlsConfig(cn *pool.Conn) *tls.Config {
+	tlsConf := db.opt.TLSConfig
+	if tlsConf.InsecureSkipVerify || tlsConf.ServerName != "" {
+		return tlsConf
+	}
+
+	addrs := db.opt.addrs()
+	index := hostIndex(cn.NetConn())
+	if index >= len(addrs) {
+		return tlsConf
+	}
+	host, _, err := net.SplitHostPort(addrs[index])
+	if err != nil {
+		return tlsConf
+	}
+
+	tlsConf = tlsConf.Clone()
+	tlsConf.ServerName = host
+	return tlsConf
+}
+
 func (db *baseDB) auth(
 	c context.Context, cn *pool.Conn, rd *pool.ReaderContext, user, password string,
 ) error {
@@ -174,6 +225,11 @@ func (db *baseDB) auth(
 		return err
 	}
 
+	if num != authenticationSASL && db.opt.ChannelBinding == "require" {
+		return errors.New("pg: channel binding is required, " +
+			"but server authenticated the client without it")
+	}
+
 	switch num {
 	case authenticationOK:
 		return nil
@@ -296,7 +352,7 @@ func readAuthOK(rd *pool.ReaderContext) error {
 func (db *baseDB) authSASL(
 	c context.Context, cn *pool.Conn, rd *pool.ReaderContext, user, password string,
 ) error {
-	var saslMech sasl.Mechanism
+	var scram, scramPlus bool
 
 loop:
 	for {
@@ -308,28 +364,26 @@ loop:
 		switch s {
 		case "":
 			break loop
-		case sasl.ScramSha256.Name:
-			saslMech = sasl.ScramSha256
-		case sasl.ScramSha256Plus.Name:
-			// ignore
-		default:
-			return fmt.Errorf("got %q, wanted %q", s, sasl.ScramSha256.Name)
+		case scramSHA256:
+			scram = true
+		case scramSHA256Plus:
+			scramPlus = true
 		}
 	}
 
-	creds := sasl.Credentials(func() (Username, Password, Identity []byte) {
-		return []byte(user), []byte(password), nil
-	})
-	client := sasl.NewClient(saslMech, creds)
+	mech, client, err := db.newSCRAMClient(cn, user, password, scram, scramPlus)
+	if err != nil {
+		return err
+	}
 
-	_, resp, err := client.Step(nil)
+	resp, err := client.Step(nil)
 	if err != nil {
 		return err
 	}
 
 	err = cn.WithWriter(c, db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
 		wb.StartMessage(saslInitialResponseMsg)
-		wb.WriteString(saslMech.Name)
+		wb.WriteString(mech)
 		wb.WriteInt32(int32(len(resp)))
 		_, err := wb.Write(resp)
 		if err != nil {
@@ -362,7 +416,7 @@ loop:
 			return err
 		}
 
-		_, resp, err = client.Step(b)
+		resp, err = client.Step(b)
 		if err != nil {
 			return err
 		}
@@ -393,7 +447,46 @@ loop:
 	}
 }
 
-func readAuthSASLFinal(rd *pool.ReaderContext, client *sasl.Negotiator) error {
+// newSCRAMClient selects the SCRAM mechanism offered by the server
+// according to the ChannelBinding option.
+func (db *baseDB) newSCRAMClient(
+	cn *pool.Conn, user, password string, scram, scramPlus bool,
+) (string, scramClient, error) {
+	_, isTLS := cn.NetConn().(*tls.Conn)
+	binding := db.opt.ChannelBinding
+
+	if scramPlus && isTLS && binding != "disable" {
+		cb, err := tlsServerEndPoint(cn.NetConn())
+		if err == nil {
+			client, err := newSCRAMPlusClient(user, password, cb)
+			return scramSHA256Plus, client, err
+		}
+		// With prefer, certificates that can't be hashed,
+		// e.g. signed with Ed25519, fall back to SCRAM-SHA-256.
+		if binding == "require" {
+			return "", nil, err
+		}
+	} else if binding == "require" {
+		return "", nil, errors.New("pg: channel binding is required, " +
+			"but server did not offer SCRAM-SHA-256-PLUS over TLS")
+	}
+
+	if !scram {
+		return "", nil, fmt.Errorf("pg: SASL: server did not offer %q", scramSHA256)
+	}
+
+	// Like libpq, tell the server that channel binding is supported
+	// when it did not offer SCRAM-SHA-256-PLUS over TLS. A certificate
+	// that can't be hashed is sent as "n,,", since the server offered it.
+	var tlsState *tls.ConnectionState
+	if tlsConn, ok := cn.NetConn().(*tls.Conn); ok && !scramPlus && binding != "disable" {
+		state := tlsConn.ConnectionState()
+		tlsState = &state
+	}
+	return scramSHA256, newSASLClient(user, password, tlsState), nil
+}
+
+func readAuthSASLFinal(rd *pool.ReaderContext, client scramClient) error {
 	c, n, err := readMessageType(rd)
 	if err != nil {
 		return err
@@ -414,14 +507,12 @@ func readAuthSASLFinal(rd *pool.ReaderContext, client *sasl.Negotiator) error {
 			return err
 		}
 
-		_, _, err = client.Step(b)
-		if err != nil {
+		if _, err := client.Step(b); err != nil {
 			return err
 		}
 
-		if client.State() != sasl.ValidServerResponse {
-			return fmt.Errorf("pg: SASL: state=%q, wanted %q",
-				client.State(), sasl.ValidServerResponse)
+		if err := client.Verify(); err != nil {
+			return err
 		}
 	case errorResponseMsg:
 		e, err := readError(rd)
@@ -443,7 +534,9 @@ func md5s(s string) string {
 	return hex.EncodeToString(h[:])
 }
 
-func writeStartupMsg(buf *pool.WriteBuffer, user, database, appName string) {
+func writeStartupMsg(
+	buf *pool.WriteBuffer, user, database, appName string, params map[string]string,
+) {
 	buf.StartMessage(0)
 	buf.WriteInt32(196608)
 	buf.WriteString("user")
@@ -454,6 +547,25 @@ func writeStartupMsg(buf *pool.WriteBuffer, user, database, appName string) {
 		buf.WriteString("application_name")
 		buf.WriteString(appName)
 	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		switch key {
+		case "user", "database":
+			continue
+		case "application_name":
+			if appName != "" {
+				continue
+			}
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		buf.WriteString(key)
+		buf.WriteString(params[key])
+	}
+
 	buf.WriteString("")
 	buf.FinishMessage()
 }
@@ -526,6 +638,174 @@ func appendQuery(fmter orm.QueryFormatter, dst []byte, query interface{}, params
 	}
 }
 
+// appendBindQuery formats the query replacing values with $1, $2, ...
+// placeholders and returns the values. Raw queries that already use
+// $1, $2, ... placeholders are sent as is with the params.
+func appendBindQuery(
+	fmter orm.QueryFormatter, dst []byte, query interface{}, params ...interface{},
+) ([]byte, []interface{}, error) {
+	if isRawBindQuery(query, params) {
+		return append(dst, query.(string)...), params, nil
+	}
+
+	var args []interface{}
+	if v, ok := fmter.(*orm.Formatter); ok {
+		fmter = v.WithBindParams(&args)
+	}
+
+	b, err := appendQuery(fmter, dst, query, params...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, args, nil
+}
+
+// isRawBindQuery reports whether the query is a string with params that
+// uses $1, $2, ... placeholders instead of ? placeholders. Such queries
+// are executed with the extended query protocol binding the params.
+func isRawBindQuery(query interface{}, params []interface{}) bool {
+	if len(params) == 0 {
+		return false
+	}
+	q, ok := query.(string)
+	if !ok || strings.IndexByte(q, '?') >= 0 {
+		return false
+	}
+	if _, ok := params[len(params)-1].(orm.TableModel); ok {
+		return false
+	}
+	return hasDollarParam(q)
+}
+
+// hasDollarParam reports whether the query contains a $n placeholder
+// outside of string literals, quoted identifiers, dollar-quoted strings
+// and comments.
+func hasDollarParam(q string) bool {
+	for i := 0; i < len(q); i++ {
+		c := q[i]
+		switch {
+		case c == '\'':
+			// Backslashes escape quotes only in E'...' strings.
+			escape := i > 0 && (q[i-1] == 'E' || q[i-1] == 'e') && (i == 1 || !isIdentByte(q[i-2]))
+			i = skipQuoted(q, i, escape)
+		case c == '"':
+			i = skipQuoted(q, i, false)
+		case c == '-' && strings.HasPrefix(q[i:], "--"):
+			j := strings.IndexByte(q[i:], '\n')
+			if j == -1 {
+				return false
+			}
+			i += j
+		case c == '/' && strings.HasPrefix(q[i:], "/*"):
+			i = skipBlockComment(q, i)
+		case c == '$' && (i == 0 || !isIdentByte(q[i-1])):
+			if i+1 < len(q) && q[i+1] >= '0' && q[i+1] <= '9' {
+				return true
+			}
+			if tag := dollarQuoteTag(q[i:]); tag != "" {
+				j := strings.Index(q[i+len(tag):], tag)
+				if j == -1 {
+					return false
+				}
+				i += 2*len(tag) + j - 1
+			}
+		}
+	}
+	return false
+}
+
+// skipQuoted returns the index of the quote that ends the quoted string
+// or identifier starting at the index i.
+func skipQuoted(q string, i int, escape bool) int {
+	quote := q[i]
+	for i++; i < len(q); i++ {
+		switch q[i] {
+		case '\\':
+			if escape {
+				i++
+			}
+		case quote:
+			return i
+		}
+	}
+	return i
+}
+
+// skipBlockComment returns the index of the end of the block comment
+// starting at the index i. Block comments can be nested.
+func skipBlockComment(q string, i int) int {
+	var depth int
+	for ; i+1 < len(q); i++ {
+		switch {
+		case q[i] == '/' && q[i+1] == '*':
+			depth++
+			i++
+		case q[i] == '*' && q[i+1] == '/':
+			depth--
+			i++
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(q)
+}
+
+// dollarQuoteTag returns the $tag$ or $$ that starts the dollar-quoted
+// string at the beginning of q.
+func dollarQuoteTag(q string) string {
+	for i := 1; i < len(q); i++ {
+		c := q[i]
+		switch {
+		case c == '$':
+			return q[:i+1]
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80:
+		case c >= '0' && c <= '9' && i > 1:
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' ||
+		c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// Writes PARSE, BIND, DESCRIBE and EXECUTE messages for the unnamed
+// statement. The caller must write the SYNC message.
+func writeParseBindExecuteMsg(buf *pool.WriteBuffer, q []byte, args []interface{}) error {
+	buf.StartMessage(parseMsg)
+	buf.WriteString("")
+	buf.WriteBytes(q)
+	if err := buf.WriteByte(0x0); err != nil {
+		return err
+	}
+	buf.WriteInt16(0)
+	buf.FinishMessage()
+
+	buf.StartMessage(bindMsg)
+	buf.WriteString("")
+	buf.WriteString("")
+	buf.WriteInt16(0)
+	writeBindParams(buf, args)
+	buf.WriteInt16(0)
+	buf.FinishMessage()
+
+	buf.StartMessage(describeMsg)
+	buf.WriteByte('P') //nolint
+	buf.WriteString("")
+	buf.FinishMessage()
+
+	buf.StartMessage(executeMsg)
+	buf.WriteString("")
+	buf.WriteInt32(0)
+	buf.FinishMessage()
+
+	return nil
+}
+
 func writeSyncMsg(buf *pool.WriteBuffer) {
 	buf.StartMessage(syncMsg)
 	buf.FinishMessage()
@@ -593,11 +873,11 @@ func readParseDescribeSync(rd *pool.ReaderContext) ([]types.ColumnInfo, error) {
 				firstErr = e
 			}
 		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
+			if err := readNotice(rd); err != nil {
 				return nil, err
 			}
 		case parameterStatusMsg:
-			if err := logParameterStatus(rd, msgLen); err != nil {
+			if err := readParameterStatus(rd); err != nil {
 				return nil, err
 			}
 		default:
@@ -606,12 +886,30 @@ func readParseDescribeSync(rd *pool.ReaderContext) ([]types.ColumnInfo, error) {
 	}
 }
 
-// Writes BIND, EXECUTE and SYNC messages.
-func writeBindExecuteMsg(buf *pool.WriteBuffer, name string, params ...interface{}) error {
+// Writes BIND, EXECUTE and SYNC messages. Result columns are requested
+// in the formats of the columns.
+func writeBindExecuteMsg(
+	buf *pool.WriteBuffer, name string, columns []types.ColumnInfo, params ...interface{},
+) error {
 	buf.StartMessage(bindMsg)
 	buf.WriteString("")
 	buf.WriteString(name)
 	buf.WriteInt16(0)
+	writeBindParams(buf, params)
+	writeResultFormats(buf, columns)
+	buf.FinishMessage()
+
+	buf.StartMessage(executeMsg)
+	buf.WriteString("")
+	buf.WriteInt32(0)
+	buf.FinishMessage()
+
+	writeSyncMsg(buf)
+
+	return nil
+}
+
+func writeBindParams(buf *pool.WriteBuffer, params []interface{}) {
 	buf.WriteInt16(int16(len(params)))
 	for _, param := range params {
 		buf.StartParam()
@@ -623,17 +921,20 @@ func writeBindExecuteMsg(buf *pool.WriteBuffer, name string, params ...interface
 			buf.FinishNullParam()
 		}
 	}
-	buf.WriteInt16(0)
-	buf.FinishMessage()
-
-	buf.StartMessage(executeMsg)
-	buf.WriteString("")
-	buf.WriteInt32(0)
-	buf.FinishMessage()
-
-	writeSyncMsg(buf)
+}
 
-	return nil
+func writeResultFormats(buf *pool.WriteBuffer, columns []types.ColumnInfo) {
+	for _, col := range columns {
+		if col.Format != types.TextFormat {
+			buf.WriteInt16(int16(len(columns)))
+			for _, col := range columns {
+				buf.WriteInt16(col.Format)
+			}
+			return
+		}
+	}
+	// All columns use the text format.
+	buf.WriteInt16(0)
 }
 
 func writeCloseMsg(buf *pool.WriteBuffer, name string) {
@@ -660,11 +961,11 @@ func readCloseCompleteMsg(rd *pool.ReaderContext) error {
 			}
 			return e
 		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
+			if err := readNotice(rd); err != nil {
 				return err
 			}
 		case parameterStatusMsg:
-			if err := logParameterStatus(rd, msgLen); err != nil {
+			if err := readParameterStatus(rd); err != nil {
 				return err
 			}
 		default:
@@ -723,11 +1024,11 @@ func readSimpleQuery(rd *pool.ReaderContext) (*result, error) {
 				firstErr = errEmptyQuery
 			}
 		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
+			if err := readNotice(rd); err != nil {
 				return nil, err
 			}
 		case parameterStatusMsg:
-			if err := logParameterStatus(rd, msgLen); err != nil {
+			if err := readParameterStatus(rd); err != nil {
 				return nil, err
 			}
 		default:
@@ -746,7 +1047,12 @@ func readExtQuery(rd *pool.ReaderContext) (*result, error) {
 		}
 
 		switch c {
-		case bindCompleteMsg:
+		case parseCompleteMsg, bindCompleteMsg, noDataMsg:
+			_, err := rd.ReadN(msgLen)
+			if err != nil {
+				return nil, err
+			}
+		case rowDescriptionMsg: // Response to the DESCRIBE message.
 			_, err := rd.ReadN(msgLen)
 			if err != nil {
 				return nil, err
@@ -787,11 +1093,11 @@ func readExtQuery(rd *pool.ReaderContext) (*result, error) {
 				firstErr = errEmptyQuery
 			}
 		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
+			if err := readNotice(rd); err != nil {
 				return nil, err
 			}
 		case parameterStatusMsg:
-			if err := logParameterStatus(rd, msgLen); err != nil {
+			if err := readParameterStatus(rd); err != nil {
 				return nil, err
 			}
 		default:
@@ -826,9 +1132,15 @@ func readRowDescription(
 		}
 		col.DataType = dataType
 
-		if _, err := rd.ReadN(8); err != nil {
+		if _, err := rd.ReadN(6); err != nil {
+			return nil, err
+		}
+
+		format, err := readInt16(rd)
+		if err != nil {
 			return nil, err
 		}
+		col.Format = format
 	}
 
 	return columnAlloc.Columns(), nil
@@ -868,7 +1180,7 @@ func readDataRow(
 		}
 
 		column := columns[colIdx]
-		if err := scanner.ScanColumn(column, colRd, int(n)); err != nil && firstErr == nil {
+		if err := scanColumn(scanner, column, colRd, int(n)); err != nil && firstErr == nil {
 			firstErr = internal.Errorf(err.Error())
 		}
 
@@ -891,6 +1203,25 @@ func readDataRow(
 	return firstErr
 }
 
+// scanColumn passes the column to the scanner converting values in the binary
+// format to the text format for scanners that don't support it.
+func scanColumn(scanner orm.ColumnScanner, col types.ColumnInfo, rd types.Reader, n int) error {
+	if col.Format != types.BinaryFormat {
+		return scanner.ScanColumn(col, rd, n)
+	}
+
+	if s, ok := scanner.(orm.BinaryColumnScanner); ok {
+		return s.ScanBinaryColumn(col, rd, n)
+	}
+
+	rd, n, err := types.ReadBinaryAsText(col, rd, n)
+	if err != nil {
+		return err
+	}
+	col.Format = types.TextFormat
+	return scanner.ScanColumn(col, rd, n)
+}
+
 func newModel(mod interface{}) (orm.Model, error) {
 	m, err := orm.NewModel(mod)
 	if err != nil {
@@ -971,11 +1302,11 @@ func readSimpleQueryData(
 				firstErr = errEmptyQuery
 			}
 		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
+			if err := readNotice(rd); err != nil {
 				return nil, err
 			}
 		case parameterStatusMsg:
-			if err := logParameterStatus(rd, msgLen); err != nil {
+			if err := readParameterStatus(rd); err != nil {
 				return nil, err
 			}
 		default:
@@ -996,11 +1327,16 @@ func readExtQueryData(
 		}
 
 		switch c {
-		case bindCompleteMsg:
+		case parseCompleteMsg, bindCompleteMsg, noDataMsg:
 			_, err := rd.ReadN(msgLen)
 			if err != nil {
 				return nil, err
 			}
+		case rowDescriptionMsg: // Response to the DESCRIBE message.
+			columns, err = readRowDescription(rd, rd.ColumnAlloc)
+			if err != nil {
+				return nil, err
+			}
 		case dataRowMsg:
 			if res.model == nil {
 				var err error
@@ -1050,12 +1386,16 @@ func readExtQueryData(
 			if firstErr == nil {
 				firstErr = e
 			}
+		case emptyQueryResponseMsg:
+			if firstErr == nil {
+				firstErr = errEmptyQuery
+			}
 		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
+			if err := readNotice(rd); err != nil {
 				return nil, err
 			}
 		case parameterStatusMsg:
-			if err := logParameterStatus(rd, msgLen); err != nil {
+			if err := readParameterStatus(rd); err != nil {
 				return nil, err
 			}
 		default:
@@ -1064,6 +1404,109 @@ func readExtQueryData(
 	}
 }
 
+// readBatch reads results of the queries sent in a batch. Rows returned
+// by each query are scanned into the corresponding model.
+func readBatch(
+	ctx context.Context, rd *pool.ReaderContext, models []interface{},
+) ([]*result, error) {
+	results := make([]*result, len(models))
+	for i := range results {
+		results[i] = new(result)
+	}
+
+	var columns []types.ColumnInfo
+	var index int
+	var firstErr error
+	for {
+		c, msgLen, err := readMessageType(rd)
+		if err != nil {
+			return nil, err
+		}
+
+		switch c {
+		case parseCompleteMsg, bindCompleteMsg, noDataMsg:
+			_, err := rd.ReadN(msgLen)
+			if err != nil {
+				return nil, err
+			}
+		case rowDescriptionMsg: // Response to the DESCRIBE message.
+			rd.ColumnAlloc.Reset()
+			columns, err = readRowDescription(rd, rd.ColumnAlloc)
+			if err != nil {
+				return nil, err
+			}
+		case dataRowMsg:
+			res := results[index]
+			if res.model == nil {
+				var err error
+				res.model, err = newModel(models[index])
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+					res.model = Discard
+				}
+			}
+
+			scanner := res.model.NextColumnScanner()
+			if err := readDataRow(ctx, rd, columns, scanner); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+			} else if err := res.model.AddColumnScanner(scanner); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+
+			res.returned++
+		case commandCompleteMsg: // Response to the EXECUTE message.
+			b, err := rd.ReadN(msgLen)
+			if err != nil {
+				return nil, err
+			}
+			if err := results[index].parse(b); err != nil && firstErr == nil {
+				firstErr = err
+			}
+			results[index].notices = takeNotices(ctx)
+			index++
+		case readyForQueryMsg: // Response to the SYNC message.
+			_, err := rd.ReadN(msgLen)
+			if err != nil {
+				return nil, err
+			}
+			if firstErr != nil {
+				// The results are returned to collect the notices.
+				return results, firstErr
+			}
+			return results, nil
+		case errorResponseMsg:
+			e, err := readError(rd)
+			if err != nil {
+				return nil, err
+			}
+			if firstErr == nil {
+				firstErr = e
+			}
+		case emptyQueryResponseMsg:
+			if firstErr == nil {
+				firstErr = errEmptyQuery
+			}
+			index++
+		case noticeResponseMsg:
+			if err := readNotice(rd); err != nil {
+				return nil, err
+			}
+		case parameterStatusMsg:
+			if err := readParameterStatus(rd); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("pg: readBatch: unexpected message %q", c)
+		}
+	}
+}
+
 func readCopyInResponse(rd *pool.ReaderContext) error {
 	var firstErr error
 	for {
@@ -1091,11 +1534,11 @@ func readCopyInResponse(rd *pool.ReaderContext) error {
 			}
 			return firstErr
 		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
+			if err := readNotice(rd); err != nil {
 				return err
 			}
 		case parameterStatusMsg:
-			if err := logParameterStatus(rd, msgLen); err != nil {
+			if err := readParameterStatus(rd); err != nil {
 				return err
 			}
 		default:
@@ -1131,11 +1574,11 @@ func readCopyOutResponse(rd *pool.ReaderContext) error {
 			}
 			return firstErr
 		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
+			if err := readNotice(rd); err != nil {
 				return err
 			}
 		case parameterStatusMsg:
-			if err := logParameterStatus(rd, msgLen); err != nil {
+			if err := readParameterStatus(rd); err != nil {
 				return err
 			}
 		default:
@@ -1197,11 +1640,11 @@ func readCopyData(rd *pool.ReaderContext, w io.Writer) (*result, error) {
 			}
 			return nil, e
 		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
+			if err := readNotice(rd); err != nil {
 				return nil, err
 			}
 		case parameterStatusMsg:
-			if err := logParameterStatus(rd, msgLen); err != nil {
+			if err := readParameterStatus(rd); err != nil {
 				return nil, err
 			}
 		default:
@@ -1210,6 +1653,93 @@ func readCopyData(rd *pool.ReaderContext, w io.Writer) (*result, error) {
 	}
 }
 
+func readCopyBothResponse(rd *pool.ReaderContext) error {
+	var firstErr error
+	for {
+		c, msgLen, err := readMessageType(rd)
+		if err != nil {
+			return err
+		}
+
+		switch c {
+		case copyBothResponseMsg:
+			_, err := rd.ReadN(msgLen)
+			return err
+		case errorResponseMsg:
+			e, err := readError(rd)
+			if err != nil {
+				return err
+			}
+			if firstErr == nil {
+				firstErr = e
+			}
+		case readyForQueryMsg:
+			_, err := rd.ReadN(msgLen)
+			if err != nil {
+				return err
+			}
+			return firstErr
+		case noticeResponseMsg:
+			if err := readNotice(rd); err != nil {
+				return err
+			}
+		case parameterStatusMsg:
+			if err := readParameterStatus(rd); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("pg: readCopyBothResponse: unexpected message %q", c)
+		}
+	}
+}
+
+// readCopyBothData reads the next CopyData message. It returns io.EOF
+// when the server ends the copy.
+func readCopyBothData(rd *pool.ReaderContext) ([]byte, error) {
+	for {
+		c, msgLen, err := readMessageType(rd)
+		if err != nil {
+			return nil, err
+		}
+
+		switch c {
+		case copyDataMsg:
+			b := make([]byte, 0, msgLen)
+			for msgLen > 0 {
+				tmp, err := rd.ReadN(msgLen)
+				if err != nil && err != bufio.ErrBufferFull {
+					return nil, err
+				}
+				b = append(b, tmp...)
+				msgLen -= len(tmp)
+			}
+			return b, nil
+		case copyDoneMsg:
+			_, err := rd.ReadN(msgLen)
+			if err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		case errorResponseMsg:
+			e, err := readError(rd)
+			if err != nil {
+				return nil, err
+			}
+			return nil, e
+		case noticeResponseMsg:
+			if err := readNotice(rd); err != nil {
+				return nil, err
+			}
+		case parameterStatusMsg:
+			if err := readParameterStatus(rd); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("pg: readCopyBothData: unexpected message %q", c)
+		}
+	}
+}
+
 func writeCopyData(buf *pool.WriteBuffer, r io.Reader) error {
 	buf.StartMessage(copyDataMsg)
 	_, err := buf.ReadFrom(r)
@@ -1222,6 +1752,12 @@ func writeCopyDone(buf *pool.WriteBuffer) {
 	buf.FinishMessage()
 }
 
+func writeCopyFail(buf *pool.WriteBuffer, msg string) {
+	buf.StartMessage(copyFailMsg)
+	buf.WriteString(msg)
+	buf.FinishMessage()
+}
+
 func readReadyForQuery(rd *pool.ReaderContext) (*result, error) {
 	var res result
 	var firstErr error
@@ -1258,11 +1794,11 @@ func readReadyForQuery(rd *pool.ReaderContext) (*result, error) {
 				firstErr = e
 			}
 		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
+			if err := readNotice(rd); err != nil {
 				return nil, err
 			}
 		case parameterStatusMsg:
-			if err := logParameterStatus(rd, msgLen); err != nil {
+			if err := readParameterStatus(rd); err != nil {
 				return nil, err
 			}
 		default:
@@ -1296,7 +1832,7 @@ func readNotification(rd *pool.ReaderContext) (channel, payload string, err erro
 			}
 			return "", "", e
 		case noticeResponseMsg:
-			if err := logNotice(rd, msgLen); err != nil {
+			if err := readNotice(rd); err != nil {
 				return "", "", err
 			}
 		case notificationResponseMsg:
@@ -1329,14 +1865,28 @@ func terminateConn(cn *pool.Conn) error {
 
 //------------------------------------------------------------------------------
 
-func logNotice(rd *pool.ReaderContext, msgLen int) error {
-	_, err := rd.ReadN(msgLen)
-	return err
+func readNotice(rd *pool.ReaderContext) error {
+	fields, err := readErrorFields(rd)
+	if err != nil {
+		return err
+	}
+	rd.Notice(fields)
+	return nil
 }
 
-func logParameterStatus(rd *pool.ReaderContext, msgLen int) error {
-	_, err := rd.ReadN(msgLen)
-	return err
+func readParameterStatus(rd *pool.ReaderContext) error {
+	name, err := readString(rd)
+	if err != nil {
+		return err
+	}
+	value, err := readString(rd)
+	if err != nil {
+		return err
+	}
+	if cn := rd.Conn(); cn != nil {
+		cn.SetParameterStatus(name, value)
+	}
+	return nil
 }
 
 func readInt16(rd *pool.ReaderContext) (int16, error) {
@@ -1364,6 +1914,16 @@ func readString(rd *pool.ReaderContext) (string, error) {
 }
 
 func readError(rd *pool.ReaderContext) (error, error) {
+	m, err := readErrorFields(rd)
+	if err != nil {
+		return nil, err
+	}
+	return internal.NewPGError(m), nil
+}
+
+// readErrorFields reads the fields of ErrorResponse
+// and NoticeResponse messages.
+func readErrorFields(rd *pool.ReaderContext) (map[byte]string, error) {
 	m := make(map[byte]string)
 	for {
 		c, err := rd.ReadByte()
@@ -1379,7 +1939,7 @@ func readError(rd *pool.ReaderContext) (error, error) {
 		}
 		m[c] = s
 	}
-	return internal.NewPGError(m), nil
+	return m, nil
 }
 
 func readMessageType(rd *pool.ReaderContext) (byte, int, error) {
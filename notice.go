@@ -0,0 +1,177 @@
+package pg
+
+import (
+	"context"
+	"sync"
+)
+
+// Notice is a notice or warning sent by the server, e.g. by RAISE NOTICE
+// in PL/pgSQL or when a deprecated feature is used.
+//
+// https://www.postgresql.org/docs/current/protocol-error-fields.html
+type Notice struct {
+	// Severity is NOTICE, WARNING, INFO, LOG or DEBUG.
+	Severity string
+	Code     string
+	Message  string
+	Detail   string
+	Hint     string
+	Where    string
+
+	fields map[byte]string
+}
+
+func newNotice(fields map[byte]string) Notice {
+	severity := fields['V']
+	if severity == "" {
+		// Non-localized severity is only sent by PostgreSQL 9.6 and later.
+		severity = fields['S']
+	}
+	return Notice{
+		Severity: severity,
+		Code:     fields['C'],
+		Message:  fields['M'],
+		Detail:   fields['D'],
+		Hint:     fields['H'],
+		Where:    fields['W'],
+		fields:   fields,
+	}
+}
+
+// Field returns a string value associated with a notice field.
+func (n Notice) Field(field byte) string {
+	return n.fields[field]
+}
+
+func (n Notice) String() string {
+	return n.Severity + ": " + n.Message
+}
+
+type noticesKey struct{}
+
+type noticeCollector struct {
+	mu      sync.Mutex
+	notices []Notice
+	// errNotices are the notices of the last query that failed.
+	errNotices []Notice
+}
+
+// CollectNotices returns a copy of the context that collects the notices
+// sent by the server while executing a query. The notices are attached to
+// the query Result and are returned by Notices. When the query fails,
+// they are returned by ErrorNotices.
+func CollectNotices(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noticesKey{}, new(noticeCollector))
+}
+
+// Notices returns the notices sent by the server while executing the query
+// that returned the result. Notices are only collected when the query is
+// executed with the context returned by CollectNotices.
+func Notices(res Result) []Notice {
+	if res, ok := res.(*result); ok {
+		return res.notices
+	}
+	return nil
+}
+
+// ErrorNotices returns the notices sent by the server while executing
+// the last query that failed with the context returned by CollectNotices.
+// When the query is retried, only the notices of the last attempt are
+// returned. They are reset when a query executed with the context succeeds.
+func ErrorNotices(ctx context.Context) []Notice {
+	c, ok := ctx.Value(noticesKey{}).(*noticeCollector)
+	if !ok {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.errNotices
+}
+
+// takeNotices returns and resets the notices collected in the context.
+func takeNotices(ctx context.Context) []Notice {
+	c, ok := ctx.Value(noticesKey{}).(*noticeCollector)
+	if !ok {
+		return nil
+	}
+
+	c.mu.Lock()
+	notices := c.notices
+	c.notices = nil
+	c.mu.Unlock()
+	return notices
+}
+
+// attachNotices drains the notices collected in the context after every
+// query attempt, so they don't leak into the next query. The notices are
+// attached to the result when the query succeeds and are kept as
+// the error notices when it fails.
+func attachNotices(ctx context.Context, res Result, err error) {
+	c, ok := ctx.Value(noticesKey{}).(*noticeCollector)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	notices := c.notices
+	c.notices = nil
+	if err != nil {
+		c.errNotices = notices
+	} else {
+		c.errNotices = nil
+	}
+	c.mu.Unlock()
+
+	if err != nil {
+		return
+	}
+	if res, ok := res.(*result); ok && res != nil {
+		res.notices = notices
+	}
+}
+
+// attachBatchNotices drains the notices collected in the context after
+// a batch attempt. Notices of the queries are attached to their results
+// while reading the batch. When the batch fails, the notices of all
+// queries are kept as the error notices.
+func attachBatchNotices(ctx context.Context, results []*result, err error) {
+	c, ok := ctx.Value(noticesKey{}).(*noticeCollector)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	notices := c.notices
+	c.notices = nil
+	if err != nil {
+		var errNotices []Notice
+		for _, res := range results {
+			errNotices = append(errNotices, res.notices...)
+		}
+		c.errNotices = append(errNotices, notices...)
+	} else {
+		c.errNotices = nil
+	}
+	c.mu.Unlock()
+
+	if err == nil && len(results) > 0 {
+		// Notices sent after the last query completed.
+		last := results[len(results)-1]
+		last.notices = append(last.notices, notices...)
+	}
+}
+
+func (db *baseDB) handleNotice(ctx context.Context, fields map[byte]string) {
+	notice := newNotice(fields)
+
+	if db.opt.OnNotice != nil {
+		db.opt.OnNotice(ctx, notice)
+	}
+
+	if c, ok := ctx.Value(noticesKey{}).(*noticeCollector); ok {
+		c.mu.Lock()
+		c.notices = append(c.notices, notice)
+		c.mu.Unlock()
+	}
+}
@@ -0,0 +1,90 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleNotice(t *testing.T) {
+	var got []Notice
+	db := &baseDB{opt: &Options{
+		OnNotice: func(ctx context.Context, notice Notice) {
+			got = append(got, notice)
+		},
+	}}
+
+	ctx := CollectNotices(context.Background())
+	db.handleNotice(ctx, map[byte]string{
+		'S': "AVISO", 'V': "NOTICE", 'C': "00000", 'M': "hello", 'W': "PL/pgSQL function inline_code_block",
+	})
+	db.handleNotice(ctx, map[byte]string{'S': "WARNING", 'C': "01000", 'M': "world", 'H': "hint"})
+
+	assert.Len(t, got, 2)
+	assert.Equal(t, "NOTICE", got[0].Severity)
+	assert.Equal(t, "AVISO", got[0].Field('S'))
+	assert.Equal(t, "PL/pgSQL function inline_code_block", got[0].Where)
+	assert.Equal(t, "NOTICE: hello", got[0].String())
+	assert.Equal(t, "WARNING", got[1].Severity)
+	assert.Equal(t, "01000", got[1].Code)
+	assert.Equal(t, "hint", got[1].Hint)
+
+	res := new(result)
+	attachNotices(ctx, res, nil)
+	assert.Equal(t, got, Notices(res))
+
+	res = new(result)
+	attachNotices(ctx, res, nil)
+	assert.Nil(t, Notices(res))
+
+	db.handleNotice(context.Background(), map[byte]string{'M': "not collected"})
+	assert.Len(t, got, 3)
+	assert.Nil(t, takeNotices(ctx))
+}
+
+func TestAttachNoticesOnError(t *testing.T) {
+	db := &baseDB{opt: &Options{}}
+	ctx := CollectNotices(context.Background())
+	errFailed := errors.New("failed")
+
+	// Notices of failed attempts are replaced on retry.
+	db.handleNotice(ctx, map[byte]string{'M': "attempt 1"})
+	attachNotices(ctx, nil, errFailed)
+	db.handleNotice(ctx, map[byte]string{'M': "attempt 2"})
+	attachNotices(ctx, nil, errFailed)
+
+	notices := ErrorNotices(ctx)
+	assert.Len(t, notices, 1)
+	assert.Equal(t, "attempt 2", notices[0].Message)
+
+	// They don't leak into the next query.
+	res := new(result)
+	attachNotices(ctx, res, nil)
+	assert.Nil(t, Notices(res))
+	assert.Nil(t, ErrorNotices(ctx))
+
+	assert.Nil(t, ErrorNotices(context.Background()))
+}
+
+func TestAttachBatchNotices(t *testing.T) {
+	db := &baseDB{opt: &Options{}}
+	ctx := CollectNotices(context.Background())
+
+	results := []*result{{notices: []Notice{{Message: "query 1"}}}, new(result)}
+	db.handleNotice(ctx, map[byte]string{'M': "query 2"})
+	attachBatchNotices(ctx, results, errors.New("failed"))
+
+	notices := ErrorNotices(ctx)
+	assert.Len(t, notices, 2)
+	assert.Equal(t, "query 1", notices[0].Message)
+	assert.Equal(t, "query 2", notices[1].Message)
+
+	results = []*result{new(result), new(result)}
+	db.handleNotice(ctx, map[byte]string{'M': "after last query"})
+	attachBatchNotices(ctx, results, nil)
+	assert.Nil(t, ErrorNotices(ctx))
+	assert.Nil(t, results[0].notices)
+	assert.Len(t, results[1].notices, 1)
+}
@@ -8,21 +8,37 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"path"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/go-pg/pg/v10/internal"
 	"github.com/go-pg/pg/v10/internal/pool"
 )
 
 // Options contains database connection options.
+//
+// Options that are not set default to the connection service selected
+// by the PGSERVICE environment variable and then to the other libpq
+// environment variables. An unknown service or a malformed service file
+// is returned as the connection error.
 type Options struct {
 	// Network type, either tcp or unix.
 	// Default is tcp.
 	Network string
 	// TCP host:port or Unix socket depending on Network.
+	// Multiple TCP addresses separated by commas are tried in order
+	// until a connection matching TargetSessionAttrs is established.
+	// Default is PGHOST and PGPORT environment variables, where PGHOST
+	// may also be the directory of a Unix-domain socket.
 	Addr string
+	// TargetSessionAttrs is the type of session that must be established:
+	// any, read-write, read-only, primary, standby or prefer-standby.
+	// Default is any.
+	TargetSessionAttrs string
 
 	// Dialer creates new network connection and has priority over
 	// Network and Addr options.
@@ -32,7 +48,13 @@ type Options struct {
 	// and user is authenticated.
 	OnConnect func(ctx context.Context, cn *Conn) error
 
-	User     string
+	// Hook that is called for every notice or warning sent by the server
+	// after the connection is established, e.g. by RAISE NOTICE.
+	OnNotice func(ctx context.Context, notice Notice)
+
+	User string
+	// Password is looked up in the password file (PGPASSFILE,
+	// default ~/.pgpass) when neither it nor PGPASSWORD is set.
 	Password string
 	Database string
 
@@ -40,8 +62,64 @@ type Options struct {
 	// Only available from pg-9.0.
 	ApplicationName string
 
+	// RuntimeParams are run-time parameters, e.g. search_path,
+	// statement_timeout or TimeZone, that are sent in the startup
+	// message and set for every new session. URLs and connection strings
+	// set them with the options param, e.g. options=-c search_path=app.
+	RuntimeParams map[string]string
+
+	// Whether ORM queries send values to the server separately from the
+	// query using $1, $2, ... placeholders and the extended query protocol.
+	// Default is to interpolate values into the query.
+	BindParams bool
+
+	// Maximum number of prepared statements cached on each connection.
+	// Only queries that bind params, i.e. ORM queries with BindParams and
+	// raw queries with params and $1, $2, ... placeholders, are prepared
+	// once per connection and then executed using the cached statement.
+	// Other queries, e.g. raw queries without params or with ? placeholders,
+	// are sent with the simple query protocol and are not cached.
+	// Like with Prepare, results of cached statements are received in the
+	// binary format, so timestamptz values are scanned in UTC.
+	// Default is 0, which disables the cache.
+	StatementCacheSize int
+
 	// TLS config for secure connections.
 	TLSConfig *tls.Config
+	// SSLMode controls how TLSConfig is used: allow tries a plaintext
+	// connection first and falls back to TLS, prefer tries TLS first and
+	// falls back to plaintext, while require, verify-ca and verify-full
+	// fail if the server does not support TLS.
+	// Default is require when TLSConfig is set. When TLSConfig is not set,
+	// it is built from SSLMode or PGSSLMODE and an invalid sslmode or
+	// a missing certificate file is returned as the connection error.
+	SSLMode string
+	// SSLNegotiation is either postgres, which sends an SSLRequest before
+	// the TLS handshake, or direct, which starts the TLS handshake
+	// immediately after connecting. Direct requires PostgreSQL 17.
+	// Default is postgres.
+	SSLNegotiation string
+	// ChannelBinding controls SCRAM-SHA-256-PLUS channel binding
+	// to the TLS connection: disable, prefer or require.
+	// Default is prefer, which uses channel binding when the connection
+	// is secured with TLS and the server supports it, and falls back
+	// to SCRAM-SHA-256 when the hash algorithm of the server certificate
+	// is not supported for channel binding. Other values are treated
+	// as require.
+	ChannelBinding string
+
+	// passFile is the password file set by the passfile connection param.
+	passFile string
+	// serviceResolved is set when the connection service is resolved
+	// by ParseURL, so init does not apply PGSERVICE again.
+	serviceResolved bool
+	// usePassFile is set when no password is provided,
+	// so it is looked up in the password file.
+	usePassFile bool
+	// initErr is the error of resolving the options from the environment.
+	// It is returned when dialing, so the client does not connect with
+	// options the user did not ask for.
+	initErr error
 
 	// Dial timeout for establishing new connections.
 	// Default is 5 seconds.
@@ -66,6 +144,15 @@ type Options struct {
 	// Default is 4 seconds; -1 disables backoff.
 	MaxRetryBackoff time.Duration
 
+	// Maximum number of times RunInTransaction reruns the function
+	// on a fresh transaction when it fails with one of TxRetryCodes.
+	// Retries use the same backoff as MaxRetries.
+	// Default is to not retry transactions.
+	MaxTxRetries int
+	// SQLSTATE codes of errors that make RunInTransaction retry.
+	// Default is 40001 (serialization_failure) and 40P01 (deadlock_detected).
+	TxRetryCodes []string
+
 	// Maximum number of socket connections.
 	// Default is 10 connections per every CPU as reported by runtime.NumCPU.
 	PoolSize int
@@ -93,6 +180,23 @@ type Options struct {
 }
 
 func (opt *Options) init() {
+	if !opt.serviceResolved {
+		if err := opt.setServiceDefaults(); err != nil {
+			opt.initErr = err
+		}
+		opt.serviceResolved = true
+	}
+
+	if opt.Addr == "" && (opt.Network == "" || opt.Network == "tcp") {
+		network, addr, err := hostsAddr(env("PGHOST", "localhost"), env("PGPORT", "5432"))
+		if err != nil {
+			internal.Warn.Printf("ignoring PGHOST and PGPORT: %s", err)
+		} else if opt.Network == "" || opt.Network == network {
+			opt.Network = network
+			opt.Addr = addr
+		}
+	}
+
 	if opt.Network == "" {
 		opt.Network = "tcp"
 	}
@@ -100,14 +204,53 @@ func (opt *Options) init() {
 	if opt.Addr == "" {
 		switch opt.Network {
 		case "tcp":
-			host := env("PGHOST", "localhost")
-			port := env("PGPORT", "5432")
-			opt.Addr = fmt.Sprintf("%s:%s", host, port)
+			opt.Addr = "localhost:5432"
 		case "unix":
 			opt.Addr = "/var/run/postgresql/.s.PGSQL.5432"
 		}
 	}
 
+	if opt.TLSConfig == nil {
+		p := newSSLParams(nil)
+		if opt.SSLMode != "" {
+			p.mode = opt.SSLMode
+		}
+		if opt.SSLNegotiation != "" {
+			p.negotiation = opt.SSLNegotiation
+		}
+		if p.mode != "" || p.rootCert == "system" {
+			if err := opt.setSSL(p); err != nil && opt.initErr == nil {
+				opt.initErr = err
+			}
+		}
+	}
+
+	if opt.ApplicationName == "" {
+		opt.ApplicationName = os.Getenv("PGAPPNAME")
+	}
+
+	if opt.TargetSessionAttrs == "" {
+		opt.TargetSessionAttrs = os.Getenv("PGTARGETSESSIONATTRS")
+	}
+
+	if opt.ChannelBinding == "" {
+		opt.ChannelBinding = os.Getenv("PGCHANNELBINDING")
+	}
+	if err := checkChannelBinding(opt.ChannelBinding); err != nil {
+		// Fail closed, so a misspelled value does not disable channel binding.
+		internal.Warn.Printf("using channel_binding 'require': %s", err)
+		opt.ChannelBinding = "require"
+	}
+
+	if opt.DialTimeout == 0 {
+		if connTimeout := os.Getenv("PGCONNECT_TIMEOUT"); connTimeout != "" {
+			timeout, err := parseConnectTimeout(connTimeout)
+			if err != nil {
+				internal.Warn.Printf("ignoring PGCONNECT_TIMEOUT: %s", err)
+			}
+			opt.DialTimeout = timeout
+		}
+	}
 	if opt.DialTimeout == 0 {
 		opt.DialTimeout = 5 * time.Second
 	}
@@ -125,14 +268,19 @@ func (opt *Options) init() {
 		opt.User = env("PGUSER", "postgres")
 	}
 
-	if opt.Password == "" {
-		opt.Password = env("PGPASSWORD", "postgres")
-	}
-
 	if opt.Database == "" {
 		opt.Database = env("PGDATABASE", "postgres")
 	}
 
+	if opt.Password == "" {
+		opt.Password = os.Getenv("PGPASSWORD")
+	}
+	if opt.Password == "" {
+		// The password file is looked up for each host when connecting.
+		opt.usePassFile = true
+		opt.Password = "postgres"
+	}
+
 	if opt.PoolSize == 0 {
 		opt.PoolSize = 10 * runtime.NumCPU()
 	}
@@ -164,6 +312,22 @@ func (opt *Options) init() {
 	case 0:
 		opt.MaxRetryBackoff = 4 * time.Second
 	}
+
+	if opt.TxRetryCodes == nil {
+		opt.TxRetryCodes = []string{
+			"40001", // serialization_failure
+			"40P01", // deadlock_detected
+		}
+	}
+}
+
+func checkChannelBinding(binding string) error {
+	switch binding {
+	case "", "disable", "prefer", "require":
+		return nil
+	default:
+		return fmt.Errorf("pg: channel_binding '%v' is not supported", binding)
+	}
 }
 
 func env(key, defValue string) string {
@@ -174,8 +338,51 @@ func env(key, defValue string) string {
 	return defValue
 }
 
-// ParseURL parses an URL into options that can be used to connect to PostgreSQL.
+// ParseURL parses an URL or a libpq keyword/value connection string
+// into options that can be used to connect to PostgreSQL.
+//
+// The service parameter or PGSERVICE environment variable selects
+// a section of the connection service file that provides defaults
+// for parameters missing in the URL.
 func ParseURL(sURL string) (*Options, error) {
+	var params map[string]string
+	var err error
+
+	isURL := strings.Contains(sURL, "://")
+	if isURL {
+		params, err = parseURLParams(sURL)
+	} else {
+		params, err = parseDSNParams(sURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setServiceParams(params); err != nil {
+		return nil, err
+	}
+
+	if isURL {
+		if params["dbname"] == "" {
+			return nil, errors.New("pg: database name not provided")
+		}
+		if params["user"] == "" {
+			params["user"] = "postgres"
+		}
+	}
+
+	opt, err := newOptions(params)
+	if err != nil {
+		return nil, err
+	}
+	opt.serviceResolved = true
+	return opt, nil
+}
+
+// parseURLParams converts an URL into connection params
+// using the same keywords as libpq connection strings.
+func parseURLParams(sURL string) (map[string]string, error) {
+	sURL, hosts := splitURLHosts(sURL)
 	parsedURL, err := url.Parse(sURL)
 	if err != nil {
 		return nil, err
@@ -186,78 +393,310 @@ func ParseURL(sURL string) (*Options, error) {
 		return nil, errors.New("pg: invalid scheme: " + parsedURL.Scheme)
 	}
 
-	// host and port
-	options := &Options{
-		Addr: parsedURL.Host,
+	query, err := url.ParseQuery(parsedURL.RawQuery)
+	if err != nil {
+		return nil, err
 	}
-	if !strings.Contains(options.Addr, ":") {
-		options.Addr += ":5432"
+
+	params := make(map[string]string, len(query))
+	for key, values := range query {
+		if len(values) > 0 {
+			params[key] = values[0]
+		}
+	}
+
+	// host and port
+	if hosts == nil && parsedURL.Host != "" {
+		hosts = []string{parsedURL.Host}
+	}
+	if len(hosts) > 0 {
+		ports := make([]string, len(hosts))
+		for i, host := range hosts {
+			if h, p, err := net.SplitHostPort(host); err == nil {
+				hosts[i], ports[i] = h, p
+			} else {
+				hosts[i], ports[i] = strings.Trim(host, "[]"), "5432"
+			}
+		}
+		params["host"] = strings.Join(hosts, ",")
+		params["port"] = strings.Join(ports, ",")
 	}
 
 	// username and password
 	if parsedURL.User != nil {
-		options.User = parsedURL.User.Username()
+		params["user"] = parsedURL.User.Username()
 
 		if password, ok := parsedURL.User.Password(); ok {
-			options.Password = password
+			params["password"] = password
 		}
 	}
 
-	if options.User == "" {
-		options.User = "postgres"
-	}
-
 	// database
 	if len(strings.Trim(parsedURL.Path, "/")) > 0 {
-		options.Database = parsedURL.Path[1:]
-	} else {
-		return nil, errors.New("pg: database name not provided")
+		params["dbname"] = parsedURL.Path[1:]
 	}
 
-	// ssl mode
-	query, err := url.ParseQuery(parsedURL.RawQuery)
-	if err != nil {
+	return params, nil
+}
+
+// newOptions creates options from libpq connection params.
+func newOptions(params map[string]string) (*Options, error) {
+	options := new(Options)
+
+	host, hasHost := params["host"]
+	port, hasPort := params["port"]
+	if hasHost || hasPort {
+		if !hasHost {
+			host = env("PGHOST", "localhost")
+		}
+		if !hasPort {
+			port = env("PGPORT", "5432")
+		}
+		var err error
+		options.Network, options.Addr, err = hostsAddr(host, port)
+		if err != nil {
+			return nil, err
+		}
+	}
+	delete(params, "host")
+	delete(params, "port")
+
+	options.Database = params["dbname"]
+	delete(params, "dbname")
+
+	options.User = params["user"]
+	delete(params, "user")
+
+	options.Password = params["password"]
+	delete(params, "password")
+
+	options.passFile = params["passfile"]
+	delete(params, "passfile")
+
+	if err := options.setParams(params); err != nil {
 		return nil, err
 	}
 
-	if sslMode, ok := query["sslmode"]; ok && len(sslMode) > 0 {
-		switch sslMode[0] {
-		case "verify-ca", "verify-full":
-			options.TLSConfig = &tls.Config{}
-		case "allow", "prefer", "require":
-			options.TLSConfig = &tls.Config{InsecureSkipVerify: true} //nolint
-		case "disable":
-			options.TLSConfig = nil
-		default:
-			return nil, fmt.Errorf("pg: sslmode '%v' is not supported", sslMode[0])
+	return options, nil
+}
+
+// parseDSNParams splits a keyword/value connection string into params.
+// Values may be single-quoted and use backslash to escape quotes and backslashes.
+func parseDSNParams(dsn string) (map[string]string, error) {
+	params := make(map[string]string)
+
+	s := strings.TrimSpace(dsn)
+	for s != "" {
+		i := strings.IndexByte(s, '=')
+		if i == -1 {
+			return nil, fmt.Errorf("pg: missing \"=\" after %q in connection string", s)
 		}
-	} else {
-		options.TLSConfig = &tls.Config{InsecureSkipVerify: true} //nolint
+		key := strings.TrimSpace(s[:i])
+		if key == "" || strings.ContainsAny(key, " \t\n") {
+			return nil, fmt.Errorf("pg: invalid keyword %q in connection string", key)
+		}
+		s = strings.TrimLeft(s[i+1:], " \t\n")
+
+		var value []byte
+		if strings.HasPrefix(s, "'") {
+			s = s[1:]
+			for {
+				if s == "" {
+					return nil, errors.New("pg: unterminated quoted string in connection string")
+				}
+				c := s[0]
+				s = s[1:]
+				if c == '\'' {
+					break
+				}
+				if c == '\\' && s != "" {
+					c = s[0]
+					s = s[1:]
+				}
+				value = append(value, c)
+			}
+		} else {
+			for s != "" && !strings.ContainsRune(" \t\n", rune(s[0])) {
+				c := s[0]
+				s = s[1:]
+				if c == '\\' && s != "" {
+					c = s[0]
+					s = s[1:]
+				}
+				value = append(value, c)
+			}
+		}
+
+		params[key] = string(value)
+		s = strings.TrimLeft(s, " \t\n")
+	}
+
+	return params, nil
+}
+
+// hostsAddr returns the network and address for comma-separated lists
+// of hosts and ports as used by libpq. A host starting with a slash is
+// the directory of a Unix-domain socket.
+func hostsAddr(host, port string) (network, addr string, _ error) {
+	hosts := strings.Split(host, ",")
+	ports := strings.Split(port, ",")
+	if len(ports) != 1 && len(ports) != len(hosts) {
+		return "", "", fmt.Errorf(
+			"pg: could not match %d port numbers to %d hosts", len(ports), len(hosts))
+	}
+
+	if len(hosts) == 1 && strings.HasPrefix(hosts[0], "/") {
+		return "unix", path.Join(hosts[0], ".s.PGSQL."+ports[0]), nil
+	}
+
+	addrs := make([]string, len(hosts))
+	for i, h := range hosts {
+		p := ports[0]
+		if len(ports) > 1 {
+			p = ports[i]
+		}
+		if h == "" {
+			h = "localhost"
+		}
+		if p == "" {
+			p = "5432"
+		}
+		addrs[i] = net.JoinHostPort(h, p)
+	}
+	return "tcp", strings.Join(addrs, ","), nil
+}
+
+// setParams sets the options from the connection parameters that are
+// common to URLs and keyword/value connection strings.
+func (opt *Options) setParams(params map[string]string) error {
+	if err := opt.setSSL(newSSLParams(params)); err != nil {
+		return err
 	}
 
-	delete(query, "sslmode")
+	for _, key := range []string{
+		"sslmode", "sslrootcert", "sslcert", "sslkey", "sslcrl", "sslnegotiation",
+	} {
+		delete(params, key)
+	}
 
-	if appName, ok := query["application_name"]; ok && len(appName) > 0 {
-		options.ApplicationName = appName[0]
+	if appName, ok := params["application_name"]; ok {
+		opt.ApplicationName = appName
+	} else if appName, ok := params["fallback_application_name"]; ok {
+		opt.ApplicationName = appName
 	}
 
-	delete(query, "application_name")
+	delete(params, "application_name")
+	delete(params, "fallback_application_name")
 
-	if connTimeout, ok := query["connect_timeout"]; ok && len(connTimeout) > 0 {
-		ct, err := strconv.Atoi(connTimeout[0])
+	if connTimeout, ok := params["connect_timeout"]; ok {
+		timeout, err := parseConnectTimeout(connTimeout)
 		if err != nil {
-			return nil, fmt.Errorf("pg: cannot parse connect_timeout option as int")
+			return err
 		}
-		options.DialTimeout = time.Second * time.Duration(ct)
+		opt.DialTimeout = timeout
 	}
 
-	delete(query, "connect_timeout")
+	delete(params, "connect_timeout")
 
-	if len(query) > 0 {
-		return nil, errors.New("pg: options other than 'sslmode', 'application_name' and 'connect_timeout' are not supported")
+	if attrs, ok := params["target_session_attrs"]; ok {
+		switch attrs {
+		case "any", "read-write", "read-only", "primary", "standby", "prefer-standby":
+			opt.TargetSessionAttrs = attrs
+		default:
+			return fmt.Errorf("pg: target_session_attrs '%v' is not supported", attrs)
+		}
 	}
 
-	return options, nil
+	delete(params, "target_session_attrs")
+
+	if binding, ok := params["channel_binding"]; ok {
+		if err := checkChannelBinding(binding); err != nil {
+			return err
+		}
+		opt.ChannelBinding = binding
+	}
+
+	delete(params, "channel_binding")
+
+	if mode, ok := params["gssencmode"]; ok {
+		// GSSAPI encryption is not supported, so only modes
+		// that allow connecting without it are accepted.
+		if mode != "disable" && mode != "prefer" {
+			return fmt.Errorf("pg: gssencmode '%v' is not supported", mode)
+		}
+	}
+
+	for key := range params {
+		if _, ok := ignoredParams[key]; ok {
+			delete(params, key)
+		}
+	}
+
+	// Server settings are sent in the options param, e.g. options=-c search_path=app.
+	if options, ok := params["options"]; ok {
+		opt.RuntimeParams = map[string]string{"options": options}
+	}
+
+	delete(params, "options")
+
+	if len(params) > 0 {
+		keys := make([]string, 0, len(params))
+		for key := range params {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return fmt.Errorf("pg: connection parameter '%v' is not supported "+
+			"(server settings can be set with options='-c name=value')", keys[0])
+	}
+
+	return nil
+}
+
+// ignoredParams are libpq connection parameters that only tune the libpq
+// client and are accepted for compatibility with libpq connection strings.
+var ignoredParams = map[string]struct{}{
+	"gssencmode":          {},
+	"gsslib":              {},
+	"keepalives":          {},
+	"keepalives_idle":     {},
+	"keepalives_interval": {},
+	"keepalives_count":    {},
+	"krbsrvname":          {},
+	"sslcompression":      {},
+	"sslsni":              {},
+	"tcp_user_timeout":    {},
+}
+
+func parseConnectTimeout(s string) (time.Duration, error) {
+	ct, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("pg: cannot parse connect_timeout option as int")
+	}
+	return time.Second * time.Duration(ct), nil
+}
+
+// splitURLHosts replaces a comma-separated list of hosts in the URL with
+// the first host, because url.Parse does not support multiple hosts.
+func splitURLHosts(sURL string) (string, []string) {
+	i := strings.Index(sURL, "://")
+	if i == -1 {
+		return sURL, nil
+	}
+
+	start := i + len("://")
+	end := len(sURL)
+	if j := strings.IndexAny(sURL[start:], "/?#"); j != -1 {
+		end = start + j
+	}
+	if j := strings.LastIndexByte(sURL[start:end], '@'); j != -1 {
+		start += j + 1
+	}
+
+	if !strings.Contains(sURL[start:end], ",") {
+		return sURL, nil
+	}
+	hosts := strings.Split(sURL[start:end], ",")
+	return sURL[:start] + hosts[0] + sURL[end:], hosts
 }
 
 func (opts *Options) ToURL() string {
@@ -291,8 +730,26 @@ func (opts *Options) ToURL() string {
 		values.Add("application_name", opts.ApplicationName)
 	}
 
+	if len(opts.TargetSessionAttrs) > 0 {
+		values.Add("target_session_attrs", opts.TargetSessionAttrs)
+	}
+
+	if options := opts.runtimeOptions(); options != "" {
+		values.Add("options", options)
+	}
+
+	if len(opts.ChannelBinding) > 0 {
+		values.Add("channel_binding", opts.ChannelBinding)
+	}
+
+	if len(opts.SSLNegotiation) > 0 {
+		values.Add("sslnegotiation", opts.SSLNegotiation)
+	}
+
 	if opts.TLSConfig == nil {
 		values.Add("sslmode", "disable")
+	} else if len(opts.SSLMode) > 0 {
+		values.Add("sslmode", opts.SSLMode)
 	} else if opts.TLSConfig.InsecureSkipVerify {
 		values.Add("sslmode", "allow")
 	} else if !opts.TLSConfig.InsecureSkipVerify {
@@ -307,12 +764,87 @@ func (opts *Options) ToURL() string {
 	return dsn
 }
 
+// runtimeOptions returns RuntimeParams as the value of the options param,
+// where settings other than options are passed as -c name=value.
+func (opts *Options) runtimeOptions() string {
+	keys := make([]string, 0, len(opts.RuntimeParams))
+	for key := range opts.RuntimeParams {
+		if key != "options" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	options := opts.RuntimeParams["options"]
+	for _, key := range keys {
+		if options != "" {
+			options += " "
+		}
+		options += "-c " + escapeOption(key) + "=" + escapeOption(opts.RuntimeParams[key])
+	}
+	return options
+}
+
+// escapeOption escapes backslashes and spaces, which separate
+// the command-line arguments in the options param.
+func escapeOption(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, " ", `\ `)
+}
+
 func (opt *Options) getDialer() func(context.Context) (net.Conn, error) {
+	if opt.initErr != nil {
+		return func(ctx context.Context) (net.Conn, error) {
+			return nil, opt.initErr
+		}
+	}
+
+	addrs := opt.addrs()
+	if len(addrs) > 1 {
+		return func(ctx context.Context) (net.Conn, error) {
+			return opt.dialHost(ctx, addrs, 0)
+		}
+	}
 	return func(ctx context.Context) (net.Conn, error) {
 		return opt.Dialer(ctx, opt.Network, opt.Addr)
 	}
 }
 
+// addrs returns the addresses of the hosts.
+func (opt *Options) addrs() []string {
+	if opt.Network == "unix" {
+		return []string{opt.Addr}
+	}
+	return strings.Split(opt.Addr, ",")
+}
+
+// hostConn is a connection to one of multiple hosts.
+type hostConn struct {
+	net.Conn
+	index int
+}
+
+// hostIndex returns the index of the host the connection is established to.
+func hostIndex(cn net.Conn) int {
+	if cn, ok := cn.(*hostConn); ok {
+		return cn.index
+	}
+	return 0
+}
+
+// dialHost dials the first reachable host starting from the index.
+func (opt *Options) dialHost(ctx context.Context, addrs []string, index int) (net.Conn, error) {
+	var lastErr error
+	for ; index < len(addrs); index++ {
+		cn, err := opt.Dialer(ctx, opt.Network, addrs[index])
+		if err == nil {
+			return &hostConn{Conn: cn, index: index}, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
 func newConnPool(opt *Options) *pool.ConnPool {
 	return pool.NewConnPool(&pool.Options{
 		Dialer:  opt.getDialer(),
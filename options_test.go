@@ -12,6 +12,9 @@ import (
 )
 
 func TestParseURL(t *testing.T) {
+	t.Setenv("PGSSLROOTCERT", "")
+	setTestRootCert(t)
+
 	cases := []struct {
 		url         string
 		addr        string
@@ -153,7 +156,8 @@ func TestParseURL(t *testing.T) {
 			"",
 			0,
 			true,
-			errors.New("pg: options other than 'sslmode', 'application_name' and 'connect_timeout' are not supported"),
+			errors.New("pg: connection parameter 'abc' is not supported " +
+				"(server settings can be set with options='-c name=value')"),
 		},
 		{
 			"postgres://vasya@somewhere.at.amazonaws.com:5432/postgres",
@@ -264,6 +268,218 @@ func TestParseURL(t *testing.T) {
 	}
 }
 
+func TestParseURLMultipleHosts(t *testing.T) {
+	cases := []struct {
+		url   string
+		addr  string
+		attrs string
+		err   error
+	}{
+		{
+			"postgres://u:p@h1,h2:5433,h3/db",
+			"h1:5432,h2:5433,h3:5432",
+			"",
+			nil,
+		},
+		{
+			"postgres://h1:5432,h2:5432/db?target_session_attrs=read-write",
+			"h1:5432,h2:5432",
+			"read-write",
+			nil,
+		},
+		{
+			"postgres://h1/db?target_session_attrs=prefer-standby",
+			"h1:5432",
+			"prefer-standby",
+			nil,
+		},
+		{
+			"postgres://h1,h2/db?target_session_attrs=master",
+			"",
+			"",
+			errors.New("pg: target_session_attrs 'master' is not supported"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.url, func(t *testing.T) {
+			o, err := ParseURL(c.url)
+			if c.err != nil {
+				assert.Equal(t, c.err, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.addr, o.Addr)
+			assert.Equal(t, c.attrs, o.TargetSessionAttrs)
+			assert.Equal(t, "db", o.Database)
+		})
+	}
+}
+
+func TestParseURLChannelBinding(t *testing.T) {
+	cases := []struct {
+		url     string
+		binding string
+		err     error
+	}{
+		{"postgres://h1/db", "", nil},
+		{"postgres://h1/db?channel_binding=disable", "disable", nil},
+		{"postgres://h1/db?channel_binding=prefer", "prefer", nil},
+		{"postgres://h1/db?sslmode=verify-full&sslrootcert=system&channel_binding=require", "require", nil},
+		{
+			"postgres://h1/db?channel_binding=always",
+			"",
+			errors.New("pg: channel_binding 'always' is not supported"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.url, func(t *testing.T) {
+			o, err := ParseURL(c.url)
+			if c.err != nil {
+				assert.Equal(t, c.err, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.binding, o.ChannelBinding)
+		})
+	}
+}
+
+func TestOptionsInitChannelBinding(t *testing.T) {
+	t.Setenv("PGCHANNELBINDING", "")
+
+	opt := &Options{ChannelBinding: "disable"}
+	opt.init()
+	assert.Equal(t, "disable", opt.ChannelBinding)
+
+	opt = &Options{ChannelBinding: "requird"}
+	opt.init()
+	assert.Equal(t, "require", opt.ChannelBinding)
+
+	t.Setenv("PGCHANNELBINDING", "always")
+	opt = new(Options)
+	opt.init()
+	assert.Equal(t, "require", opt.ChannelBinding)
+}
+
+func TestParseDSN(t *testing.T) {
+	o, err := ParseURL("host=db.example.com port=5433 dbname=mydb user=vasya password='pup kin\\'s' " +
+		"sslmode=disable application_name=myapp connect_timeout=10")
+	assert.NoError(t, err)
+	assert.Equal(t, "tcp", o.Network)
+	assert.Equal(t, "db.example.com:5433", o.Addr)
+	assert.Equal(t, "mydb", o.Database)
+	assert.Equal(t, "vasya", o.User)
+	assert.Equal(t, "pup kin's", o.Password)
+	assert.Nil(t, o.TLSConfig)
+	assert.Equal(t, "myapp", o.ApplicationName)
+	assert.Equal(t, 10*time.Second, o.DialTimeout)
+
+	o, err = ParseURL("host = h1,h2 port=5432,5433 dbname=mydb target_session_attrs=read-write")
+	assert.NoError(t, err)
+	assert.Equal(t, "h1:5432,h2:5433", o.Addr)
+	assert.Equal(t, "read-write", o.TargetSessionAttrs)
+	assert.NotNil(t, o.TLSConfig)
+
+	o, err = ParseURL("host=/tmp port=5433 dbname=mydb")
+	assert.NoError(t, err)
+	assert.Equal(t, "unix", o.Network)
+	assert.Equal(t, "/tmp/.s.PGSQL.5433", o.Addr)
+
+	o, err = ParseURL("dbname=mydb")
+	assert.NoError(t, err)
+	assert.Equal(t, "", o.Addr)
+	assert.Equal(t, "mydb", o.Database)
+
+	cases := []struct {
+		dsn string
+		err string
+	}{
+		{"host=h1 dbname", `pg: missing "=" after "dbname" in connection string`},
+		{"host=h1 password='foo", "pg: unterminated quoted string in connection string"},
+		{"host=h1,h2,h3 port=1,2", "pg: could not match 2 port numbers to 3 hosts"},
+		{"host=h1 sslmode=foo", "pg: sslmode 'foo' is not supported"},
+		{"host=h1 foo=bar", "pg: connection parameter 'foo' is not supported " +
+			"(server settings can be set with options='-c name=value')"},
+		{"host=h1 search_path=app", "pg: connection parameter 'search_path' is not supported " +
+			"(server settings can be set with options='-c name=value')"},
+		{"host=h1 gssencmode=require", "pg: gssencmode 'require' is not supported"},
+	}
+	for _, c := range cases {
+		_, err := ParseURL(c.dsn)
+		assert.EqualError(t, err, c.err, c.dsn)
+	}
+}
+
+func TestParseURLRuntimeParams(t *testing.T) {
+	o, err := ParseURL("postgres://h1/db?sslmode=disable" +
+		"&options=-c%20search_path%3Dfoo,public%20-c%20lock_timeout%3D1s")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"options": "-c search_path=foo,public -c lock_timeout=1s",
+	}, o.RuntimeParams)
+
+	// libpq client parameters are accepted.
+	o, err = ParseURL("host=h1 dbname=db options='-c geqo=off' keepalives=1 keepalives_idle=30 " +
+		"gssencmode=disable sslsni=1 fallback_application_name=myapp")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"options": "-c geqo=off"}, o.RuntimeParams)
+	assert.Equal(t, "myapp", o.ApplicationName)
+
+	o.RuntimeParams["TimeZone"] = "UTC"
+	o.RuntimeParams["application_name"] = `my app\1`
+	o, err = ParseURL(o.ToURL())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"options": `-c geqo=off -c TimeZone=UTC -c application_name=my\ app\\1`,
+	}, o.RuntimeParams)
+
+	o, err = ParseURL("postgres://h1/db")
+	assert.NoError(t, err)
+	assert.Nil(t, o.RuntimeParams)
+}
+
+func TestOptions_initEnv(t *testing.T) {
+	t.Setenv("PGHOST", "db1.example.com,db2.example.com")
+	t.Setenv("PGPORT", "5433")
+	t.Setenv("PGUSER", "vasya")
+	t.Setenv("PGPASSWORD", "pupkin")
+	t.Setenv("PGDATABASE", "mydb")
+	t.Setenv("PGSSLMODE", "require")
+	t.Setenv("PGAPPNAME", "myapp")
+	t.Setenv("PGCONNECT_TIMEOUT", "3")
+
+	opt := new(Options)
+	opt.init()
+
+	assert.Equal(t, "tcp", opt.Network)
+	assert.Equal(t, "db1.example.com:5433,db2.example.com:5433", opt.Addr)
+	assert.Equal(t, "vasya", opt.User)
+	assert.Equal(t, "pupkin", opt.Password)
+	assert.Equal(t, "mydb", opt.Database)
+	assert.NotNil(t, opt.TLSConfig)
+	assert.Equal(t, "myapp", opt.ApplicationName)
+	assert.Equal(t, 3*time.Second, opt.DialTimeout)
+
+	t.Setenv("PGHOST", "/var/run/postgresql")
+	opt = &Options{ApplicationName: "other", DialTimeout: time.Second}
+	opt.init()
+
+	assert.Equal(t, "unix", opt.Network)
+	assert.Equal(t, "/var/run/postgresql/.s.PGSQL.5433", opt.Addr)
+	assert.Equal(t, "other", opt.ApplicationName)
+	assert.Equal(t, time.Second, opt.DialTimeout)
+}
+
+func TestOptions_addrs(t *testing.T) {
+	opt := &Options{Network: "tcp", Addr: "h1:5432,h2:5432"}
+	assert.Equal(t, []string{"h1:5432", "h2:5432"}, opt.addrs())
+
+	opt = &Options{Network: "unix", Addr: "/var/run/postgresql/.s.PGSQL.5432"}
+	assert.Equal(t, []string{"/var/run/postgresql/.s.PGSQL.5432"}, opt.addrs())
+}
+
 func TestOptions_ToURL(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,214 @@
+package orm
+
+import (
+	"strings"
+
+	"github.com/go-pg/pg/v10/internal"
+)
+
+// SQLSTATE codes of the constraint violations mapped to fields.
+const (
+	notNullViolation    = "23502"
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+// ConstraintError is returned by Insert and Update when the query violates
+// a unique, not-null or foreign key constraint of the model table.
+// It identifies the model fields that are responsible for the violation
+// using the column and constraint names sent by the server. Constraints
+// with custom names are only mapped when the server sends messages
+// in English, because the key columns are parsed from the detail.
+//
+// ConstraintError embeds the original error, so it can be used as pg.Error,
+// and unwraps to it, so errors.As works with pg.PGError.
+type ConstraintError struct {
+	internal.PGError
+
+	Table *Table
+	// Fields are the fields of the violated constraint,
+	// e.g. all fields of a multi-column unique constraint.
+	Fields []*Field
+}
+
+// Unwrap returns the PostgreSQL error.
+func (err *ConstraintError) Unwrap() error {
+	return err.PGError
+}
+
+// FieldNames returns the Go names of the fields, e.g. Email.
+func (err *ConstraintError) FieldNames() []string {
+	names := make([]string, len(err.Fields))
+	for i, f := range err.Fields {
+		names[i] = f.GoName
+	}
+	return names
+}
+
+// constraintError returns ConstraintError when err is a constraint violation
+// that can be mapped to the fields of the query table. Otherwise it returns err.
+func (q *Query) constraintError(err error) error {
+	pgErr, ok := err.(internal.PGError)
+	if !ok || q.tableModel == nil {
+		return err
+	}
+
+	table := q.tableModel.Table()
+	fields := table.constraintFields(pgErr)
+	if len(fields) == 0 {
+		return err
+	}
+	return &ConstraintError{
+		PGError: pgErr,
+		Table:   table,
+		Fields:  fields,
+	}
+}
+
+// constraintFields returns the fields responsible for the constraint violation.
+func (t *Table) constraintFields(pgErr internal.PGError) []*Field {
+	if pgErr.Table != "" && pgErr.Table != t.unquotedName() {
+		return nil
+	}
+
+	switch pgErr.Code {
+	case notNullViolation, uniqueViolation, foreignKeyViolation:
+	default:
+		return nil
+	}
+
+	// The column is sent when the violation is tied to a single column,
+	// e.g. for not-null constraints.
+	if pgErr.Column != "" {
+		if f := t.getField(pgErr.Column); f != nil {
+			return []*Field{f}
+		}
+		return nil
+	}
+
+	switch pgErr.Code {
+	case uniqueViolation:
+		if fields := t.uniqueConstraintFields(pgErr.Constraint); fields != nil {
+			return fields
+		}
+	case foreignKeyViolation:
+		if fields := t.fkConstraintFields(pgErr.Constraint); fields != nil {
+			return fields
+		}
+	}
+
+	// Constraints with custom names are matched using the key columns
+	// in the detail, e.g. Key (email)=(a@example.com) already exists.
+	// The detail is localized, so this only works when the server
+	// sends messages in English (lc_messages).
+	return t.fieldsByName(parseKeyColumns(pgErr.Detail))
+}
+
+// uniqueConstraintFields matches the constraint with the primary key and
+// unique fields using the names that PostgreSQL generates by default.
+func (t *Table) uniqueConstraintFields(constraint string) []*Field {
+	if constraint == "" {
+		return nil
+	}
+
+	name := t.unquotedName()
+	if constraint == name+"_pkey" {
+		return t.PKs
+	}
+
+	for _, f := range t.Fields {
+		if f.hasFlag(UniqueFlag) && constraint == defaultConstraintName(name, []*Field{f}, "key") {
+			return []*Field{f}
+		}
+	}
+	for _, fields := range t.Unique {
+		if constraint == defaultConstraintName(name, fields, "key") {
+			return fields
+		}
+	}
+	return nil
+}
+
+// fkConstraintFields matches the constraint with the foreign keys
+// of has one relations using the names that PostgreSQL generates by default.
+func (t *Table) fkConstraintFields(constraint string) []*Field {
+	if constraint == "" {
+		return nil
+	}
+
+	name := t.unquotedName()
+	for _, rel := range t.Relations {
+		if rel.Type != HasOneRelation {
+			continue
+		}
+		if constraint == defaultConstraintName(name, rel.BaseFKs, "fkey") {
+			return rel.BaseFKs
+		}
+	}
+	return nil
+}
+
+func (t *Table) fieldsByName(names []string) []*Field {
+	if len(names) == 0 {
+		return nil
+	}
+	fields := make([]*Field, 0, len(names))
+	for _, name := range names {
+		f := t.getField(name)
+		if f == nil {
+			return nil
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// unquotedName returns the table name without the schema and quotes.
+func (t *Table) unquotedName() string {
+	s := string(t.SQLName)
+	if i := strings.LastIndex(s, `".`); i >= 0 {
+		s = s[i+2:]
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = strings.ReplaceAll(s[1:len(s)-1], `""`, `"`)
+	}
+	return s
+}
+
+// defaultConstraintName returns the constraint name that PostgreSQL
+// generates, e.g. users_email_key. Long names are truncated by
+// the server, so they don't match.
+func defaultConstraintName(table string, fields []*Field, suffix string) string {
+	b := make([]byte, 0, 64)
+	b = append(b, table...)
+	for _, f := range fields {
+		b = append(b, '_')
+		b = append(b, f.SQLName...)
+	}
+	b = append(b, '_')
+	b = append(b, suffix...)
+	return internal.BytesToString(b)
+}
+
+// parseKeyColumns parses column names from details like
+// Key (a, b)=(1, 2) already exists. Localized details are not parsed.
+func parseKeyColumns(detail string) []string {
+	const prefix = "Key ("
+	if !strings.HasPrefix(detail, prefix) {
+		return nil
+	}
+	detail = detail[len(prefix):]
+
+	end := strings.Index(detail, ")=(")
+	if end == -1 {
+		return nil
+	}
+
+	names := strings.Split(detail[:end], ", ")
+	for i, name := range names {
+		if len(name) >= 2 && name[0] == '"' && name[len(name)-1] == '"' {
+			names[i] = strings.ReplaceAll(name[1:len(name)-1], `""`, `"`)
+		}
+	}
+	return names
+}
@@ -0,0 +1,151 @@
+package orm
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/go-pg/pg/v10/internal"
+)
+
+type ConstraintModel struct {
+	tableName struct{} `pg:"app.accounts"`
+
+	Id      int
+	Email   string `pg:",unique"`
+	OrgId   int    `pg:"unique:org_slug"`
+	Slug    string `pg:"unique:org_slug"`
+	Name    string `pg:",notnull"`
+	Owner   *HasOneModel
+	OwnerId int
+}
+
+// constraintDB returns err for all queries.
+type constraintDB struct {
+	DB
+
+	err error
+}
+
+func (db *constraintDB) Formatter() QueryFormatter {
+	return NewFormatter()
+}
+
+func (db *constraintDB) Context() context.Context {
+	return context.Background()
+}
+
+func (db *constraintDB) QueryContext(
+	c context.Context, model, query interface{}, params ...interface{},
+) (Result, error) {
+	return nil, db.err
+}
+
+var _ = Describe("ConstraintError", func() {
+	var db *constraintDB
+
+	BeforeEach(func() {
+		db = new(constraintDB)
+	})
+
+	insert := func(fields map[byte]string) error {
+		db.err = internal.NewPGError(fields)
+		_, err := NewQuery(db, &ConstraintModel{}).Insert()
+		return err
+	}
+
+	fieldNames := func(err error) []string {
+		var constraintErr *ConstraintError
+		Expect(errors.As(err, &constraintErr)).To(BeTrue())
+		Expect(constraintErr.Table.TypeName).To(Equal("ConstraintModel"))
+		return constraintErr.FieldNames()
+	}
+
+	It("maps not-null violation to the column", func() {
+		err := insert(map[byte]string{'C': "23502", 't': "accounts", 'c': "name"})
+		Expect(fieldNames(err)).To(Equal([]string{"Name"}))
+	})
+
+	It("maps unique violations using default constraint names", func() {
+		err := insert(map[byte]string{'C': "23505", 't': "accounts", 'n': "accounts_email_key"})
+		Expect(fieldNames(err)).To(Equal([]string{"Email"}))
+
+		err = insert(map[byte]string{'C': "23505", 't': "accounts", 'n': "accounts_org_id_slug_key"})
+		Expect(fieldNames(err)).To(Equal([]string{"OrgId", "Slug"}))
+
+		err = insert(map[byte]string{'C': "23505", 't': "accounts", 'n': "accounts_pkey"})
+		Expect(fieldNames(err)).To(Equal([]string{"Id"}))
+	})
+
+	It("maps foreign key violation using default constraint name", func() {
+		err := insert(map[byte]string{'C': "23503", 't': "accounts", 'n': "accounts_owner_id_fkey"})
+		Expect(fieldNames(err)).To(Equal([]string{"OwnerId"}))
+	})
+
+	It("maps custom constraint names using the key columns", func() {
+		err := insert(map[byte]string{
+			'C': "23505",
+			't': "accounts",
+			'n': "custom_idx",
+			'D': `Key (org_id, "slug")=(1, foo) already exists.`,
+		})
+		Expect(fieldNames(err)).To(Equal([]string{"OrgId", "Slug"}))
+	})
+
+	It("maps the column sent by the server", func() {
+		err := insert(map[byte]string{
+			'C': "23505",
+			't': "accounts",
+			'n': "custom_idx",
+			'c': "email",
+			'D': "Schlüssel »(slug)=(foo)« existiert bereits.",
+		})
+		Expect(fieldNames(err)).To(Equal([]string{"Email"}))
+	})
+
+	It("does not parse localized details", func() {
+		fields := map[byte]string{
+			'C': "23505",
+			't': "accounts",
+			'n': "custom_idx",
+			'D': "Schlüssel »(email)=(foo)« existiert bereits.",
+		}
+		err := insert(fields)
+		Expect(err).To(Equal(internal.NewPGError(fields)))
+	})
+
+	It("keeps the PostgreSQL error", func() {
+		err := insert(map[byte]string{'C': "23505", 'n': "accounts_email_key", 'M': "duplicate key"})
+
+		var pgErr internal.PGError
+		Expect(errors.As(err, &pgErr)).To(BeTrue())
+		Expect(pgErr.Constraint).To(Equal("accounts_email_key"))
+
+		constraintErr := err.(*ConstraintError)
+		Expect(constraintErr.Field('C')).To(Equal("23505"))
+		Expect(constraintErr.IntegrityViolation()).To(BeTrue())
+		Expect(err.Error()).To(Equal(pgErr.Error()))
+	})
+
+	It("returns other errors as is", func() {
+		fields := map[byte]string{'C': "23505", 't': "other_table", 'n': "accounts_email_key"}
+		err := insert(fields)
+		Expect(err).To(Equal(internal.NewPGError(fields)))
+
+		fields = map[byte]string{'C': "23514", 't': "accounts", 'n': "accounts_name_check"}
+		err = insert(fields)
+		Expect(err).To(Equal(internal.NewPGError(fields)))
+
+		db.err = errors.New("network error")
+		_, err = NewQuery(db, &ConstraintModel{}).Insert()
+		Expect(err).To(Equal(db.err))
+	})
+
+	It("maps errors of Update", func() {
+		db.err = internal.NewPGError(map[byte]string{'C': "23502", 't': "accounts", 'c': "name"})
+		_, err := NewQuery(db, &ConstraintModel{Id: 1}).WherePK().Update()
+		Expect(fieldNames(err)).To(Equal([]string{"Name"}))
+	})
+})
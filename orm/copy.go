@@ -0,0 +1,710 @@
+package orm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/go-pg/pg/v10/internal"
+	"github.com/go-pg/pg/v10/internal/pool"
+	"github.com/go-pg/pg/v10/types"
+)
+
+const copyFromBufferSize = 32 << 10
+
+// CopyFromModel copies rows into the model table with COPY FROM STDIN
+// encoding them in the text format, or the binary format with CopyBinary,
+// on the fly. Without arguments the rows
+// are taken from the model that must be a slice of structs or pointers
+// to structs. Otherwise rows are read from rows that is a slice, a channel
+// of structs or pointers to structs, or a func() (interface{}, error)
+// iterator that returns io.EOF after the last row.
+//
+// Columns can be limited with Column and ExcludeColumn.
+// Unlike Insert, COPY can't use DEFAULT for zero values, so they are
+// written as NULL, and zero values of columns that have a default,
+// e.g. serial primary keys, are rejected. Such columns should be
+// excluded or set.
+// RowsAffected of the returned result is the number of copied rows.
+func (q *Query) CopyFromModel(rows ...interface{}) (Result, error) {
+	if q.stickyErr != nil {
+		return nil, q.stickyErr
+	}
+	if q.tableModel == nil {
+		return nil, errModelNil
+	}
+
+	var source interface{}
+	switch len(rows) {
+	case 0:
+		if !q.hasTableModel() || q.tableModel.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("pg: CopyFromModel requires a slice model or rows")
+		}
+		source = q.tableModel.Value().Interface()
+	case 1:
+		source = rows[0]
+	default:
+		return nil, fmt.Errorf("pg: CopyFromModel got %d rows arguments, wanted at most 1", len(rows))
+	}
+
+	table := q.tableModel.Table()
+	fields, err := q.getFields()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		fields = table.Fields
+	}
+
+	next, err := copyFromSource(table, source)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.SQLName
+	}
+	binary := q.hasFlag(copyBinaryFlag)
+	columns, err := q.copyColumns(names, binary)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &copyFromReader{
+		table:    table,
+		fields:   fields,
+		defaults: make([]bool, len(fields)),
+		next:     next,
+	}
+	for i, col := range columns {
+		r.defaults[i] = col.HasDefault
+	}
+
+	b := append([]byte("COPY "), table.SQLName...)
+	b = append(b, " ("...)
+	b = appendColumns(b, "", fields)
+	b = append(b, ") FROM STDIN"...)
+
+	if binary {
+		r.dataTypes = make([]int32, len(columns))
+		for i, col := range columns {
+			r.dataTypes[i] = col.DataType
+		}
+		r.values = make([]interface{}, len(fields))
+		b = append(b, " WITH (FORMAT binary)"...)
+	}
+
+	return q.db.CopyFrom(r, string(b), q.tableModel)
+}
+
+type copyColumn struct {
+	Name       string `pg:"attname"`
+	DataType   int32  `pg:"atttypid"`
+	HasDefault bool   `pg:"has_default"`
+}
+
+// copyColumns returns the model table columns with the names
+// checking that their data types support the binary format if needed.
+func (q *Query) copyColumns(names []string, binary bool) ([]copyColumn, error) {
+	table := q.tableModel.Table()
+
+	var columns []copyColumn
+	_, err := q.db.QueryContext(q.ctx, &columns, `
+		SELECT attname, atttypid, atthasdef OR attidentity <> '' AS has_default
+		FROM pg_attribute
+		WHERE attrelid = ?::regclass AND attnum > 0 AND NOT attisdropped
+	`, string(table.SQLName))
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]copyColumn, len(names))
+	for i, name := range names {
+		for _, col := range columns {
+			if col.Name == name {
+				found[i] = col
+				break
+			}
+		}
+		if found[i].DataType == 0 {
+			return nil, fmt.Errorf("pg: table %s does not have column=%q", table.SQLName, name)
+		}
+		if binary && !types.SupportsBinaryEncoding(found[i].DataType) {
+			return nil, fmt.Errorf("pg: column=%q of type=%d does not support binary COPY",
+				name, found[i].DataType)
+		}
+	}
+	return found, nil
+}
+
+// copyFromSource returns a function that returns the next struct
+// of the source or io.EOF.
+func copyFromSource(table *Table, source interface{}) (func() (reflect.Value, error), error) {
+	if fn, ok := source.(func() (interface{}, error)); ok {
+		return func() (reflect.Value, error) {
+			row, err := fn()
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			return copyFromStruct(table, reflect.ValueOf(row))
+		}, nil
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(source))
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		var i int
+		return func() (reflect.Value, error) {
+			if i >= v.Len() {
+				return reflect.Value{}, io.EOF
+			}
+			elem := v.Index(i)
+			i++
+			return copyFromStruct(table, elem)
+		}, nil
+	case reflect.Chan:
+		if v.Type().ChanDir()&reflect.RecvDir == 0 {
+			return nil, fmt.Errorf("pg: CopyFromModel(send-only %s)", v.Type())
+		}
+		return func() (reflect.Value, error) {
+			elem, ok := v.Recv()
+			if !ok {
+				return reflect.Value{}, io.EOF
+			}
+			return copyFromStruct(table, elem)
+		}, nil
+	default:
+		return nil, fmt.Errorf("pg: CopyFromModel(unsupported %T)", source)
+	}
+}
+
+func copyFromStruct(table *Table, v reflect.Value) (reflect.Value, error) {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}, errors.New("pg: CopyFromModel got nil row")
+		}
+		v = v.Elem()
+	}
+	if v.Type() != table.Type {
+		return reflect.Value{}, fmt.Errorf("pg: CopyFromModel got %s, wanted %s", v.Type(), table.Type)
+	}
+	return v, nil
+}
+
+// copyFromReader encodes rows in the COPY text format or in the binary
+// format when data types are set.
+type copyFromReader struct {
+	table    *Table
+	fields   []*Field
+	defaults []bool
+	next     func() (reflect.Value, error)
+
+	dataTypes []int32
+	values    []interface{}
+	header    bool
+
+	buf []byte
+	pos int
+	tmp []byte
+	err error
+}
+
+var _ io.Reader = (*copyFromReader)(nil)
+
+func (r *copyFromReader) Read(p []byte) (int, error) {
+	for r.pos == len(r.buf) {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.fill()
+	}
+
+	n := copy(p, r.buf[r.pos:])
+	r.pos += n
+	return n, nil
+}
+
+// fill encodes rows until the buffer is full or the rows are exhausted.
+func (r *copyFromReader) fill() {
+	r.buf = r.buf[:0]
+	r.pos = 0
+	if r.dataTypes != nil && !r.header {
+		r.buf = types.AppendCopyBinaryHeader(r.buf)
+		r.header = true
+	}
+
+	for len(r.buf) < copyFromBufferSize {
+		strct, err := r.next()
+		if err != nil {
+			if err == io.EOF && r.dataTypes != nil {
+				r.buf = types.AppendCopyBinaryTrailer(r.buf)
+			}
+			r.err = err
+			return
+		}
+
+		if err := r.checkDefaults(strct); err != nil {
+			r.err = err
+			return
+		}
+
+		if r.dataTypes == nil {
+			r.buf = r.appendRow(r.buf, strct)
+			continue
+		}
+
+		b, err := r.appendBinaryRow(r.buf, strct)
+		if err != nil {
+			r.err = err
+			return
+		}
+		r.buf = b
+	}
+}
+
+// checkDefaults returns an error for zero values of columns with
+// a default. Insert uses DEFAULT for them, but COPY would write NULL.
+func (r *copyFromReader) checkDefaults(strct reflect.Value) error {
+	for i, f := range r.fields {
+		if r.defaults[i] && f.NullZero() && f.HasZeroValue(strct) {
+			return fmt.Errorf("pg: CopyFromModel can't use the default of column=%q "+
+				"for zero %s.%s (set the field, exclude the column or use the use_zero tag option)",
+				f.SQLName, r.table.TypeName, f.GoName)
+		}
+	}
+	return nil
+}
+
+func (r *copyFromReader) appendBinaryRow(b []byte, strct reflect.Value) ([]byte, error) {
+	for i, f := range r.fields {
+		r.values[i] = nil
+		fv, ok := fieldByIndex(strct, f.Index)
+		if !ok || f.NullZero() && f.isZero(fv) {
+			continue
+		}
+		r.values[i] = fv.Interface()
+	}
+	return types.AppendCopyBinaryRow(b, r.dataTypes, r.values...)
+}
+
+func (r *copyFromReader) appendRow(b []byte, strct reflect.Value) []byte {
+	for i, f := range r.fields {
+		if i > 0 {
+			b = append(b, '\t')
+		}
+
+		if r.tmp == nil {
+			r.tmp = make([]byte, 0, 64)
+		}
+		// Appenders return nil for NULL when values are not quoted.
+		value := f.AppendValue(r.tmp[:0], strct, 0)
+		if value == nil {
+			b = append(b, `\N`...)
+			continue
+		}
+		r.tmp = value
+		b = appendCopyText(b, value)
+	}
+	return append(b, '\n')
+}
+
+// appendCopyText escapes the value for the COPY text format.
+func appendCopyText(b, value []byte) []byte {
+	for _, c := range value {
+		switch c {
+		case '\\':
+			b = append(b, '\\', '\\')
+		case '\n':
+			b = append(b, '\\', 'n')
+		case '\r':
+			b = append(b, '\\', 'r')
+		case '\t':
+			b = append(b, '\\', 't')
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
+
+//------------------------------------------------------------------------------
+
+// CopyToModel selects rows with COPY (SELECT ...) TO STDOUT and scans
+// them into the model that must be a slice of structs or pointers to structs.
+// The COPY output in the text format, or the binary format with CopyBinary,
+// is parsed as it is received, which is faster than Select for large tables.
+//
+// Columns can be limited with Column and ExcludeColumn.
+// RowsAffected of the returned result is the number of copied rows.
+func (q *Query) CopyToModel() (Result, error) {
+	if q.stickyErr != nil {
+		return nil, q.stickyErr
+	}
+	if !q.hasTableModel() || q.tableModel.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("pg: CopyToModel requires a slice model")
+	}
+	return q.copyTo(q.tableModel)
+}
+
+// CopyToForEach is like CopyToModel, but calls the fn for each row
+// like ForEach.
+func (q *Query) CopyToForEach(fn interface{}) (Result, error) {
+	if q.stickyErr != nil {
+		return nil, q.stickyErr
+	}
+	return q.copyTo(newFuncModel(fn))
+}
+
+func (q *Query) copyTo(model Model) (Result, error) {
+	if q.tableModel == nil {
+		return nil, errModelNil
+	}
+	if len(q.tableModel.GetJoins()) > 0 {
+		return nil, errors.New("pg: CopyToModel does not support relations")
+	}
+
+	q = q.Clone()
+	if q.columns == nil {
+		for _, f := range q.tableModel.Table().Fields {
+			q.columns = append(q.columns, fieldAppender{f.SQLName})
+		}
+	}
+
+	columns := make([]types.ColumnInfo, len(q.columns))
+	for i, col := range q.columns {
+		app, ok := col.(fieldAppender)
+		if !ok {
+			return nil, errors.New("pg: CopyToModel supports only model columns")
+		}
+		columns[i] = types.ColumnInfo{
+			Index: int16(i),
+			Name:  app.field,
+		}
+	}
+
+	binary := q.hasFlag(copyBinaryFlag)
+	if binary {
+		names := make([]string, len(columns))
+		for i := range columns {
+			names[i] = columns[i].Name
+		}
+		copyColumns, err := q.copyColumns(names, true)
+		if err != nil {
+			return nil, err
+		}
+		for i := range columns {
+			columns[i].DataType = copyColumns[i].DataType
+			columns[i].Format = types.BinaryFormat
+		}
+	}
+
+	if err := model.Init(); err != nil {
+		return nil, err
+	}
+
+	w := &copyToWriter{
+		ctx:     q.ctx,
+		model:   model,
+		columns: columns,
+		binary:  binary,
+	}
+	cq := copyToQuery{
+		sel:    NewSelectQuery(q),
+		binary: binary,
+	}
+	res, err := q.db.CopyTo(w, cq, q.tableModel)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.flush(); err != nil {
+		return nil, err
+	}
+
+	if err := model.AfterSelect(q.ctx); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+type copyToQuery struct {
+	sel    *SelectQuery
+	binary bool
+}
+
+var _ QueryAppender = copyToQuery{}
+
+func (q copyToQuery) AppendQuery(fmter QueryFormatter, b []byte) ([]byte, error) {
+	b = append(b, "COPY ("...)
+	b, err := q.sel.AppendQuery(fmter, b)
+	if err != nil {
+		return nil, err
+	}
+	b = append(b, ") TO STDOUT"...)
+	if q.binary {
+		b = append(b, " WITH (FORMAT binary)"...)
+	}
+	return b, nil
+}
+
+// copyToWriter parses rows in the COPY text or binary format and scans
+// them into the model.
+type copyToWriter struct {
+	ctx     context.Context
+	model   Model
+	columns []types.ColumnInfo
+
+	binary  bool
+	header  bool
+	trailer bool
+	fields  [][]byte
+
+	buf   []byte
+	value []byte
+	err   error
+}
+
+var _ io.Writer = (*copyToWriter)(nil)
+
+func (w *copyToWriter) Write(b []byte) (int, error) {
+	w.buf = append(w.buf, b...)
+
+	if w.binary {
+		w.writeBinary()
+		return len(b), nil
+	}
+
+	var pos int
+	for {
+		i := bytes.IndexByte(w.buf[pos:], '\n')
+		if i == -1 {
+			break
+		}
+		row := w.buf[pos : pos+i]
+		w.scanRow(func(scanner ColumnScanner) error {
+			return w.scanTextRow(scanner, row)
+		})
+		pos += i + 1
+	}
+	w.buf = w.buf[:copy(w.buf, w.buf[pos:])]
+
+	return len(b), nil
+}
+
+// flush returns the first scan error or an error for an incomplete row.
+func (w *copyToWriter) flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.buf) > 0 || w.binary && !w.trailer {
+		return errors.New("pg: COPY data ends with an incomplete row")
+	}
+	return nil
+}
+
+// writeBinary scans complete rows in the binary format.
+func (w *copyToWriter) writeBinary() {
+	if w.err != nil {
+		// The data is discarded after the first error.
+		w.buf = w.buf[:0]
+		return
+	}
+
+	var pos int
+	if !w.header {
+		n, err := types.ParseCopyBinaryHeader(w.buf)
+		if err != nil {
+			w.err = err
+			return
+		}
+		if n == 0 {
+			return
+		}
+		pos = n
+		w.header = true
+	}
+
+	for !w.trailer {
+		fields, n, err := types.ParseCopyBinaryRow(w.buf[pos:], w.fields[:0])
+		if err == io.EOF {
+			w.trailer = true
+			pos += n
+			break
+		}
+		if err != nil {
+			w.err = err
+			break
+		}
+		if n == 0 {
+			break
+		}
+		w.fields = fields
+		w.scanRow(func(scanner ColumnScanner) error {
+			return w.scanBinaryRow(scanner, fields)
+		})
+		pos += n
+	}
+	w.buf = w.buf[:copy(w.buf, w.buf[pos:])]
+}
+
+func (w *copyToWriter) scanBinaryRow(scanner ColumnScanner, fields [][]byte) error {
+	if len(fields) != len(w.columns) {
+		return fmt.Errorf("pg: COPY row has %d columns, wanted %d", len(fields), len(w.columns))
+	}
+
+	for i, field := range fields {
+		n := len(field)
+		if field == nil {
+			n = -1
+		}
+		err := scanBinaryColumn(scanner, w.columns[i], pool.NewBytesReader(field), n)
+		if err != nil {
+			return internal.Errorf(err.Error())
+		}
+	}
+	return nil
+}
+
+// scanBinaryColumn converts values to the text format for scanners
+// that don't support the binary format.
+func scanBinaryColumn(scanner ColumnScanner, col types.ColumnInfo, rd types.Reader, n int) error {
+	if s, ok := scanner.(BinaryColumnScanner); ok {
+		return s.ScanBinaryColumn(col, rd, n)
+	}
+
+	rd, n, err := types.ReadBinaryAsText(col, rd, n)
+	if err != nil {
+		return err
+	}
+	col.Format = types.TextFormat
+	return scanner.ScanColumn(col, rd, n)
+}
+
+// scanRow scans the row with scan like readDataRow does. The first error
+// is remembered and the remaining rows are skipped.
+func (w *copyToWriter) scanRow(scan func(scanner ColumnScanner) error) {
+	if w.err != nil {
+		return
+	}
+
+	scanner := w.model.NextColumnScanner()
+
+	if h, ok := scanner.(BeforeScanHook); ok {
+		if err := h.BeforeScan(w.ctx); err != nil {
+			w.err = err
+			return
+		}
+	}
+
+	if err := scan(scanner); err != nil {
+		w.err = err
+		return
+	}
+
+	if h, ok := scanner.(AfterScanHook); ok {
+		if err := h.AfterScan(w.ctx); err != nil {
+			w.err = err
+			return
+		}
+	}
+
+	if err := w.model.AddColumnScanner(scanner); err != nil {
+		w.err = err
+	}
+}
+
+func (w *copyToWriter) scanTextRow(scanner ColumnScanner, row []byte) error {
+	for i := range w.columns {
+		var field []byte
+		if i == len(w.columns)-1 {
+			field = row
+		} else {
+			j := bytes.IndexByte(row, '\t')
+			if j == -1 {
+				return fmt.Errorf("pg: COPY row has less than %d columns", len(w.columns))
+			}
+			field, row = row[:j], row[j+1:]
+		}
+
+		col := w.columns[i]
+		var err error
+		if string(field) == `\N` {
+			err = scanner.ScanColumn(col, pool.NewBytesReader(nil), -1)
+		} else {
+			w.value = parseCopyText(w.value[:0], field)
+			err = scanner.ScanColumn(col, pool.NewBytesReader(w.value), len(w.value))
+		}
+		if err != nil {
+			return internal.Errorf(err.Error())
+		}
+	}
+	return nil
+}
+
+// parseCopyText unescapes a value in the COPY text format.
+func parseCopyText(b, src []byte) []byte {
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if c != '\\' || i+1 == len(src) {
+			b = append(b, c)
+			continue
+		}
+
+		i++
+		c = src[i]
+		switch c {
+		case 'b':
+			b = append(b, '\b')
+		case 'f':
+			b = append(b, '\f')
+		case 'n':
+			b = append(b, '\n')
+		case 'r':
+			b = append(b, '\r')
+		case 't':
+			b = append(b, '\t')
+		case 'v':
+			b = append(b, '\v')
+		case 'x':
+			var n byte
+			var j int
+			for ; j < 2 && i+1 < len(src) && isHexDigit(src[i+1]); j++ {
+				i++
+				n = n<<4 | unhex(src[i])
+			}
+			if j == 0 {
+				b = append(b, 'x')
+			} else {
+				b = append(b, n)
+			}
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			n := c - '0'
+			for j := 0; j < 2 && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '7'; j++ {
+				i++
+				n = n<<3 | (src[i] - '0')
+			}
+			b = append(b, n)
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
+	default:
+		return c - '0'
+	}
+}
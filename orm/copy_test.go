@@ -0,0 +1,301 @@
+package orm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/go-pg/pg/v10/types"
+)
+
+type CopyTest struct {
+	Id    int
+	Value string
+	Tags  []string `pg:",array"`
+	Ptr   *int
+}
+
+// copyDB records the query and the data passed to CopyFrom.
+type copyDB struct {
+	DB
+
+	query string
+	data  string
+}
+
+// copyTestColumns are returned for the pg_attribute query.
+var copyTestColumns = []copyColumn{
+	{Name: "id", DataType: 20, HasDefault: true},
+	{Name: "value", DataType: 25},
+	{Name: "tags", DataType: 1009},
+	{Name: "ptr", DataType: 23},
+}
+
+var copyTestTypes = []int32{20, 25, 1009, 23}
+
+func (db *copyDB) QueryContext(
+	c context.Context, model, query interface{}, params ...interface{},
+) (Result, error) {
+	*model.(*[]copyColumn) = copyTestColumns
+	return nil, nil
+}
+
+func (db *copyDB) Formatter() QueryFormatter {
+	return NewFormatter()
+}
+
+func (db *copyDB) Context() context.Context {
+	return context.Background()
+}
+
+func (db *copyDB) CopyFrom(r io.Reader, query interface{}, params ...interface{}) (Result, error) {
+	db.query = query.(string)
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	db.data = string(b)
+	return nil, nil
+}
+
+func (db *copyDB) CopyTo(w io.Writer, query interface{}, params ...interface{}) (Result, error) {
+	b, err := query.(QueryAppender).AppendQuery(db.Formatter(), nil)
+	if err != nil {
+		return nil, err
+	}
+	db.query = string(b)
+
+	// Write the data in small chunks to split rows.
+	data := []byte(db.data)
+	for len(data) > 0 {
+		n := 3
+		if n > len(data) {
+			n = len(data)
+		}
+		if _, err := w.Write(data[:n]); err != nil {
+			return nil, err
+		}
+		data = data[n:]
+	}
+	return nil, nil
+}
+
+var _ = Describe("CopyFromModel", func() {
+	var db *copyDB
+
+	BeforeEach(func() {
+		db = new(copyDB)
+	})
+
+	It("encodes slice model in text format", func() {
+		n := 42
+		rows := []CopyTest{
+			{Id: 1, Value: "a\tb\nc\\d", Tags: []string{"x", "y"}, Ptr: &n},
+			{Id: 2},
+		}
+		_, err := NewQuery(db, &rows).CopyFromModel()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db.query).To(Equal(`COPY "copy_tests" ("id", "value", "tags", "ptr") FROM STDIN`))
+		Expect(db.data).To(Equal(
+			"1\ta\\tb\\nc\\\\d\t{\"x\",\"y\"}\t42\n" +
+				"2\t\\N\t\\N\t\\N\n"))
+	})
+
+	It("supports Column", func() {
+		rows := []*CopyTest{{Id: 1, Value: "foo"}}
+		_, err := NewQuery(db, &rows).Column("value").CopyFromModel()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db.query).To(Equal(`COPY "copy_tests" ("value") FROM STDIN`))
+		Expect(db.data).To(Equal("foo\n"))
+	})
+
+	It("reads rows from channel", func() {
+		ch := make(chan *CopyTest, 2)
+		ch <- &CopyTest{Id: 1}
+		ch <- &CopyTest{Id: 2}
+		close(ch)
+
+		_, err := NewQuery(db, (*CopyTest)(nil)).Column("id").CopyFromModel(ch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db.data).To(Equal("1\n2\n"))
+	})
+
+	It("reads rows from iterator", func() {
+		var i int
+		next := func() (interface{}, error) {
+			if i == 3 {
+				return nil, io.EOF
+			}
+			i++
+			return CopyTest{Id: i}, nil
+		}
+
+		_, err := NewQuery(db, (*CopyTest)(nil)).Column("id").CopyFromModel(next)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db.data).To(Equal("1\n2\n3\n"))
+	})
+
+	It("returns iterator error", func() {
+		next := func() (interface{}, error) {
+			return nil, errors.New("iterator failed")
+		}
+
+		_, err := NewQuery(db, (*CopyTest)(nil)).CopyFromModel(next)
+		Expect(err).To(MatchError("iterator failed"))
+	})
+
+	It("rejects zero values of columns with a default", func() {
+		rows := []CopyTest{{Id: 1}, {}}
+		_, err := NewQuery(db, &rows).CopyFromModel()
+		Expect(err).To(MatchError(`pg: CopyFromModel can't use the default of column="id" ` +
+			"for zero CopyTest.Id (set the field, exclude the column or use the use_zero tag option)"))
+
+		_, err = NewQuery(db, &rows).ExcludeColumn("id").CopyFromModel()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db.query).To(Equal(`COPY "copy_tests" ("value", "tags", "ptr") FROM STDIN`))
+		Expect(db.data).To(Equal("\\N\t\\N\t\\N\n\\N\t\\N\t\\N\n"))
+	})
+
+	It("rejects rows of another type", func() {
+		rows := []InsertTest{{Id: 1}}
+		_, err := NewQuery(db, (*CopyTest)(nil)).CopyFromModel(rows)
+		Expect(err).To(MatchError("pg: CopyFromModel got orm.InsertTest, wanted orm.CopyTest"))
+	})
+
+	It("encodes rows in binary format", func() {
+		n := 42
+		rows := []CopyTest{
+			{Id: 1, Value: "foo", Tags: []string{"x", "y"}, Ptr: &n},
+			{Id: 2},
+		}
+		_, err := NewQuery(db, &rows).CopyBinary().CopyFromModel()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db.query).To(Equal(`COPY "copy_tests" ("id", "value", "tags", "ptr") FROM STDIN WITH (FORMAT binary)`))
+
+		var buf bytes.Buffer
+		w := types.NewCopyBinaryWriter(&buf, copyTestTypes)
+		Expect(w.WriteRow(1, "foo", []string{"x", "y"}, 42)).NotTo(HaveOccurred())
+		Expect(w.WriteRow(2, nil, nil, nil)).NotTo(HaveOccurred())
+		Expect(w.Close()).NotTo(HaveOccurred())
+		Expect(db.data).To(Equal(buf.String()))
+	})
+
+	It("requires slice model without rows", func() {
+		_, err := NewQuery(db, &CopyTest{}).CopyFromModel()
+		Expect(err).To(MatchError("pg: CopyFromModel requires a slice model or rows"))
+	})
+})
+
+var _ = Describe("CopyToModel", func() {
+	var db *copyDB
+
+	BeforeEach(func() {
+		db = new(copyDB)
+	})
+
+	It("scans rows into slice model", func() {
+		db.data = "1\ta\\tb\\nc\\\\d\t{x,y}\t42\n" +
+			"2\t\\N\t\\N\t\\N\n"
+
+		var rows []CopyTest
+		_, err := NewQuery(db, &rows).Where("id > 0").CopyToModel()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db.query).To(Equal(`COPY (SELECT "id", "value", "tags", "ptr" FROM "copy_tests" AS "copy_test" WHERE (id > 0)) TO STDOUT`))
+
+		n := 42
+		Expect(rows).To(Equal([]CopyTest{
+			{Id: 1, Value: "a\tb\nc\\d", Tags: []string{"x", "y"}, Ptr: &n},
+			{Id: 2},
+		}))
+	})
+
+	It("supports Column", func() {
+		db.data = "foo\nbar\n"
+
+		var rows []*CopyTest
+		_, err := NewQuery(db, &rows).Column("value").CopyToModel()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rows).To(HaveLen(2))
+		Expect(rows[0].Value).To(Equal("foo"))
+		Expect(rows[1].Value).To(Equal("bar"))
+	})
+
+	It("calls fn for each row", func() {
+		db.data = "1\n2\n3\n"
+
+		var ids []int
+		_, err := NewQuery(db, (*CopyTest)(nil)).Column("id").CopyToForEach(func(row *CopyTest) error {
+			ids = append(ids, row.Id)
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids).To(Equal([]int{1, 2, 3}))
+	})
+
+	It("scans rows in binary format", func() {
+		n := 42
+		var buf bytes.Buffer
+		w := types.NewCopyBinaryWriter(&buf, copyTestTypes)
+		Expect(w.WriteRow(1, "a\tb", []string{"x", "y"}, n)).NotTo(HaveOccurred())
+		Expect(w.WriteRow(2, nil, nil, nil)).NotTo(HaveOccurred())
+		Expect(w.Close()).NotTo(HaveOccurred())
+		db.data = buf.String()
+
+		var rows []CopyTest
+		_, err := NewQuery(db, &rows).CopyBinary().CopyToModel()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db.query).To(Equal(`COPY (SELECT "id", "value", "tags", "ptr" FROM "copy_tests" AS "copy_test") TO STDOUT WITH (FORMAT binary)`))
+		Expect(rows).To(Equal([]CopyTest{
+			{Id: 1, Value: "a\tb", Tags: []string{"x", "y"}, Ptr: &n},
+			{Id: 2},
+		}))
+	})
+
+	It("returns error for incomplete binary data", func() {
+		var buf bytes.Buffer
+		w := types.NewCopyBinaryWriter(&buf, []int32{20})
+		Expect(w.WriteRow(1)).NotTo(HaveOccurred())
+		Expect(w.Flush()).NotTo(HaveOccurred())
+		db.data = buf.String()
+
+		var rows []CopyTest
+		_, err := NewQuery(db, &rows).Column("id").CopyBinary().CopyToModel()
+		Expect(err).To(MatchError("pg: COPY data ends with an incomplete row"))
+	})
+
+	It("returns scan error", func() {
+		db.data = "foo\n"
+
+		var rows []CopyTest
+		_, err := NewQuery(db, &rows).Column("id").CopyToModel()
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns error for incomplete row", func() {
+		db.data = "1"
+
+		var rows []CopyTest
+		_, err := NewQuery(db, &rows).Column("id").CopyToModel()
+		Expect(err).To(MatchError("pg: COPY data ends with an incomplete row"))
+	})
+
+	It("unescapes text values", func() {
+		tests := []struct {
+			src, wanted string
+		}{
+			{`plain`, "plain"},
+			{`\\\t\n\r\b\f\v`, "\\\t\n\r\b\f\v"},
+			{`\101\7`, "A\x07"},
+			{`\x41\x4a2\xz`, "AJ2xz"},
+			{`trailing\`, `trailing\`},
+		}
+		for _, test := range tests {
+			Expect(string(parseCopyText(nil, []byte(test.src)))).To(Equal(test.wanted))
+		}
+	})
+})
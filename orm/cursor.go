@@ -0,0 +1,149 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/go-pg/pg/v10/types"
+)
+
+var cursorSeq uint64
+
+// Cursor iterates over the rows of a select query in batches using
+// a server-side cursor. Rows are not materialized by the server and only
+// a batch of rows is held in memory at a time.
+type Cursor struct {
+	ctx       context.Context
+	db        DB
+	table     *Table
+	name      string
+	batchSize int
+
+	done   bool
+	closed bool
+	err    error
+}
+
+// Cursor declares a server-side cursor with DECLARE ... CURSOR for
+// the select query. Rows are fetched with FETCH batchSize rows at a time
+// by Cursor.Next, and the cursor is closed when all rows are fetched
+// or on error.
+//
+// Cursors only exist inside a transaction, so the query must be created
+// with Tx.Model.
+func (q *Query) Cursor(c context.Context, batchSize int) (*Cursor, error) {
+	if q.stickyErr != nil {
+		return nil, q.stickyErr
+	}
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("pg: Cursor got batchSize=%d, wanted > 0", batchSize)
+	}
+	if q.tableModel == nil {
+		return nil, errModelNil
+	}
+	if len(q.tableModel.GetJoins()) > 0 {
+		return nil, errors.New("pg: Cursor does not support relations")
+	}
+
+	cur := &Cursor{
+		ctx:       c,
+		db:        q.db,
+		table:     q.tableModel.Table(),
+		name:      "pg_cursor_" + strconv.FormatUint(atomic.AddUint64(&cursorSeq, 1), 10),
+		batchSize: batchSize,
+	}
+
+	query := declareCursorQuery{
+		name: cur.name,
+		sel:  NewSelectQuery(q),
+	}
+	if _, err := q.db.ExecContext(c, query, q.tableModel); err != nil {
+		return nil, err
+	}
+	return cur, nil
+}
+
+// Next fetches the next batch of rows into dst that must be a pointer
+// to a slice of the query model. The slice is reset before scanning.
+// It returns false when there are no more rows or on error, which is
+// available with Err. The cursor is closed after the last batch.
+func (cur *Cursor) Next(dst interface{}) bool {
+	if cur.err != nil || cur.done {
+		return false
+	}
+
+	m, err := NewModel(dst)
+	if err != nil {
+		cur.fail(err)
+		return false
+	}
+	model, ok := m.(TableModel)
+	if !ok || model.Kind() != reflect.Slice || model.Table() != cur.table {
+		cur.fail(fmt.Errorf("pg: Cursor.Next(%T), wanted a pointer to []%s", dst, cur.table.Type))
+		return false
+	}
+
+	res, err := cur.db.QueryContext(
+		cur.ctx, model, "FETCH FORWARD ? FROM ?", cur.batchSize, types.Ident(cur.name))
+	if err != nil {
+		// The transaction is aborted, so the cursor can't be closed.
+		cur.closed = true
+		cur.fail(err)
+		return false
+	}
+
+	if err := model.AfterSelect(cur.ctx); err != nil {
+		cur.fail(err)
+		return false
+	}
+
+	n := res.RowsReturned()
+	if n < cur.batchSize {
+		cur.done = true
+		if err := cur.Close(); err != nil {
+			cur.err = err
+		}
+	}
+	return n > 0
+}
+
+// Err returns the first error that occurred during iteration.
+func (cur *Cursor) Err() error {
+	return cur.err
+}
+
+// Close closes the server-side cursor with CLOSE. It is called
+// automatically when Next returns false and is safe to call many times.
+func (cur *Cursor) Close() error {
+	if cur.closed {
+		return nil
+	}
+	cur.closed = true
+	cur.done = true
+	_, err := cur.db.ExecContext(cur.ctx, "CLOSE ?", types.Ident(cur.name))
+	return err
+}
+
+// fail remembers the error and closes the cursor ignoring the close error.
+func (cur *Cursor) fail(err error) {
+	cur.err = err
+	_ = cur.Close()
+}
+
+type declareCursorQuery struct {
+	name string
+	sel  *SelectQuery
+}
+
+var _ QueryAppender = declareCursorQuery{}
+
+func (q declareCursorQuery) AppendQuery(fmter QueryFormatter, b []byte) ([]byte, error) {
+	b = append(b, "DECLARE "...)
+	b = types.AppendIdent(b, q.name, 1)
+	b = append(b, " NO SCROLL CURSOR FOR "...)
+	return q.sel.AppendQuery(fmter, b)
+}
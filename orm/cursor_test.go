@@ -0,0 +1,147 @@
+package orm
+
+import (
+	"context"
+	"strconv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/go-pg/pg/v10/internal/pool"
+	"github.com/go-pg/pg/v10/types"
+)
+
+type cursorResult struct {
+	n int
+}
+
+func (res cursorResult) Model() Model      { return nil }
+func (res cursorResult) RowsAffected() int { return res.n }
+func (res cursorResult) RowsReturned() int { return res.n }
+
+// cursorDB records queries and returns ids from 1 to rows for FETCH.
+type cursorDB struct {
+	DB
+
+	rows    int
+	next    int
+	queries []string
+}
+
+func (db *cursorDB) Formatter() QueryFormatter {
+	return NewFormatter()
+}
+
+func (db *cursorDB) Context() context.Context {
+	return context.Background()
+}
+
+func (db *cursorDB) format(query interface{}, params ...interface{}) string {
+	if q, ok := query.(QueryAppender); ok {
+		b, err := q.AppendQuery(db.Formatter(), nil)
+		Expect(err).NotTo(HaveOccurred())
+		return string(b)
+	}
+	return string(db.Formatter().FormatQuery(nil, query.(string), params...))
+}
+
+func (db *cursorDB) ExecContext(c context.Context, query interface{}, params ...interface{}) (Result, error) {
+	db.queries = append(db.queries, db.format(query, params...))
+	return cursorResult{}, nil
+}
+
+func (db *cursorDB) QueryContext(
+	c context.Context, model, query interface{}, params ...interface{},
+) (Result, error) {
+	db.queries = append(db.queries, db.format(query, params...))
+
+	m := model.(Model)
+	Expect(m.Init()).NotTo(HaveOccurred())
+
+	var n int
+	for ; n < params[0].(int) && db.next < db.rows; n++ {
+		db.next++
+		id := []byte(strconv.Itoa(db.next))
+		scanner := m.NextColumnScanner()
+		col := types.ColumnInfo{Name: "id"}
+		Expect(scanner.ScanColumn(col, pool.NewBytesReader(id), len(id))).NotTo(HaveOccurred())
+		Expect(m.AddColumnScanner(scanner)).NotTo(HaveOccurred())
+	}
+	return cursorResult{n: n}, nil
+}
+
+var _ = Describe("Cursor", func() {
+	var db *cursorDB
+
+	BeforeEach(func() {
+		db = new(cursorDB)
+	})
+
+	It("fetches rows in batches and closes cursor", func() {
+		db.rows = 5
+
+		cur, err := NewQuery(db, (*SelectModel)(nil)).Column("id").Where("id > 0").Cursor(context.Background(), 2)
+		Expect(err).NotTo(HaveOccurred())
+
+		var ids []int
+		var batches int
+		var models []SelectModel
+		for cur.Next(&models) {
+			batches++
+			for _, m := range models {
+				ids = append(ids, m.Id)
+			}
+		}
+		Expect(cur.Err()).NotTo(HaveOccurred())
+		Expect(batches).To(Equal(3))
+		Expect(ids).To(Equal([]int{1, 2, 3, 4, 5}))
+
+		name := cur.name
+		Expect(db.queries).To(Equal([]string{
+			`DECLARE "` + name + `" NO SCROLL CURSOR FOR SELECT "id" FROM "select_models" AS "select_model" WHERE (id > 0)`,
+			`FETCH FORWARD 2 FROM "` + name + `"`,
+			`FETCH FORWARD 2 FROM "` + name + `"`,
+			`FETCH FORWARD 2 FROM "` + name + `"`,
+			`CLOSE "` + name + `"`,
+		}))
+
+		Expect(cur.Next(&models)).To(BeFalse())
+		Expect(cur.Close()).NotTo(HaveOccurred())
+		Expect(db.queries).To(HaveLen(5))
+	})
+
+	It("closes cursor when the last batch is full", func() {
+		db.rows = 2
+
+		cur, err := NewQuery(db, (*SelectModel)(nil)).Column("id").Cursor(context.Background(), 2)
+		Expect(err).NotTo(HaveOccurred())
+
+		var models []SelectModel
+		Expect(cur.Next(&models)).To(BeTrue())
+		Expect(models).To(HaveLen(2))
+		Expect(cur.Next(&models)).To(BeFalse())
+		Expect(models).To(BeEmpty())
+		Expect(cur.Err()).NotTo(HaveOccurred())
+		Expect(db.queries[len(db.queries)-1]).To(HavePrefix("CLOSE "))
+	})
+
+	It("rejects destination of another type", func() {
+		cur, err := NewQuery(db, (*SelectModel)(nil)).Cursor(context.Background(), 10)
+		Expect(err).NotTo(HaveOccurred())
+
+		var models []InsertTest
+		Expect(cur.Next(&models)).To(BeFalse())
+		Expect(cur.Err()).To(MatchError("pg: Cursor.Next(*[]orm.InsertTest), wanted a pointer to []orm.SelectModel"))
+		Expect(db.queries[len(db.queries)-1]).To(HavePrefix("CLOSE "))
+	})
+
+	It("returns an error for invalid batch size", func() {
+		_, err := NewQuery(db, (*SelectModel)(nil)).Cursor(context.Background(), 0)
+		Expect(err).To(MatchError("pg: Cursor got batchSize=0, wanted > 0"))
+	})
+
+	It("returns an error for relations", func() {
+		_, err := NewQuery(db, (*SelectModel)(nil)).Relation("HasOne").Cursor(context.Background(), 10)
+		Expect(err).To(MatchError("pg: Cursor does not support relations"))
+	})
+})
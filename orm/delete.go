@@ -144,7 +144,7 @@ func appendColumnAndSliceValue(
 			if isPlaceholder {
 				b = append(b, '?')
 			} else {
-				b = f.AppendValue(b, el, 1)
+				b = appendFieldValue(fmter, b, f, el)
 			}
 		}
 		if len(fields) > 1 {
@@ -34,8 +34,9 @@ type Field struct {
 
 	flags uint8
 
-	append types.AppenderFunc
-	scan   types.ScannerFunc
+	append     types.AppenderFunc
+	scan       types.ScannerFunc
+	scanBinary types.BinaryScannerFunc
 
 	isZero zerochecker.Func
 }
@@ -125,6 +126,29 @@ func (f *Field) ScanValue(strct reflect.Value, rd types.Reader, n int) error {
 	return f.scan(fv, rd, n)
 }
 
+func (f *Field) ScanBinaryValue(strct reflect.Value, col types.ColumnInfo, rd types.Reader, n int) error {
+	if f.scanBinary == nil {
+		rd, n, err := types.ReadBinaryAsText(col, rd, n)
+		if err != nil {
+			return err
+		}
+		return f.ScanValue(strct, rd, n)
+	}
+
+	var fv reflect.Value
+	if n == -1 {
+		var ok bool
+		fv, ok = fieldByIndex(strct, f.Index)
+		if !ok {
+			return nil
+		}
+	} else {
+		fv = fieldByIndexAlloc(strct, f.Index)
+	}
+
+	return f.scanBinary(fv, col, rd, n)
+}
+
 type Method struct {
 	Index int
 
@@ -3,6 +3,7 @@ package orm
 import (
 	"bytes"
 	"fmt"
+	"reflect"
 	"sort"
 	"strconv"
 	"strings"
@@ -141,6 +142,7 @@ type QueryFormatter interface {
 type Formatter struct {
 	namedParams map[string]interface{}
 	model       TableModel
+	bindParams  *[]interface{}
 }
 
 var _ QueryFormatter = (*Formatter)(nil)
@@ -174,6 +176,7 @@ func (f *Formatter) clone() *Formatter {
 	cp := NewFormatter()
 
 	cp.model = f.model
+	cp.bindParams = f.bindParams
 	if len(f.namedParams) > 0 {
 		cp.namedParams = make(map[string]interface{}, len(f.namedParams))
 	}
@@ -216,6 +219,21 @@ func (f *Formatter) WithParam(param string, value interface{}) *Formatter {
 	return cp
 }
 
+// WithBindParams returns a copy of the formatter that replaces query values
+// with $1, $2, ... placeholders and appends the values to params instead of
+// interpolating them into the query.
+func (f *Formatter) WithBindParams(params *[]interface{}) *Formatter {
+	cp := f.clone()
+	cp.bindParams = params
+	return cp
+}
+
+func (f *Formatter) bindParam(b []byte, param interface{}) []byte {
+	*f.bindParams = append(*f.bindParams, param)
+	b = append(b, '$')
+	return strconv.AppendInt(b, int64(len(*f.bindParams)), 10)
+}
+
 func (f *Formatter) Param(param string) interface{} {
 	return f.namedParams[param]
 }
@@ -328,6 +346,54 @@ func (f *Formatter) appendParam(b []byte, param interface{}) []byte {
 		}
 		return bb
 	default:
+		if f.bindParams != nil && canBindParam(param) {
+			return f.bindParam(b, param)
+		}
 		return types.Append(b, param, 1)
 	}
 }
+
+// canBindParam reports whether the param is a value that can be sent
+// separately from the query. Other value appenders, e.g. types.Safe,
+// types.Ident or types.In, produce SQL and are always interpolated.
+func canBindParam(param interface{}) bool {
+	switch param.(type) {
+	case *types.Array, *types.Hstore, types.NullTime, types.RawValue:
+		return true
+	case types.ValueAppender:
+		return false
+	default:
+		return true
+	}
+}
+
+func isBindFormatter(fmter QueryFormatter) bool {
+	f, ok := fmter.(*Formatter)
+	return ok && f.bindParams != nil
+}
+
+// appendFieldValue appends the field value or, when the formatter binds
+// params, a placeholder for the value.
+func appendFieldValue(fmter QueryFormatter, b []byte, f *Field, strct reflect.Value) []byte {
+	if isBindFormatter(fmter) {
+		return fmter.(*Formatter).bindParam(b, bindValue(f.AppendValue(make([]byte, 0), strct, 0)))
+	}
+	return f.AppendValue(b, strct, 1)
+}
+
+// appendMethodValue is like appendFieldValue, but for methods.
+func appendMethodValue(fmter QueryFormatter, b []byte, m *Method, strct reflect.Value) []byte {
+	if isBindFormatter(fmter) {
+		return fmter.(*Formatter).bindParam(b, bindValue(m.AppendValue(make([]byte, 0), strct, 0)))
+	}
+	return m.AppendValue(b, strct, 1)
+}
+
+// bindValue converts a value appended without quoting to a bind param.
+// Nil means the value is NULL.
+func bindValue(b []byte) interface{} {
+	if b == nil {
+		return nil
+	}
+	return types.Safe(internal.BytesToString(b))
+}
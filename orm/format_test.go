@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"reflect"
 	"testing"
 
 	"github.com/go-pg/pg/v10/orm"
@@ -165,6 +166,51 @@ func TestFormatQuery(t *testing.T) {
 	}
 }
 
+type bindParamsTest struct {
+	q            string
+	params       params
+	wanted       string
+	wantedParams params
+}
+
+var bindParamsTests = []bindParamsTest{
+	{q: "?", wanted: "?"},
+	{q: "? ?", params: params{"foo", 1}, wanted: "$1 $2", wantedParams: params{"foo", 1}},
+	{q: "?0 ?0", params: params{"foo"}, wanted: "$1 $2", wantedParams: params{"foo", "foo"}},
+	{q: "?", params: params{nil}, wanted: "$1", wantedParams: params{nil}},
+	{q: "?", params: params{types.Safe("query")}, wanted: "query"},
+	{q: "?", params: params{types.Ident("field")}, wanted: `"field"`},
+	{q: "?", params: params{types.In([]int{1, 2})}, wanted: "1,2"},
+	{q: "?", params: params{orm.SafeQuery("x = ?", 1)}, wanted: "x = $1", wantedParams: params{1}},
+	{
+		q:            "?string ?use_zero ?Method",
+		params:       params{&StructFormatter{String: "foo"}},
+		wanted:       "$1 $2 $3",
+		wantedParams: params{types.Safe("foo"), types.Safe(""), types.Safe("method_value")},
+	},
+	{
+		q:            "?string",
+		params:       params{&StructFormatter{}},
+		wanted:       "$1",
+		wantedParams: params{nil},
+	},
+}
+
+func TestFormatQueryBindParams(t *testing.T) {
+	for i, test := range bindParamsTests {
+		var got params
+		f := orm.NewFormatter().WithBindParams((*[]interface{})(&got))
+
+		q := f.FormatQuery(nil, test.q, test.params...)
+		if string(q) != test.wanted {
+			t.Fatalf("#%d: got %q, wanted %q", i, q, test.wanted)
+		}
+		if !reflect.DeepEqual(got, test.wantedParams) {
+			t.Fatalf("#%d: got params %#v, wanted %#v", i, got, test.wantedParams)
+		}
+	}
+}
+
 func BenchmarkFormatQueryWithoutParams(b *testing.B) {
 	var f orm.Formatter
 	for i := 0; i < b.N; i++ {
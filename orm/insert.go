@@ -251,7 +251,7 @@ func (q *InsertQuery) appendValues(
 			b = append(b, "DEFAULT"...)
 			q.addReturningField(f)
 		default:
-			b = f.AppendValue(b, strct, 1)
+			b = appendFieldValue(fmter, b, f, strct)
 		}
 	}
 
@@ -54,6 +54,27 @@ var _ = Describe("Insert", func() {
 		Expect(s).To(Equal(`INSERT INTO "insert_tests" ("id") VALUES (1)`))
 	})
 
+	It("binds params", func() {
+		model := &InsertTest{
+			Id:    1,
+			Value: "hello",
+		}
+		q := NewQuery(nil, model).Value("value", "upper(?)", model.Value)
+
+		s, params := insertQueryBindParams(q)
+		Expect(s).To(Equal(`INSERT INTO "insert_tests" ("id", "value") VALUES ($1, upper($2)) RETURNING "value"`))
+		Expect(params).To(Equal([]interface{}{types.Safe("1"), "hello"}))
+	})
+
+	It("binds params for slices", func() {
+		models := []InsertTest{{Id: 1, Value: "foo"}, {Id: 2}}
+		q := NewQuery(nil, &models)
+
+		s, params := insertQueryBindParams(q)
+		Expect(s).To(Equal(`INSERT INTO "insert_tests" ("id", "value") VALUES ($1, $2), ($3, DEFAULT) RETURNING "value"`))
+		Expect(params).To(Equal([]interface{}{types.Safe("1"), types.Safe("foo"), types.Safe("2")}))
+	})
+
 	It("supports Value", func() {
 		model := &InsertTest{
 			Id:    1,
@@ -292,6 +313,14 @@ var _ = Describe("Insert", func() {
 	})
 })
 
+func insertQueryBindParams(q *Query) (string, []interface{}) {
+	var params []interface{}
+	ins := NewInsertQuery(q)
+	b, err := ins.AppendQuery(NewFormatter().WithBindParams(&params), nil)
+	Expect(err).NotTo(HaveOccurred())
+	return string(b), params
+}
+
 func insertQueryString(q *Query) string {
 	ins := NewInsertQuery(q)
 	return queryString(ins)
@@ -25,3 +25,7 @@ func (m Discard) AddColumnScanner(ColumnScanner) error {
 func (m Discard) ScanColumn(col types.ColumnInfo, rd types.Reader, n int) error {
 	return nil
 }
+
+func (m Discard) ScanBinaryColumn(col types.ColumnInfo, rd types.Reader, n int) error {
+	return nil
+}
@@ -48,6 +48,16 @@ func (m *mapModel) ScanColumn(col types.ColumnInfo, rd types.Reader, n int) erro
 	return nil
 }
 
+func (m *mapModel) ScanBinaryColumn(col types.ColumnInfo, rd types.Reader, n int) error {
+	val, err := types.ReadBinaryColumnValue(col, rd, n)
+	if err != nil {
+		return err
+	}
+
+	m.m[col.Name] = val
+	return nil
+}
+
 func (mapModel) useQueryOne() bool {
 	return true
 }
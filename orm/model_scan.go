@@ -37,6 +37,14 @@ func (m scanValuesModel) ScanColumn(col types.ColumnInfo, rd types.Reader, n int
 	return types.Scan(m.values[col.Index], rd, n)
 }
 
+func (m scanValuesModel) ScanBinaryColumn(col types.ColumnInfo, rd types.Reader, n int) error {
+	if int(col.Index) >= len(m.values) {
+		return fmt.Errorf("pg: no Scan var for column index=%d name=%q",
+			col.Index, col.Name)
+	}
+	return types.ScanBinary(m.values[col.Index], col, rd, n)
+}
+
 //------------------------------------------------------------------------------
 
 type scanReflectValuesModel struct {
@@ -67,3 +75,11 @@ func (m scanReflectValuesModel) ScanColumn(col types.ColumnInfo, rd types.Reader
 	}
 	return types.ScanValue(m.values[col.Index], rd, n)
 }
+
+func (m scanReflectValuesModel) ScanBinaryColumn(col types.ColumnInfo, rd types.Reader, n int) error {
+	if int(col.Index) >= len(m.values) {
+		return fmt.Errorf("pg: no Scan var for column index=%d name=%q",
+			col.Index, col.Name)
+	}
+	return types.ScanBinaryValue(m.values[col.Index], col, rd, n)
+}
@@ -9,17 +9,19 @@ import (
 
 type sliceModel struct {
 	Discard
-	slice    reflect.Value
-	nextElem func() reflect.Value
-	scan     func(reflect.Value, types.Reader, int) error
+	slice      reflect.Value
+	nextElem   func() reflect.Value
+	scan       func(reflect.Value, types.Reader, int) error
+	scanBinary types.BinaryScannerFunc
 }
 
 var _ Model = (*sliceModel)(nil)
 
 func newSliceModel(slice reflect.Value, elemType reflect.Type) *sliceModel {
 	return &sliceModel{
-		slice: slice,
-		scan:  types.Scanner(elemType),
+		slice:      slice,
+		scan:       types.Scanner(elemType),
+		scanBinary: types.BinaryScanner(elemType),
 	}
 }
 
@@ -41,3 +43,11 @@ func (m *sliceModel) ScanColumn(col types.ColumnInfo, rd types.Reader, n int) er
 	v := m.nextElem()
 	return m.scan(v, rd, n)
 }
+
+func (m *sliceModel) ScanBinaryColumn(col types.ColumnInfo, rd types.Reader, n int) error {
+	if m.nextElem == nil {
+		m.nextElem = internal.MakeSliceNextElemFunc(m.slice)
+	}
+	v := m.nextElem()
+	return m.scanBinary(v, col, rd, n)
+}
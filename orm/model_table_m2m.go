@@ -91,6 +91,15 @@ func (m *m2mModel) modelIDMap(b []byte) ([]byte, error) {
 	return b, nil
 }
 
+func (m *m2mModel) ScanBinaryColumn(col types.ColumnInfo, rd types.Reader, n int) error {
+	rd, n, err := types.ReadBinaryAsText(col, rd, n)
+	if err != nil {
+		return err
+	}
+	col.Format = types.TextFormat
+	return m.ScanColumn(col, rd, n)
+}
+
 func (m *m2mModel) ScanColumn(col types.ColumnInfo, rd types.Reader, n int) error {
 	if n > 0 {
 		b, err := rd.ReadFullTemp()
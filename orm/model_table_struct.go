@@ -59,7 +59,7 @@ func (m *structTableModel) Relation() *Relation {
 }
 
 func (m *structTableModel) AppendParam(fmter QueryFormatter, b []byte, name string) ([]byte, bool) {
-	b, ok := m.table.AppendParam(b, m.strct, name)
+	b, ok := m.table.appendParam(fmter, b, m.strct, name)
 	if ok {
 		return b, true
 	}
@@ -263,6 +263,12 @@ func (m *structTableModel) ScanColumn(
 	)
 }
 
+func (m *structTableModel) ScanBinaryColumn(
+	col types.ColumnInfo, rd types.Reader, n int,
+) error {
+	return m.ScanColumn(col, rd, n)
+}
+
 func (m *structTableModel) scanColumn(col types.ColumnInfo, rd types.Reader, n int) (bool, error) {
 	// Don't init nil struct if value is NULL.
 	if n == -1 &&
@@ -295,6 +301,9 @@ func (m *structTableModel) scanColumn(col types.ColumnInfo, rd types.Reader, n i
 		return false, nil
 	}
 
+	if col.Format == types.BinaryFormat {
+		return true, field.ScanBinaryValue(m.strct, col, rd, n)
+	}
 	return true, field.ScanValue(m.strct, rd, n)
 }
 
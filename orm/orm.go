@@ -19,6 +19,13 @@ type ColumnScanner interface {
 	ScanColumn(col types.ColumnInfo, rd types.Reader, n int) error
 }
 
+// BinaryColumnScanner is implemented by column scanners that can scan
+// column values sent in the binary format. Binary values are converted
+// to the text format before they are passed to other column scanners.
+type BinaryColumnScanner interface {
+	ScanBinaryColumn(col types.ColumnInfo, rd types.Reader, n int) error
+}
+
 type QueryAppender interface {
 	AppendQuery(fmter QueryFormatter, b []byte) ([]byte, error)
 }
@@ -34,6 +34,9 @@ const (
 	implicitModelFlag queryFlag = 1 << iota
 	deletedFlag
 	allWithDeletedFlag
+	bindParamsFlag
+	usePrimaryFlag
+	copyBinaryFlag
 )
 
 type withQuery struct {
@@ -250,6 +253,36 @@ func (q *Query) Deleted() *Query {
 	return q.withFlag(deletedFlag).withoutFlag(allWithDeletedFlag)
 }
 
+// BindParams sends query values to the server separately from the query
+// using $1, $2, ... placeholders instead of interpolating them into the query.
+func (q *Query) BindParams() *Query {
+	return q.withFlag(bindParamsFlag)
+}
+
+// BindsParams reports whether query values are sent separately from the query.
+func (q *Query) BindsParams() bool {
+	return q.hasFlag(bindParamsFlag)
+}
+
+// UsePrimary makes ClusterDB send the query to the primary even if it
+// only reads data.
+func (q *Query) UsePrimary() *Query {
+	return q.withFlag(usePrimaryFlag)
+}
+
+// UsesPrimary reports whether the query must be sent to the primary,
+// i.e. UsePrimary was called or the query locks rows with For.
+func (q *Query) UsesPrimary() bool {
+	return q.hasFlag(usePrimaryFlag) || q.selFor != nil
+}
+
+// CopyBinary makes CopyFromModel and CopyToModel use the COPY binary format,
+// which is more compact than the text format for bytea and timestamps.
+// Column data types are looked up in pg_attribute before copying.
+func (q *Query) CopyBinary() *Query {
+	return q.withFlag(copyBinaryFlag)
+}
+
 // AllWithDeleted changes query to return all rows including soft deleted ones.
 func (q *Query) AllWithDeleted() *Query {
 	if q.tableModel != nil {
@@ -1038,7 +1071,7 @@ func (q *Query) Insert(values ...interface{}) (Result, error) {
 	query := NewInsertQuery(q)
 	res, err := q.returningQuery(ctx, model, query)
 	if err != nil {
-		return nil, err
+		return nil, q.constraintError(err)
 	}
 
 	if q.tableModel != nil {
@@ -1089,7 +1122,8 @@ func (q *Query) SelectOrInsert(values ...interface{}) (inserted bool, _ error) {
 			if err == internal.ErrNoRows {
 				continue
 			}
-			if pgErr, ok := err.(internal.PGError); ok {
+			var pgErr internal.PGError
+			if errors.As(err, &pgErr) {
 				if pgErr.IntegrityViolation() {
 					continue
 				}
@@ -1150,7 +1184,7 @@ func (q *Query) update(values []interface{}, omitZero bool) (Result, error) {
 	query := NewUpdateQuery(q, omitZero)
 	res, err := q.returningQuery(c, model, query)
 	if err != nil {
-		return nil, err
+		return nil, q.constraintError(err)
 	}
 
 	if q.tableModel != nil {
@@ -1592,7 +1626,7 @@ func appendColumnAndValue(
 		if isPlaceholder {
 			b = append(b, '?')
 		} else {
-			b = f.AppendValue(b, v, 1)
+			b = appendFieldValue(fmter, b, f, v)
 		}
 	}
 	return b
@@ -1654,9 +1688,10 @@ func (q joinPKSliceQuery) AppendQuery(fmter QueryFormatter, b []byte) ([]byte, e
 				b = append(b, ", "...)
 			}
 
-			b = f.AppendValue(b, el, 1)
+			b = appendFieldValue(fmter, b, f, el)
 
-			if f.UserSQLType != "" {
+			// Bound params in VALUES lists are text unless they are cast.
+			if f.UserSQLType != "" || isBindFormatter(fmter) {
 				b = append(b, "::"...)
 				b = append(b, f.SQLType...)
 			}
@@ -232,15 +232,21 @@ func (t *Table) GetField(name string) (*Field, error) {
 }
 
 func (t *Table) AppendParam(b []byte, strct reflect.Value, name string) ([]byte, bool) {
+	return t.appendParam(defaultFmter, b, strct, name)
+}
+
+func (t *Table) appendParam(
+	fmter QueryFormatter, b []byte, strct reflect.Value, name string,
+) ([]byte, bool) {
 	field, ok := t.FieldsMap[name]
 	if ok {
-		b = field.AppendValue(b, strct, 1)
+		b = appendFieldValue(fmter, b, field, strct)
 		return b, true
 	}
 
 	method, ok := t.Methods[name]
 	if ok {
-		b = method.AppendValue(b, strct.Addr(), 1)
+		b = appendMethodValue(fmter, b, method, strct.Addr())
 		return b, true
 	}
 
@@ -487,6 +493,7 @@ func (t *Table) newField(f reflect.StructField, index []int) *Field {
 	} else {
 		field.append = types.Appender(f.Type)
 		field.scan = types.Scanner(f.Type)
+		field.scanBinary = types.BinaryScanner(f.Type)
 	}
 	field.isZero = zerochecker.Checker(f.Type)
 
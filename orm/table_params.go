@@ -25,5 +25,5 @@ func newTableParams(strct interface{}) (*tableParams, bool) {
 }
 
 func (m *tableParams) AppendParam(fmter QueryFormatter, b []byte, name string) ([]byte, bool) {
-	return m.table.AppendParam(b, m.strct, name)
+	return m.table.appendParam(fmter, b, m.strct, name)
 }
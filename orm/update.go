@@ -227,7 +227,7 @@ func (q *UpdateQuery) appendSetStruct(fmter QueryFormatter, b []byte, strct refl
 				return nil, err
 			}
 		} else {
-			b = f.AppendValue(b, strct, 1)
+			b = appendFieldValue(fmter, b, f, strct)
 		}
 	}
 
@@ -354,7 +354,7 @@ func (q *UpdateQuery) appendValues(
 		if q.placeholder {
 			b = append(b, '?')
 		} else {
-			b = f.AppendValue(b, indirect(strct), 1)
+			b = appendFieldValue(fmter, b, f, indirect(strct))
 		}
 
 		b = append(b, "::"...)
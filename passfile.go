@@ -0,0 +1,151 @@
+package pg
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/go-pg/pg/v10/internal"
+)
+
+// hostPassword returns the password used to connect to the host
+// with the index. When no password is provided, it is looked up
+// in the password file for every host, so failover uses the password
+// of the host it connects to.
+func (opt *Options) hostPassword(index int) string {
+	if !opt.usePassFile {
+		return opt.Password
+	}
+	addrs := opt.addrs()
+	if index >= len(addrs) {
+		return opt.Password
+	}
+	if password := opt.passFilePassword(opt.passFileHosts(addrs[index])); password != "" {
+		return password
+	}
+	return opt.Password
+}
+
+// passFilePassword looks up the password for the hosts in the password
+// file using the same rules as libpq. See
+// https://www.postgresql.org/docs/current/libpq-pgpass.html.
+func (opt *Options) passFilePassword(hosts [][2]string) string {
+	file := opt.passFile
+	if file == "" {
+		file = os.Getenv("PGPASSFILE")
+	}
+	if file == "" {
+		file = userConfigFile("pgpass.conf", ".pgpass")
+		if file == "" {
+			return ""
+		}
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil || !fi.Mode().IsRegular() {
+		return ""
+	}
+	if runtime.GOOS != "windows" && fi.Mode().Perm()&0o077 != 0 {
+		internal.Warn.Printf(
+			"password file %q has group or world access; permissions should be u=rw (0600) or less",
+			file)
+		return ""
+	}
+
+	var entries [][]string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if fields := splitPassFileLine(line); len(fields) == 5 {
+			entries = append(entries, fields)
+		}
+	}
+
+	for _, h := range hosts {
+		for _, fields := range entries {
+			if passFileMatch(fields[0], h[0]) && passFileMatch(fields[1], h[1]) &&
+				passFileMatch(fields[2], opt.Database) && passFileMatch(fields[3], opt.User) {
+				return fields[4]
+			}
+		}
+	}
+	return ""
+}
+
+// passFileHosts returns host and port pairs of the address that are
+// matched against the password file entries. Unix-domain sockets
+// in the default directory also match localhost.
+func (opt *Options) passFileHosts(addr string) [][2]string {
+	if opt.Network == "unix" {
+		dir, file := path.Split(addr)
+		dir = path.Clean(dir)
+		port := strings.TrimPrefix(file, ".s.PGSQL.")
+		hosts := [][2]string{{dir, port}}
+		if dir == "/var/run/postgresql" || dir == "/tmp" {
+			hosts = append(hosts, [2]string{"localhost", port})
+		}
+		return hosts
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil
+	}
+	return [][2]string{{host, port}}
+}
+
+// splitPassFileLine splits the line into colon-separated fields
+// unescaping \: and \\.
+func splitPassFileLine(line string) []string {
+	var fields []string
+	var field []byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && i+1 < len(line):
+			i++
+			field = append(field, line[i])
+		case c == ':':
+			fields = append(fields, string(field))
+			field = field[:0]
+		default:
+			field = append(field, c)
+		}
+	}
+	return append(fields, string(field))
+}
+
+func passFileMatch(pattern, value string) bool {
+	return pattern == "*" || pattern == value
+}
+
+// userConfigFile returns the path of the libpq configuration file
+// in the user's home directory or, on Windows, in %APPDATA%\postgresql.
+func userConfigFile(windowsName, name string) string {
+	if runtime.GOOS == "windows" {
+		appData := os.Getenv("APPDATA")
+		if appData == "" {
+			return ""
+		}
+		return filepath.Join(appData, "postgresql", windowsName)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, name)
+}
@@ -0,0 +1,162 @@
+package pg
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writePassFile(t *testing.T, content string, perm os.FileMode) string {
+	file := filepath.Join(t.TempDir(), "pgpass")
+	if err := os.WriteFile(file, []byte(content), perm); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestOptions_passFilePassword(t *testing.T) {
+	t.Setenv("PGPASSWORD", "")
+	t.Setenv("PGPASSFILE", writePassFile(t, `# comment
+db1.example.com:5432:mydb:vasya:secret1
+*:5433:*:vasya:secret2
+localhost:5432:*:*:pass\:with\\colon
+/sockets:5432:*:*:socket
+`, 0o600))
+
+	cases := []struct {
+		opt      *Options
+		password string
+	}{
+		{&Options{Addr: "db1.example.com:5432", User: "vasya", Database: "mydb"}, "secret1"},
+		{&Options{Addr: "db1.example.com:5432", User: "vasya", Database: "other"}, "postgres"},
+		{&Options{Addr: "db2.example.com:5433", User: "vasya", Database: "other"}, "secret2"},
+		{&Options{Addr: "localhost:5432", User: "petya"}, `pass:with\colon`},
+		{&Options{Network: "unix", Addr: "/sockets/.s.PGSQL.5432", User: "petya"}, "socket"},
+		{&Options{Network: "unix", Addr: "/var/run/postgresql/.s.PGSQL.5432", User: "petya"}, `pass:with\colon`},
+		{&Options{Addr: "localhost:5432", User: "petya", Password: "explicit"}, "explicit"},
+	}
+	for _, c := range cases {
+		c.opt.init()
+		assert.Equal(t, c.password, c.opt.hostPassword(0), c.opt.Addr)
+	}
+
+	opt := &Options{Addr: "db3.example.com:5432,db2.example.com:5433,db1.example.com:5432", User: "vasya"}
+	opt.init()
+	assert.Equal(t, "postgres", opt.hostPassword(0))
+	assert.Equal(t, "secret2", opt.hostPassword(1))
+	assert.Equal(t, "postgres", opt.hostPassword(2))
+}
+
+func TestOptions_passFilePasswordPermissions(t *testing.T) {
+	t.Setenv("PGPASSWORD", "")
+	t.Setenv("PGPASSFILE", writePassFile(t, "*:*:*:*:secret\n", 0o644))
+
+	opt := &Options{Addr: "localhost:5432"}
+	opt.init()
+	assert.Equal(t, "postgres", opt.hostPassword(0))
+}
+
+func TestParseURLPassFile(t *testing.T) {
+	t.Setenv("PGPASSWORD", "")
+	file := writePassFile(t, "*:*:*:*:secret\n", 0o600)
+
+	opt, err := ParseURL("host=localhost dbname=mydb passfile=" + file)
+	assert.NoError(t, err)
+
+	opt.init()
+	assert.Equal(t, "secret", opt.hostPassword(0))
+}
+
+func TestConnectPassFilePerHost(t *testing.T) {
+	ln1, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln1.Close()
+	ln2, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln2.Close()
+
+	_, port1, _ := net.SplitHostPort(ln1.Addr().String())
+	_, port2, _ := net.SplitHostPort(ln2.Addr().String())
+	t.Setenv("PGPASSWORD", "")
+	t.Setenv("PGPASSFILE", writePassFile(t, fmt.Sprintf(
+		"127.0.0.1:%s:*:*:secret1\n127.0.0.1:%s:*:*:secret2\n", port1, port2), 0o600))
+
+	passwords := make(chan string, 2)
+	go servePasswordAuth(ln1, passwords, false)
+	go servePasswordAuth(ln2, passwords, true)
+
+	db := Connect(&Options{
+		Addr:    ln1.Addr().String() + "," + ln2.Addr().String(),
+		SSLMode: "disable",
+	})
+	defer db.Close()
+
+	cn, err := db.getConn(context.Background())
+	assert.NoError(t, err)
+	db.releaseConn(context.Background(), cn, nil)
+
+	assert.Equal(t, "secret1", <-passwords)
+	assert.Equal(t, "secret2", <-passwords)
+}
+
+// servePasswordAuth requests a cleartext password and sends it
+// to the channel. The password is accepted when ok is true.
+func servePasswordAuth(ln net.Listener, passwords chan<- string, ok bool) {
+	for {
+		cn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go func(cn net.Conn) {
+			defer cn.Close()
+
+			var hdr [4]byte
+			if _, err := io.ReadFull(cn, hdr[:]); err != nil {
+				return
+			}
+			size := binary.BigEndian.Uint32(hdr[:])
+			if _, err := io.CopyN(io.Discard, cn, int64(size-4)); err != nil {
+				return
+			}
+
+			// AuthenticationCleartextPassword.
+			if _, err := cn.Write([]byte{'R', 0, 0, 0, 8, 0, 0, 0, 3}); err != nil {
+				return
+			}
+
+			var msg [5]byte
+			if _, err := io.ReadFull(cn, msg[:]); err != nil {
+				return
+			}
+			b := make([]byte, binary.BigEndian.Uint32(msg[1:])-4)
+			if _, err := io.ReadFull(cn, b); err != nil {
+				return
+			}
+			passwords <- string(bytes.TrimRight(b, "\x00"))
+
+			if !ok {
+				fields := "SFATAL\x00C28P01\x00Mpassword authentication failed\x00\x00"
+				e := []byte{'E', 0, 0, 0, 0}
+				binary.BigEndian.PutUint32(e[1:], uint32(4+len(fields)))
+				_, _ = cn.Write(append(e, fields...))
+				return
+			}
+
+			// AuthenticationOk and ReadyForQuery.
+			_, _ = cn.Write([]byte{'R', 0, 0, 0, 8, 0, 0, 0, 0, 'Z', 0, 0, 0, 5, 'I'})
+			_, _ = io.Copy(io.Discard, cn)
+		}(cn)
+	}
+}
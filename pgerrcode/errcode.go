@@ -0,0 +1,587 @@
+// Package pgerrcode contains constants for PostgreSQL error codes
+// (SQLSTATE) and functions that report the class of an error code.
+//
+// The constants are maintained by hand. They match
+// src/backend/utils/errcodes.txt of PostgreSQL 13 and must be updated
+// when a new PostgreSQL release adds error codes.
+//
+// https://www.postgresql.org/docs/current/errcodes-appendix.html
+package pgerrcode
+
+// Error codes grouped by class.
+const (
+	// Class 00 - Successful Completion
+	SuccessfulCompletion = "00000"
+
+	// Class 01 - Warning
+	Warning                          = "01000"
+	DynamicResultSetsReturned        = "0100C"
+	ImplicitZeroBitPadding           = "01008"
+	NullValueEliminatedInSetFunction = "01003"
+	PrivilegeNotGranted              = "01007"
+	PrivilegeNotRevoked              = "01006"
+	StringDataRightTruncationWarning = "01004"
+	DeprecatedFeature                = "01P01"
+
+	// Class 02 - No Data (this is also a warning class per the SQL standard)
+	NoData                                = "02000"
+	NoAdditionalDynamicResultSetsReturned = "02001"
+
+	// Class 03 - SQL Statement Not Yet Complete
+	SQLStatementNotYetComplete = "03000"
+
+	// Class 08 - Connection Exception
+	ConnectionException                           = "08000"
+	ConnectionDoesNotExist                        = "08003"
+	ConnectionFailure                             = "08006"
+	SQLClientUnableToEstablishSQLConnection       = "08001"
+	SQLServerRejectedEstablishmentOfSQLConnection = "08004"
+	TransactionResolutionUnknown                  = "08007"
+	ProtocolViolation                             = "08P01"
+
+	// Class 09 - Triggered Action Exception
+	TriggeredActionException = "09000"
+
+	// Class 0A - Feature Not Supported
+	FeatureNotSupported = "0A000"
+
+	// Class 0B - Invalid Transaction Initiation
+	InvalidTransactionInitiation = "0B000"
+
+	// Class 0F - Locator Exception
+	LocatorException            = "0F000"
+	InvalidLocatorSpecification = "0F001"
+
+	// Class 0L - Invalid Grantor
+	InvalidGrantor        = "0L000"
+	InvalidGrantOperation = "0LP01"
+
+	// Class 0P - Invalid Role Specification
+	InvalidRoleSpecification = "0P000"
+
+	// Class 0Z - Diagnostics Exception
+	DiagnosticsException                           = "0Z000"
+	StackedDiagnosticsAccessedWithoutActiveHandler = "0Z002"
+
+	// Class 10 - XQuery Error
+	InvalidArgumentForXQuery = "10608"
+
+	// Class 20 - Case Not Found
+	CaseNotFound = "20000"
+
+	// Class 21 - Cardinality Violation
+	CardinalityViolation = "21000"
+
+	// Class 22 - Data Exception
+	DataException                             = "22000"
+	ArraySubscriptError                       = "2202E"
+	CharacterNotInRepertoire                  = "22021"
+	DatetimeFieldOverflow                     = "22008"
+	DivisionByZero                            = "22012"
+	ErrorInAssignment                         = "22005"
+	EscapeCharacterConflict                   = "2200B"
+	IndicatorOverflow                         = "22022"
+	IntervalFieldOverflow                     = "22015"
+	InvalidArgumentForLogarithm               = "2201E"
+	InvalidArgumentForNtileFunction           = "22014"
+	InvalidArgumentForNthValueFunction        = "22016"
+	InvalidArgumentForPowerFunction           = "2201F"
+	InvalidArgumentForWidthBucketFunction     = "2201G"
+	InvalidCharacterValueForCast              = "22018"
+	InvalidDatetimeFormat                     = "22007"
+	InvalidEscapeCharacter                    = "22019"
+	InvalidEscapeOctet                        = "2200D"
+	InvalidEscapeSequence                     = "22025"
+	NonstandardUseOfEscapeCharacter           = "22P06"
+	InvalidIndicatorParameterValue            = "22010"
+	InvalidParameterValue                     = "22023"
+	InvalidPrecedingOrFollowingSize           = "22013"
+	InvalidRegularExpression                  = "2201B"
+	InvalidRowCountInLimitClause              = "2201W"
+	InvalidRowCountInResultOffsetClause       = "2201X"
+	InvalidTablesampleArgument                = "2202H"
+	InvalidTablesampleRepeat                  = "2202G"
+	InvalidTimeZoneDisplacementValue          = "22009"
+	InvalidUseOfEscapeCharacter               = "2200C"
+	MostSpecificTypeMismatch                  = "2200G"
+	NullValueNotAllowedDataException          = "22004"
+	NullValueNoIndicatorParameter             = "22002"
+	NumericValueOutOfRange                    = "22003"
+	SequenceGeneratorLimitExceeded            = "2200H"
+	StringDataLengthMismatch                  = "22026"
+	StringDataRightTruncation                 = "22001"
+	SubstringError                            = "22011"
+	TrimError                                 = "22027"
+	UnterminatedCString                       = "22024"
+	ZeroLengthCharacterString                 = "2200F"
+	FloatingPointException                    = "22P01"
+	InvalidTextRepresentation                 = "22P02"
+	InvalidBinaryRepresentation               = "22P03"
+	BadCopyFileFormat                         = "22P04"
+	UntranslatableCharacter                   = "22P05"
+	NotAnXMLDocument                          = "2200L"
+	InvalidXMLDocument                        = "2200M"
+	InvalidXMLContent                         = "2200N"
+	InvalidXMLComment                         = "2200S"
+	InvalidXMLProcessingInstruction           = "2200T"
+	DuplicateJSONObjectKeyValue               = "22030"
+	InvalidArgumentForSQLJSONDatetimeFunction = "22031"
+	InvalidJSONText                           = "22032"
+	InvalidSQLJSONSubscript                   = "22033"
+	MoreThanOneSQLJSONItem                    = "22034"
+	NoSQLJSONItem                             = "22035"
+	NonNumericSQLJSONItem                     = "22036"
+	NonUniqueKeysInAJSONObject                = "22037"
+	SingletonSQLJSONItemRequired              = "22038"
+	SQLJSONArrayNotFound                      = "22039"
+	SQLJSONMemberNotFound                     = "2203A"
+	SQLJSONNumberNotFound                     = "2203B"
+	SQLJSONObjectNotFound                     = "2203C"
+	TooManyJSONArrayElements                  = "2203D"
+	TooManyJSONObjectMembers                  = "2203E"
+	SQLJSONScalarRequired                     = "2203F"
+
+	// Class 23 - Integrity Constraint Violation
+	IntegrityConstraintViolation = "23000"
+	RestrictViolation            = "23001"
+	NotNullViolation             = "23502"
+	ForeignKeyViolation          = "23503"
+	UniqueViolation              = "23505"
+	CheckViolation               = "23514"
+	ExclusionViolation           = "23P01"
+
+	// Class 24 - Invalid Cursor State
+	InvalidCursorState = "24000"
+
+	// Class 25 - Invalid Transaction State
+	InvalidTransactionState                         = "25000"
+	ActiveSQLTransaction                            = "25001"
+	BranchTransactionAlreadyActive                  = "25002"
+	HeldCursorRequiresSameIsolationLevel            = "25008"
+	InappropriateAccessModeForBranchTransaction     = "25003"
+	InappropriateIsolationLevelForBranchTransaction = "25004"
+	NoActiveSQLTransactionForBranchTransaction      = "25005"
+	ReadOnlySQLTransaction                          = "25006"
+	SchemaAndDataStatementMixingNotSupported        = "25007"
+	NoActiveSQLTransaction                          = "25P01"
+	InFailedSQLTransaction                          = "25P02"
+	IdleInTransactionSessionTimeout                 = "25P03"
+
+	// Class 26 - Invalid SQL Statement Name
+	InvalidSQLStatementName = "26000"
+
+	// Class 27 - Triggered Data Change Violation
+	TriggeredDataChangeViolation = "27000"
+
+	// Class 28 - Invalid Authorization Specification
+	InvalidAuthorizationSpecification = "28000"
+	InvalidPassword                   = "28P01"
+
+	// Class 2B - Dependent Privilege Descriptors Still Exist
+	DependentPrivilegeDescriptorsStillExist = "2B000"
+	DependentObjectsStillExist              = "2BP01"
+
+	// Class 2D - Invalid Transaction Termination
+	InvalidTransactionTermination = "2D000"
+
+	// Class 2F - SQL Routine Exception
+	SQLRoutineException                       = "2F000"
+	FunctionExecutedNoReturnStatement         = "2F005"
+	ModifyingSQLDataNotPermittedSQLRoutine    = "2F002"
+	ProhibitedSQLStatementAttemptedSQLRoutine = "2F003"
+	ReadingSQLDataNotPermittedSQLRoutine      = "2F004"
+
+	// Class 34 - Invalid Cursor Name
+	InvalidCursorName = "34000"
+
+	// Class 38 - External Routine Exception
+	ExternalRoutineException                       = "38000"
+	ContainingSQLNotPermitted                      = "38001"
+	ModifyingSQLDataNotPermittedExternalRoutine    = "38002"
+	ProhibitedSQLStatementAttemptedExternalRoutine = "38003"
+	ReadingSQLDataNotPermittedExternalRoutine      = "38004"
+
+	// Class 39 - External Routine Invocation Exception
+	ExternalRoutineInvocationException = "39000"
+	InvalidSQLStateReturned            = "39001"
+	NullValueNotAllowedExternalRoutine = "39004"
+	TriggerProtocolViolated            = "39P01"
+	SRFProtocolViolated                = "39P02"
+	EventTriggerProtocolViolated       = "39P03"
+
+	// Class 3B - Savepoint Exception
+	SavepointException            = "3B000"
+	InvalidSavepointSpecification = "3B001"
+
+	// Class 3D - Invalid Catalog Name
+	InvalidCatalogName = "3D000"
+
+	// Class 3F - Invalid Schema Name
+	InvalidSchemaName = "3F000"
+
+	// Class 40 - Transaction Rollback
+	TransactionRollback                     = "40000"
+	TransactionIntegrityConstraintViolation = "40002"
+	SerializationFailure                    = "40001"
+	StatementCompletionUnknown              = "40003"
+	DeadlockDetected                        = "40P01"
+
+	// Class 42 - Syntax Error or Access Rule Violation
+	SyntaxErrorOrAccessRuleViolation   = "42000"
+	SyntaxError                        = "42601"
+	InsufficientPrivilege              = "42501"
+	CannotCoerce                       = "42846"
+	GroupingError                      = "42803"
+	WindowingError                     = "42P20"
+	InvalidRecursion                   = "42P19"
+	InvalidForeignKey                  = "42830"
+	InvalidName                        = "42602"
+	NameTooLong                        = "42622"
+	ReservedName                       = "42939"
+	DatatypeMismatch                   = "42804"
+	IndeterminateDatatype              = "42P18"
+	CollationMismatch                  = "42P21"
+	IndeterminateCollation             = "42P22"
+	WrongObjectType                    = "42809"
+	GeneratedAlways                    = "428C9"
+	UndefinedColumn                    = "42703"
+	UndefinedFunction                  = "42883"
+	UndefinedTable                     = "42P01"
+	UndefinedParameter                 = "42P02"
+	UndefinedObject                    = "42704"
+	DuplicateColumn                    = "42701"
+	DuplicateCursor                    = "42P03"
+	DuplicateDatabase                  = "42P04"
+	DuplicateFunction                  = "42723"
+	DuplicatePreparedStatement         = "42P05"
+	DuplicateSchema                    = "42P06"
+	DuplicateTable                     = "42P07"
+	DuplicateAlias                     = "42712"
+	DuplicateObject                    = "42710"
+	AmbiguousColumn                    = "42702"
+	AmbiguousFunction                  = "42725"
+	AmbiguousParameter                 = "42P08"
+	AmbiguousAlias                     = "42P09"
+	InvalidColumnReference             = "42P10"
+	InvalidColumnDefinition            = "42611"
+	InvalidCursorDefinition            = "42P11"
+	InvalidDatabaseDefinition          = "42P12"
+	InvalidFunctionDefinition          = "42P13"
+	InvalidPreparedStatementDefinition = "42P14"
+	InvalidSchemaDefinition            = "42P15"
+	InvalidTableDefinition             = "42P16"
+	InvalidObjectDefinition            = "42P17"
+
+	// Class 44 - WITH CHECK OPTION Violation
+	WithCheckOptionViolation = "44000"
+
+	// Class 53 - Insufficient Resources
+	InsufficientResources      = "53000"
+	DiskFull                   = "53100"
+	OutOfMemory                = "53200"
+	TooManyConnections         = "53300"
+	ConfigurationLimitExceeded = "53400"
+
+	// Class 54 - Program Limit Exceeded
+	ProgramLimitExceeded = "54000"
+	StatementTooComplex  = "54001"
+	TooManyColumns       = "54011"
+	TooManyArguments     = "54023"
+
+	// Class 55 - Object Not In Prerequisite State
+	ObjectNotInPrerequisiteState = "55000"
+	ObjectInUse                  = "55006"
+	CantChangeRuntimeParam       = "55P02"
+	LockNotAvailable             = "55P03"
+	UnsafeNewEnumValueUsage      = "55P04"
+
+	// Class 57 - Operator Intervention
+	OperatorIntervention = "57000"
+	QueryCanceled        = "57014"
+	AdminShutdown        = "57P01"
+	CrashShutdown        = "57P02"
+	CannotConnectNow     = "57P03"
+	DatabaseDropped      = "57P04"
+
+	// Class 58 - System Error (errors external to PostgreSQL itself)
+	SystemError   = "58000"
+	IOError       = "58030"
+	UndefinedFile = "58P01"
+	DuplicateFile = "58P02"
+
+	// Class 72 - Snapshot Failure
+	SnapshotTooOld = "72000"
+
+	// Class F0 - Configuration File Error
+	ConfigFileError = "F0000"
+	LockFileExists  = "F0001"
+
+	// Class HV - Foreign Data Wrapper Error (SQL/MED)
+	FDWError                             = "HV000"
+	FDWColumnNameNotFound                = "HV005"
+	FDWDynamicParameterValueNeeded       = "HV002"
+	FDWFunctionSequenceError             = "HV010"
+	FDWInconsistentDescriptorInformation = "HV021"
+	FDWInvalidAttributeValue             = "HV024"
+	FDWInvalidColumnName                 = "HV007"
+	FDWInvalidColumnNumber               = "HV008"
+	FDWInvalidDataType                   = "HV004"
+	FDWInvalidDataTypeDescriptors        = "HV006"
+	FDWInvalidDescriptorFieldIdentifier  = "HV091"
+	FDWInvalidHandle                     = "HV00B"
+	FDWInvalidOptionIndex                = "HV00C"
+	FDWInvalidOptionName                 = "HV00D"
+	FDWInvalidStringLengthOrBufferLength = "HV090"
+	FDWInvalidStringFormat               = "HV00A"
+	FDWInvalidUseOfNullPointer           = "HV009"
+	FDWTooManyHandles                    = "HV014"
+	FDWOutOfMemory                       = "HV001"
+	FDWNoSchemas                         = "HV00P"
+	FDWOptionNameNotFound                = "HV00J"
+	FDWReplyHandle                       = "HV00K"
+	FDWSchemaNotFound                    = "HV00Q"
+	FDWTableNotFound                     = "HV00R"
+	FDWUnableToCreateExecution           = "HV00L"
+	FDWUnableToCreateReply               = "HV00M"
+	FDWUnableToEstablishConnection       = "HV00N"
+
+	// Class P0 - PL/pgSQL Error
+	PLpgSQLError   = "P0000"
+	RaiseException = "P0001"
+	NoDataFound    = "P0002"
+	TooManyRows    = "P0003"
+	AssertFailure  = "P0004"
+
+	// Class XX - Internal Error
+	InternalError  = "XX000"
+	DataCorrupted  = "XX001"
+	IndexCorrupted = "XX002"
+)
+
+// Class returns the class of the error code, i.e. its first two characters.
+func Class(code string) string {
+	if len(code) < 2 {
+		return ""
+	}
+	return code[:2]
+}
+
+// IsSuccessfulCompletion reports whether the error code is of class 00 - Successful Completion.
+func IsSuccessfulCompletion(code string) bool {
+	return Class(code) == "00"
+}
+
+// IsWarning reports whether the error code is of class 01 - Warning.
+func IsWarning(code string) bool {
+	return Class(code) == "01"
+}
+
+// IsNoData reports whether the error code is of class 02 - No Data (this is also a warning class per the SQL standard).
+func IsNoData(code string) bool {
+	return Class(code) == "02"
+}
+
+// IsSQLStatementNotYetComplete reports whether the error code is of class 03 - SQL Statement Not Yet Complete.
+func IsSQLStatementNotYetComplete(code string) bool {
+	return Class(code) == "03"
+}
+
+// IsConnectionException reports whether the error code is of class 08 - Connection Exception.
+func IsConnectionException(code string) bool {
+	return Class(code) == "08"
+}
+
+// IsTriggeredActionException reports whether the error code is of class 09 - Triggered Action Exception.
+func IsTriggeredActionException(code string) bool {
+	return Class(code) == "09"
+}
+
+// IsFeatureNotSupported reports whether the error code is of class 0A - Feature Not Supported.
+func IsFeatureNotSupported(code string) bool {
+	return Class(code) == "0A"
+}
+
+// IsInvalidTransactionInitiation reports whether the error code is of class 0B - Invalid Transaction Initiation.
+func IsInvalidTransactionInitiation(code string) bool {
+	return Class(code) == "0B"
+}
+
+// IsLocatorException reports whether the error code is of class 0F - Locator Exception.
+func IsLocatorException(code string) bool {
+	return Class(code) == "0F"
+}
+
+// IsInvalidGrantor reports whether the error code is of class 0L - Invalid Grantor.
+func IsInvalidGrantor(code string) bool {
+	return Class(code) == "0L"
+}
+
+// IsInvalidRoleSpecification reports whether the error code is of class 0P - Invalid Role Specification.
+func IsInvalidRoleSpecification(code string) bool {
+	return Class(code) == "0P"
+}
+
+// IsDiagnosticsException reports whether the error code is of class 0Z - Diagnostics Exception.
+func IsDiagnosticsException(code string) bool {
+	return Class(code) == "0Z"
+}
+
+// IsXQueryError reports whether the error code is of class 10 - XQuery Error.
+func IsXQueryError(code string) bool {
+	return Class(code) == "10"
+}
+
+// IsCaseNotFound reports whether the error code is of class 20 - Case Not Found.
+func IsCaseNotFound(code string) bool {
+	return Class(code) == "20"
+}
+
+// IsCardinalityViolation reports whether the error code is of class 21 - Cardinality Violation.
+func IsCardinalityViolation(code string) bool {
+	return Class(code) == "21"
+}
+
+// IsDataException reports whether the error code is of class 22 - Data Exception.
+func IsDataException(code string) bool {
+	return Class(code) == "22"
+}
+
+// IsIntegrityConstraintViolation reports whether the error code is of class 23 - Integrity Constraint Violation.
+func IsIntegrityConstraintViolation(code string) bool {
+	return Class(code) == "23"
+}
+
+// IsInvalidCursorState reports whether the error code is of class 24 - Invalid Cursor State.
+func IsInvalidCursorState(code string) bool {
+	return Class(code) == "24"
+}
+
+// IsInvalidTransactionState reports whether the error code is of class 25 - Invalid Transaction State.
+func IsInvalidTransactionState(code string) bool {
+	return Class(code) == "25"
+}
+
+// IsInvalidSQLStatementName reports whether the error code is of class 26 - Invalid SQL Statement Name.
+func IsInvalidSQLStatementName(code string) bool {
+	return Class(code) == "26"
+}
+
+// IsTriggeredDataChangeViolation reports whether the error code is of class 27 - Triggered Data Change Violation.
+func IsTriggeredDataChangeViolation(code string) bool {
+	return Class(code) == "27"
+}
+
+// IsInvalidAuthorizationSpecification reports whether the error code is of class 28 - Invalid Authorization Specification.
+func IsInvalidAuthorizationSpecification(code string) bool {
+	return Class(code) == "28"
+}
+
+// IsDependentPrivilegeDescriptorsStillExist reports whether the error code is of class 2B - Dependent Privilege Descriptors Still Exist.
+func IsDependentPrivilegeDescriptorsStillExist(code string) bool {
+	return Class(code) == "2B"
+}
+
+// IsInvalidTransactionTermination reports whether the error code is of class 2D - Invalid Transaction Termination.
+func IsInvalidTransactionTermination(code string) bool {
+	return Class(code) == "2D"
+}
+
+// IsSQLRoutineException reports whether the error code is of class 2F - SQL Routine Exception.
+func IsSQLRoutineException(code string) bool {
+	return Class(code) == "2F"
+}
+
+// IsInvalidCursorName reports whether the error code is of class 34 - Invalid Cursor Name.
+func IsInvalidCursorName(code string) bool {
+	return Class(code) == "34"
+}
+
+// IsExternalRoutineException reports whether the error code is of class 38 - External Routine Exception.
+func IsExternalRoutineException(code string) bool {
+	return Class(code) == "38"
+}
+
+// IsExternalRoutineInvocationException reports whether the error code is of class 39 - External Routine Invocation Exception.
+func IsExternalRoutineInvocationException(code string) bool {
+	return Class(code) == "39"
+}
+
+// IsSavepointException reports whether the error code is of class 3B - Savepoint Exception.
+func IsSavepointException(code string) bool {
+	return Class(code) == "3B"
+}
+
+// IsInvalidCatalogName reports whether the error code is of class 3D - Invalid Catalog Name.
+func IsInvalidCatalogName(code string) bool {
+	return Class(code) == "3D"
+}
+
+// IsInvalidSchemaName reports whether the error code is of class 3F - Invalid Schema Name.
+func IsInvalidSchemaName(code string) bool {
+	return Class(code) == "3F"
+}
+
+// IsTransactionRollback reports whether the error code is of class 40 - Transaction Rollback.
+func IsTransactionRollback(code string) bool {
+	return Class(code) == "40"
+}
+
+// IsSyntaxErrorOrAccessRuleViolation reports whether the error code is of class 42 - Syntax Error or Access Rule Violation.
+func IsSyntaxErrorOrAccessRuleViolation(code string) bool {
+	return Class(code) == "42"
+}
+
+// IsWithCheckOptionViolation reports whether the error code is of class 44 - WITH CHECK OPTION Violation.
+func IsWithCheckOptionViolation(code string) bool {
+	return Class(code) == "44"
+}
+
+// IsInsufficientResources reports whether the error code is of class 53 - Insufficient Resources.
+func IsInsufficientResources(code string) bool {
+	return Class(code) == "53"
+}
+
+// IsProgramLimitExceeded reports whether the error code is of class 54 - Program Limit Exceeded.
+func IsProgramLimitExceeded(code string) bool {
+	return Class(code) == "54"
+}
+
+// IsObjectNotInPrerequisiteState reports whether the error code is of class 55 - Object Not In Prerequisite State.
+func IsObjectNotInPrerequisiteState(code string) bool {
+	return Class(code) == "55"
+}
+
+// IsOperatorIntervention reports whether the error code is of class 57 - Operator Intervention.
+func IsOperatorIntervention(code string) bool {
+	return Class(code) == "57"
+}
+
+// IsSystemError reports whether the error code is of class 58 - System Error (errors external to PostgreSQL itself).
+func IsSystemError(code string) bool {
+	return Class(code) == "58"
+}
+
+// IsSnapshotFailure reports whether the error code is of class 72 - Snapshot Failure.
+func IsSnapshotFailure(code string) bool {
+	return Class(code) == "72"
+}
+
+// IsConfigFileError reports whether the error code is of class F0 - Configuration File Error.
+func IsConfigFileError(code string) bool {
+	return Class(code) == "F0"
+}
+
+// IsFDWError reports whether the error code is of class HV - Foreign Data Wrapper Error (SQL/MED).
+func IsFDWError(code string) bool {
+	return Class(code) == "HV"
+}
+
+// IsPLpgSQLError reports whether the error code is of class P0 - PL/pgSQL Error.
+func IsPLpgSQLError(code string) bool {
+	return Class(code) == "P0"
+}
+
+// IsInternalError reports whether the error code is of class XX - Internal Error.
+func IsInternalError(code string) bool {
+	return Class(code) == "XX"
+}
@@ -0,0 +1,35 @@
+package pgerrcode_test
+
+import (
+	"testing"
+
+	"github.com/go-pg/pg/v10/pgerrcode"
+)
+
+func TestClass(t *testing.T) {
+	tests := []struct {
+		code  string
+		class string
+	}{
+		{pgerrcode.UniqueViolation, "23"},
+		{pgerrcode.DeadlockDetected, "40"},
+		{pgerrcode.FDWTableNotFound, "HV"},
+		{"4", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := pgerrcode.Class(test.code); got != test.class {
+			t.Fatalf("Class(%q) = %q, wanted %q", test.code, got, test.class)
+		}
+	}
+
+	if !pgerrcode.IsIntegrityConstraintViolation(pgerrcode.ForeignKeyViolation) {
+		t.Fatal("foreign_key_violation is an integrity constraint violation")
+	}
+	if !pgerrcode.IsXQueryError(pgerrcode.InvalidArgumentForXQuery) {
+		t.Fatal("invalid_argument_for_xquery is an XQuery error")
+	}
+	if pgerrcode.IsTransactionRollback(pgerrcode.UniqueViolation) {
+		t.Fatal("unique_violation is not a transaction rollback")
+	}
+}
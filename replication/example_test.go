@@ -0,0 +1,59 @@
+package replication_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/replication"
+)
+
+func ExampleConn_StartReplication() {
+	ctx := context.Background()
+
+	conn := replication.Connect(&pg.Options{
+		User:     "postgres",
+		Database: "postgres",
+	})
+	defer conn.Close()
+
+	if err := conn.CreatePublication(ctx, "books_pub", "books"); err != nil {
+		panic(err)
+	}
+
+	slot, err := conn.CreateSlot(ctx, "books_slot", &replication.SlotOptions{
+		Temporary: true,
+		Snapshot:  "noexport",
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	stream, err := conn.StartReplication(ctx, slot.Name, slot.ConsistentPoint, &replication.StreamOptions{
+		Publications: []string{"books_pub"},
+	})
+	if err != nil {
+		panic(err)
+	}
+	defer stream.Close()
+
+	stream.RegisterModel((*Book)(nil))
+
+	for {
+		xld, err := stream.Receive(ctx)
+		if err != nil {
+			panic(err)
+		}
+
+		switch msg := xld.Message.(type) {
+		case *replication.Insert:
+			fmt.Printf("inserted %v\n", msg.NewModel.(*Book))
+		case *replication.Update:
+			fmt.Printf("updated %v\n", msg.NewModel.(*Book))
+		case *replication.Delete:
+			fmt.Printf("deleted %v\n", msg.OldModel.(*Book))
+		case *replication.Commit:
+			stream.Ack(msg.EndLSN)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package replication
+
+import (
+	"fmt"
+
+	"github.com/go-pg/pg/v10/types"
+)
+
+// LSN is a position in the write-ahead log.
+type LSN uint64
+
+var (
+	_ types.ValueAppender = LSN(0)
+	_ types.ValueScanner  = (*LSN)(nil)
+)
+
+// ParseLSN parses the textual representation of an LSN, e.g. 16/B374D848.
+func ParseLSN(s string) (LSN, error) {
+	var hi, lo uint32
+	var tail string
+	if n, _ := fmt.Sscanf(s, "%X/%X%s", &hi, &lo, &tail); n != 2 {
+		return 0, fmt.Errorf("pg: invalid LSN: %q", s)
+	}
+	return LSN(uint64(hi)<<32 | uint64(lo)), nil
+}
+
+func (lsn LSN) String() string {
+	return fmt.Sprintf("%X/%X", uint32(lsn>>32), uint32(lsn))
+}
+
+func (lsn LSN) AppendValue(b []byte, flags int) ([]byte, error) {
+	return types.AppendString(b, lsn.String(), flags), nil
+}
+
+func (lsn *LSN) ScanValue(rd types.Reader, n int) error {
+	if n <= 0 {
+		*lsn = 0
+		return nil
+	}
+
+	b, err := rd.ReadFullTemp()
+	if err != nil {
+		return err
+	}
+
+	parsed, err := ParseLSN(string(b))
+	if err != nil {
+		return err
+	}
+	*lsn = parsed
+	return nil
+}
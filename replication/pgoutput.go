@@ -0,0 +1,548 @@
+package replication
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-pg/pg/v10/internal/pool"
+	"github.com/go-pg/pg/v10/orm"
+	"github.com/go-pg/pg/v10/types"
+)
+
+// pgEpoch is the PostgreSQL epoch (2000-01-01) used by replication timestamps.
+const pgEpoch = 946684800
+
+// Message is a logical replication message sent by the pgoutput plugin.
+// It is one of *Begin, *Commit, *Origin, *Relation, *Type, *Insert,
+// *Update, *Delete or *Truncate.
+type Message interface {
+	pgoutputMessage()
+}
+
+// Begin starts a transaction.
+type Begin struct {
+	// FinalLSN is the LSN of the commit record of the transaction.
+	FinalLSN   LSN
+	CommitTime time.Time
+	XID        uint32
+}
+
+// Commit ends a transaction.
+type Commit struct {
+	Flags      uint8
+	CommitLSN  LSN
+	EndLSN     LSN
+	CommitTime time.Time
+}
+
+// Origin is the replication origin of the transaction.
+type Origin struct {
+	CommitLSN LSN
+	Name      string
+}
+
+// Relation describes a table. It is sent before the first change
+// of the table in the stream and after the table definition changes.
+type Relation struct {
+	RelationID      uint32
+	Namespace       string
+	Name            string
+	ReplicaIdentity uint8
+	Columns         []RelationColumn
+
+	table *orm.Table
+}
+
+// RelationColumn describes a column of a Relation.
+type RelationColumn struct {
+	// Flags is 1 when the column is part of the key.
+	Flags        uint8
+	Name         string
+	DataType     int32
+	TypeModifier int32
+}
+
+// Type describes a custom data type used by a Relation.
+type Type struct {
+	DataType  int32
+	Namespace string
+	Name      string
+}
+
+// Insert is a new row.
+type Insert struct {
+	RelationID uint32
+	Relation   *Relation
+	New        *Tuple
+	// NewModel is a pointer to the registered model scanned from New.
+	NewModel interface{}
+}
+
+// Update is an updated row. Old is only set when the key changed
+// or the table uses REPLICA IDENTITY FULL.
+type Update struct {
+	RelationID uint32
+	Relation   *Relation
+	Old        *Tuple
+	New        *Tuple
+	// OldModel and NewModel are pointers to the registered model
+	// scanned from Old and New.
+	OldModel interface{}
+	NewModel interface{}
+}
+
+// Delete is a deleted row. Old contains the key columns
+// or the whole row with REPLICA IDENTITY FULL.
+type Delete struct {
+	RelationID uint32
+	Relation   *Relation
+	Old        *Tuple
+	// OldModel is a pointer to the registered model scanned from Old.
+	OldModel interface{}
+}
+
+// Truncate is a truncation of one or more tables.
+type Truncate struct {
+	// Options is 1 for CASCADE and 2 for RESTART IDENTITY.
+	Options     uint8
+	RelationIDs []uint32
+}
+
+func (*Begin) pgoutputMessage()    {}
+func (*Commit) pgoutputMessage()   {}
+func (*Origin) pgoutputMessage()   {}
+func (*Relation) pgoutputMessage() {}
+func (*Type) pgoutputMessage()     {}
+func (*Insert) pgoutputMessage()   {}
+func (*Update) pgoutputMessage()   {}
+func (*Delete) pgoutputMessage()   {}
+func (*Truncate) pgoutputMessage() {}
+
+// Kinds of TupleColumn.
+const (
+	TupleNull      = 'n'
+	TupleUnchanged = 'u'
+	TupleText      = 't'
+	TupleBinary    = 'b'
+)
+
+// Tuple is a row of a Relation.
+type Tuple struct {
+	Columns []TupleColumn
+}
+
+// TupleColumn is a column value. Unchanged is used for TOASTed
+// values that were not changed by an update.
+type TupleColumn struct {
+	Kind byte
+	Data []byte
+}
+
+// Scan scans the tuple into the struct pointed to by model
+// using the columns of the relation. Columns that have no matching
+// field and unchanged TOASTed values are skipped.
+func (rel *Relation) Scan(model interface{}, tuple *Tuple) error {
+	v := reflect.ValueOf(model)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("pg: Scan(non-pointer-to-struct %T)", model)
+	}
+	strct := v.Elem()
+	return rel.scan(orm.GetTable(strct.Type()), strct, tuple)
+}
+
+func (rel *Relation) scan(table *orm.Table, strct reflect.Value, tuple *Tuple) error {
+	if len(tuple.Columns) > len(rel.Columns) {
+		return fmt.Errorf("pg: tuple has %d columns, but relation %s has %d",
+			len(tuple.Columns), rel.Name, len(rel.Columns))
+	}
+
+	for i := range tuple.Columns {
+		tc := &tuple.Columns[i]
+		col := &rel.Columns[i]
+
+		field, ok := table.FieldsMap[col.Name]
+		if !ok {
+			continue
+		}
+
+		var err error
+		switch tc.Kind {
+		case TupleNull:
+			err = field.ScanValue(strct, pool.NewBytesReader(nil), -1)
+		case TupleUnchanged:
+		case TupleText:
+			err = field.ScanValue(strct, pool.NewBytesReader(tc.Data), len(tc.Data))
+		case TupleBinary:
+			var b []byte
+			b, err = types.AppendBinaryAsText(nil, col.DataType, tc.Data)
+			if err == nil {
+				err = field.ScanValue(strct, pool.NewBytesReader(b), len(b))
+			}
+		default:
+			err = fmt.Errorf("pg: unknown tuple column kind %q", tc.Kind)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Decoder decodes pgoutput messages. It remembers relations
+// so changes can be matched with their tables.
+type Decoder struct {
+	relations map[uint32]*Relation
+	tables    map[string]*orm.Table
+}
+
+// NewDecoder returns a new Decoder.
+func NewDecoder() *Decoder {
+	return &Decoder{
+		relations: make(map[uint32]*Relation),
+		tables:    make(map[string]*orm.Table),
+	}
+}
+
+// RegisterModel registers models, e.g. (*Book)(nil), whose tables
+// are scanned into new model instances set in the NewModel and
+// OldModel fields of changes. Models must be registered before
+// the relations of their tables are decoded.
+func (d *Decoder) RegisterModel(models ...interface{}) {
+	for _, model := range models {
+		typ := reflect.TypeOf(model)
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct {
+			panic(fmt.Errorf("pg: RegisterModel(unsupported %s)", typ))
+		}
+
+		table := orm.GetTable(typ)
+		d.tables[string(table.SQLName)] = table
+	}
+}
+
+// Relation returns the relation with the id or nil.
+func (d *Decoder) Relation(id uint32) *Relation {
+	return d.relations[id]
+}
+
+func (d *Decoder) relation(id uint32) (*Relation, error) {
+	rel, ok := d.relations[id]
+	if !ok {
+		return nil, fmt.Errorf("pg: unknown relation %d", id)
+	}
+	return rel, nil
+}
+
+func (d *Decoder) table(rel *Relation) *orm.Table {
+	name := rel.Namespace + "." + rel.Name
+	if table, ok := d.tables[string(types.AppendIdent(nil, name, 1))]; ok {
+		return table
+	}
+	if rel.Namespace == "public" {
+		return d.tables[string(types.AppendIdent(nil, rel.Name, 1))]
+	}
+	return nil
+}
+
+// Decode decodes a pgoutput message.
+func (d *Decoder) Decode(data []byte) (Message, error) {
+	msg, err := d.decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (d *Decoder) decode(data []byte) (Message, error) {
+	if len(data) == 0 {
+		return nil, errShortMessage
+	}
+
+	rd := &msgReader{b: data[1:]}
+	switch data[0] {
+	case 'B':
+		msg := &Begin{
+			FinalLSN:   LSN(rd.uint64()),
+			CommitTime: rd.time(),
+			XID:        rd.uint32(),
+		}
+		return msg, rd.err
+	case 'C':
+		msg := &Commit{
+			Flags:      rd.uint8(),
+			CommitLSN:  LSN(rd.uint64()),
+			EndLSN:     LSN(rd.uint64()),
+			CommitTime: rd.time(),
+		}
+		return msg, rd.err
+	case 'O':
+		msg := &Origin{
+			CommitLSN: LSN(rd.uint64()),
+			Name:      rd.string(),
+		}
+		return msg, rd.err
+	case 'R':
+		return d.decodeRelation(rd)
+	case 'Y':
+		msg := &Type{
+			DataType:  int32(rd.uint32()),
+			Namespace: rd.string(),
+			Name:      rd.string(),
+		}
+		return msg, rd.err
+	case 'I':
+		return d.decodeInsert(rd)
+	case 'U':
+		return d.decodeUpdate(rd)
+	case 'D':
+		return d.decodeDelete(rd)
+	case 'T':
+		n := int(rd.uint32())
+		msg := &Truncate{
+			Options: rd.uint8(),
+		}
+		for i := 0; i < n && rd.err == nil; i++ {
+			msg.RelationIDs = append(msg.RelationIDs, rd.uint32())
+		}
+		return msg, rd.err
+	default:
+		return nil, fmt.Errorf("pg: unknown pgoutput message %q", data[0])
+	}
+}
+
+func (d *Decoder) decodeRelation(rd *msgReader) (Message, error) {
+	rel := &Relation{
+		RelationID:      rd.uint32(),
+		Namespace:       rd.string(),
+		Name:            rd.string(),
+		ReplicaIdentity: rd.uint8(),
+	}
+
+	n := int(rd.uint16())
+	for i := 0; i < n && rd.err == nil; i++ {
+		rel.Columns = append(rel.Columns, RelationColumn{
+			Flags:        rd.uint8(),
+			Name:         rd.string(),
+			DataType:     int32(rd.uint32()),
+			TypeModifier: int32(rd.uint32()),
+		})
+	}
+	if rd.err != nil {
+		return nil, rd.err
+	}
+
+	rel.table = d.table(rel)
+	d.relations[rel.RelationID] = rel
+	return rel, nil
+}
+
+func (d *Decoder) decodeInsert(rd *msgReader) (Message, error) {
+	msg := &Insert{
+		RelationID: rd.uint32(),
+	}
+	if c := rd.uint8(); rd.err == nil && c != 'N' {
+		return nil, fmt.Errorf("pg: Insert: unexpected tuple type %q", c)
+	}
+	msg.New = rd.tuple()
+	if rd.err != nil {
+		return nil, rd.err
+	}
+
+	rel, err := d.relation(msg.RelationID)
+	if err != nil {
+		return nil, err
+	}
+	msg.Relation = rel
+
+	msg.NewModel, err = rel.newModel(msg.New)
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (d *Decoder) decodeUpdate(rd *msgReader) (Message, error) {
+	msg := &Update{
+		RelationID: rd.uint32(),
+	}
+	c := rd.uint8()
+	if c == 'K' || c == 'O' {
+		msg.Old = rd.tuple()
+		c = rd.uint8()
+	}
+	if rd.err == nil && c != 'N' {
+		return nil, fmt.Errorf("pg: Update: unexpected tuple type %q", c)
+	}
+	msg.New = rd.tuple()
+	if rd.err != nil {
+		return nil, rd.err
+	}
+
+	rel, err := d.relation(msg.RelationID)
+	if err != nil {
+		return nil, err
+	}
+	msg.Relation = rel
+
+	if msg.Old != nil {
+		msg.OldModel, err = rel.newModel(msg.Old)
+		if err != nil {
+			return nil, err
+		}
+	}
+	msg.NewModel, err = rel.newModel(msg.New)
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (d *Decoder) decodeDelete(rd *msgReader) (Message, error) {
+	msg := &Delete{
+		RelationID: rd.uint32(),
+	}
+	if c := rd.uint8(); rd.err == nil && c != 'K' && c != 'O' {
+		return nil, fmt.Errorf("pg: Delete: unexpected tuple type %q", c)
+	}
+	msg.Old = rd.tuple()
+	if rd.err != nil {
+		return nil, rd.err
+	}
+
+	rel, err := d.relation(msg.RelationID)
+	if err != nil {
+		return nil, err
+	}
+	msg.Relation = rel
+
+	msg.OldModel, err = rel.newModel(msg.Old)
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// newModel returns a new instance of the registered model
+// scanned from the tuple or nil.
+func (rel *Relation) newModel(tuple *Tuple) (interface{}, error) {
+	if rel.table == nil {
+		return nil, nil
+	}
+	v := reflect.New(rel.table.Type)
+	if err := rel.scan(rel.table, v.Elem(), tuple); err != nil {
+		return nil, err
+	}
+	return v.Interface(), nil
+}
+
+//------------------------------------------------------------------------------
+
+var errShortMessage = errors.New("pg: pgoutput message is too short")
+
+type msgReader struct {
+	b   []byte
+	err error
+}
+
+func (rd *msgReader) next(n int) []byte {
+	if rd.err != nil {
+		return nil
+	}
+	if len(rd.b) < n {
+		rd.err = errShortMessage
+		return nil
+	}
+	b := rd.b[:n]
+	rd.b = rd.b[n:]
+	return b
+}
+
+func (rd *msgReader) uint8() uint8 {
+	b := rd.next(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (rd *msgReader) uint16() uint16 {
+	b := rd.next(2)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b)
+}
+
+func (rd *msgReader) uint32() uint32 {
+	b := rd.next(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+func (rd *msgReader) uint64() uint64 {
+	b := rd.next(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+func (rd *msgReader) time() time.Time {
+	usec := int64(rd.uint64())
+	if rd.err != nil {
+		return time.Time{}
+	}
+	return pgTime(usec)
+}
+
+func (rd *msgReader) string() string {
+	if rd.err != nil {
+		return ""
+	}
+	for i, c := range rd.b {
+		if c == 0 {
+			s := string(rd.b[:i])
+			rd.b = rd.b[i+1:]
+			return s
+		}
+	}
+	rd.err = errShortMessage
+	return ""
+}
+
+func (rd *msgReader) tuple() *Tuple {
+	n := int(rd.uint16())
+	tuple := &Tuple{
+		Columns: make([]TupleColumn, 0, n),
+	}
+	for i := 0; i < n && rd.err == nil; i++ {
+		col := TupleColumn{
+			Kind: rd.uint8(),
+		}
+		switch col.Kind {
+		case TupleText, TupleBinary:
+			size := int(rd.uint32())
+			if b := rd.next(size); b != nil {
+				col.Data = append([]byte(nil), b...)
+			}
+		}
+		tuple.Columns = append(tuple.Columns, col)
+	}
+	return tuple
+}
+
+func pgTime(usec int64) time.Time {
+	return time.Unix(pgEpoch+usec/1e6, (usec%1e6)*1e3).UTC()
+}
+
+func pgTimestamp(tm time.Time) int64 {
+	return (tm.Unix()-pgEpoch)*1e6 + int64(tm.Nanosecond()/1e3)
+}
@@ -0,0 +1,268 @@
+package replication_test
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v10/replication"
+)
+
+type msgBuilder []byte
+
+func (b msgBuilder) uint8(n uint8) msgBuilder {
+	return append(b, n)
+}
+
+func (b msgBuilder) uint16(n uint16) msgBuilder {
+	return append(b, byte(n>>8), byte(n))
+}
+
+func (b msgBuilder) uint32(n uint32) msgBuilder {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], n)
+	return append(b, buf[:]...)
+}
+
+func (b msgBuilder) uint64(n uint64) msgBuilder {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], n)
+	return append(b, buf[:]...)
+}
+
+func (b msgBuilder) string(s string) msgBuilder {
+	b = append(b, s...)
+	return append(b, 0)
+}
+
+// tuple encodes values as text columns; nil is NULL.
+func (b msgBuilder) tuple(values ...interface{}) msgBuilder {
+	b = b.uint16(uint16(len(values)))
+	for _, v := range values {
+		switch v := v.(type) {
+		case nil:
+			b = b.uint8('n')
+		case byte:
+			b = b.uint8(v)
+		case string:
+			b = b.uint8('t').uint32(uint32(len(v)))
+			b = append(b, v...)
+		}
+	}
+	return b
+}
+
+func relationMsg() []byte {
+	return msgBuilder{'R'}.
+		uint32(16384).
+		string("public").
+		string("books").
+		uint8('d').
+		uint16(3).
+		uint8(1).string("id").uint32(20).uint32(0xffffffff).
+		uint8(0).string("title").uint32(25).uint32(0xffffffff).
+		uint8(0).string("extra").uint32(25).uint32(0xffffffff)
+}
+
+type Book struct {
+	ID    int64
+	Title string
+}
+
+func TestDecodeTransaction(t *testing.T) {
+	tm := time.Date(2020, time.March, 4, 5, 6, 7, 123456000, time.UTC)
+	usec := uint64(tm.Sub(time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)).Microseconds())
+
+	dec := replication.NewDecoder()
+
+	msg, err := dec.Decode(msgBuilder{'B'}.uint64(0x16B374D848).uint64(usec).uint32(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := &replication.Begin{FinalLSN: 0x16B374D848, CommitTime: tm, XID: 42}
+	if !reflect.DeepEqual(msg, wanted) {
+		t.Fatalf("got %#v, wanted %#v", msg, wanted)
+	}
+
+	msg, err = dec.Decode(msgBuilder{'C'}.uint8(0).uint64(1).uint64(2).uint64(usec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantedCommit := &replication.Commit{CommitLSN: 1, EndLSN: 2, CommitTime: tm}
+	if !reflect.DeepEqual(msg, wantedCommit) {
+		t.Fatalf("got %#v, wanted %#v", msg, wantedCommit)
+	}
+}
+
+func TestDecodeChanges(t *testing.T) {
+	dec := replication.NewDecoder()
+
+	msg, err := dec.Decode(relationMsg())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel := msg.(*replication.Relation)
+	if rel.Namespace != "public" || rel.Name != "books" || len(rel.Columns) != 3 {
+		t.Fatalf("got %#v", rel)
+	}
+	if col := rel.Columns[0]; col.Name != "id" || col.DataType != 20 || col.Flags != 1 || col.TypeModifier != -1 {
+		t.Fatalf("got %#v", col)
+	}
+
+	msg, err = dec.Decode(msgBuilder{'I'}.uint32(16384).uint8('N').tuple("1", "hello", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	insert := msg.(*replication.Insert)
+	if insert.Relation != rel || insert.NewModel != nil {
+		t.Fatalf("got %#v", insert)
+	}
+	wantedTuple := &replication.Tuple{Columns: []replication.TupleColumn{
+		{Kind: 't', Data: []byte("1")},
+		{Kind: 't', Data: []byte("hello")},
+		{Kind: 'n'},
+	}}
+	if !reflect.DeepEqual(insert.New, wantedTuple) {
+		t.Fatalf("got %#v, wanted %#v", insert.New, wantedTuple)
+	}
+
+	msg, err = dec.Decode(msgBuilder{'U'}.uint32(16384).
+		uint8('K').tuple("1", nil, nil).
+		uint8('N').tuple("2", byte('u'), nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	update := msg.(*replication.Update)
+	if len(update.Old.Columns) != 3 || update.New.Columns[1].Kind != replication.TupleUnchanged {
+		t.Fatalf("got %#v", update)
+	}
+
+	msg, err = dec.Decode(msgBuilder{'U'}.uint32(16384).uint8('N').tuple("2", "world", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update := msg.(*replication.Update); update.Old != nil {
+		t.Fatalf("got %#v", update.Old)
+	}
+
+	msg, err = dec.Decode(msgBuilder{'D'}.uint32(16384).uint8('K').tuple("2", nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if del := msg.(*replication.Delete); string(del.Old.Columns[0].Data) != "2" {
+		t.Fatalf("got %#v", del)
+	}
+
+	msg, err = dec.Decode(msgBuilder{'T'}.uint32(2).uint8(1).uint32(16384).uint32(16385))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantedTruncate := &replication.Truncate{Options: 1, RelationIDs: []uint32{16384, 16385}}
+	if !reflect.DeepEqual(msg, wantedTruncate) {
+		t.Fatalf("got %#v, wanted %#v", msg, wantedTruncate)
+	}
+}
+
+func TestDecodeRegisteredModel(t *testing.T) {
+	dec := replication.NewDecoder()
+	dec.RegisterModel((*Book)(nil))
+
+	if _, err := dec.Decode(relationMsg()); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := dec.Decode(msgBuilder{'I'}.uint32(16384).uint8('N').tuple("1", "hello", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	book, ok := msg.(*replication.Insert).NewModel.(*Book)
+	if !ok {
+		t.Fatalf("got %#v", msg.(*replication.Insert).NewModel)
+	}
+	if book.ID != 1 || book.Title != "hello" {
+		t.Fatalf("got %#v", book)
+	}
+
+	msg, err = dec.Decode(msgBuilder{'U'}.uint32(16384).uint8('N').tuple("1", byte('u'), nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book := msg.(*replication.Update).NewModel.(*Book); book.ID != 1 || book.Title != "" {
+		t.Fatalf("got %#v", book)
+	}
+
+	msg, err = dec.Decode(msgBuilder{'D'}.uint32(16384).uint8('K').tuple("1", nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book := msg.(*replication.Delete).OldModel.(*Book); book.ID != 1 {
+		t.Fatalf("got %#v", book)
+	}
+}
+
+func TestRelationScan(t *testing.T) {
+	dec := replication.NewDecoder()
+	msg, err := dec.Decode(relationMsg())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel := msg.(*replication.Relation)
+
+	book := &Book{Title: "old"}
+	tuple := &replication.Tuple{Columns: []replication.TupleColumn{
+		{Kind: 'b', Data: []byte{0, 0, 0, 0, 0, 0, 0, 7}},
+		{Kind: 'n'},
+		{Kind: 't', Data: []byte("ignored")},
+	}}
+	if err := rel.Scan(book, tuple); err != nil {
+		t.Fatal(err)
+	}
+	if book.ID != 7 || book.Title != "" {
+		t.Fatalf("got %#v", book)
+	}
+
+	if err := rel.Scan(*book, tuple); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	dec := replication.NewDecoder()
+
+	tests := []struct {
+		data   []byte
+		wanted string
+	}{
+		{nil, "pg: pgoutput message is too short"},
+		{[]byte("B\x00\x01"), "pg: pgoutput message is too short"},
+		{[]byte("Z"), `pg: unknown pgoutput message 'Z'`},
+		{msgBuilder{'I'}.uint32(1).uint8('N').tuple("1"), "pg: unknown relation 1"},
+		{msgBuilder{'I'}.uint32(1).uint8('X'), `pg: Insert: unexpected tuple type 'X'`},
+	}
+	for _, test := range tests {
+		_, err := dec.Decode(test.data)
+		if err == nil || err.Error() != test.wanted {
+			t.Fatalf("got %v, wanted %q", err, test.wanted)
+		}
+	}
+}
+
+func TestLSN(t *testing.T) {
+	lsn, err := replication.ParseLSN("16/B374D848")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lsn != 0x16B374D848 {
+		t.Fatalf("got %X", uint64(lsn))
+	}
+	if s := lsn.String(); s != "16/B374D848" {
+		t.Fatalf("got %q", s)
+	}
+
+	for _, s := range []string{"", "16", "16/", "16/B374D848x", "X/1"} {
+		if _, err := replication.ParseLSN(s); err == nil {
+			t.Fatalf("ParseLSN(%q): expected an error", s)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Package replication implements a PostgreSQL logical replication client
+// that streams changes decoded by the pgoutput plugin.
+package replication
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/types"
+)
+
+// Conn is a connection opened with replication=database that runs
+// replication commands and regular SQL.
+type Conn struct {
+	db *pg.DB
+}
+
+// Connect connects to a database in logical replication mode
+// using provided options.
+func Connect(opt *pg.Options) *Conn {
+	o := *opt
+	o.RuntimeParams = make(map[string]string, len(opt.RuntimeParams)+1)
+	for k, v := range opt.RuntimeParams {
+		o.RuntimeParams[k] = v
+	}
+	o.RuntimeParams["replication"] = "database"
+
+	// Every connection occupies a WAL sender on the server.
+	if o.PoolSize == 0 {
+		o.PoolSize = 1
+	}
+
+	return &Conn{
+		db: pg.Connect(&o),
+	}
+}
+
+// DB returns the underlying DB that can be used to run SQL
+// commands like CREATE PUBLICATION.
+func (c *Conn) DB() *pg.DB {
+	return c.db
+}
+
+// Close closes the connection, releasing any open resources.
+func (c *Conn) Close() error {
+	return c.db.Close()
+}
+
+// SystemInfo is the result of the IDENTIFY_SYSTEM command.
+type SystemInfo struct {
+	SystemID string
+	Timeline int32
+	XLogPos  LSN
+	DBName   string
+}
+
+// IdentifySystem requests the server to identify itself.
+func (c *Conn) IdentifySystem(ctx context.Context) (*SystemInfo, error) {
+	info := new(SystemInfo)
+	_, err := c.db.QueryOneContext(
+		ctx,
+		pg.Scan(&info.SystemID, &info.Timeline, &info.XLogPos, &info.DBName),
+		"IDENTIFY_SYSTEM",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// SlotOptions are the options of a new replication slot.
+type SlotOptions struct {
+	// Temporary slots are dropped when the connection is closed.
+	Temporary bool
+	// Snapshot is what to do with the snapshot created with the slot:
+	// "export" (default), "use" or "noexport".
+	Snapshot string
+}
+
+// Slot is a created replication slot.
+type Slot struct {
+	Name            string
+	ConsistentPoint LSN
+	SnapshotName    string
+	OutputPlugin    string
+}
+
+// CreateSlot creates a logical replication slot that uses
+// the pgoutput plugin.
+func (c *Conn) CreateSlot(ctx context.Context, name string, opt *SlotOptions) (*Slot, error) {
+	if opt == nil {
+		opt = new(SlotOptions)
+	}
+
+	var b strings.Builder
+	b.WriteString("CREATE_REPLICATION_SLOT ?")
+	if opt.Temporary {
+		b.WriteString(" TEMPORARY")
+	}
+	b.WriteString(" LOGICAL pgoutput")
+	switch opt.Snapshot {
+	case "", "export":
+	case "use":
+		b.WriteString(" USE_SNAPSHOT")
+	case "noexport":
+		b.WriteString(" NOEXPORT_SNAPSHOT")
+	default:
+		return nil, fmt.Errorf("pg: snapshot action %q is not supported", opt.Snapshot)
+	}
+
+	slot := new(Slot)
+	_, err := c.db.QueryOneContext(
+		ctx,
+		pg.Scan(&slot.Name, &slot.ConsistentPoint, &slot.SnapshotName, &slot.OutputPlugin),
+		b.String(), pg.Ident(name),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return slot, nil
+}
+
+// DropSlot drops the replication slot.
+func (c *Conn) DropSlot(ctx context.Context, name string) error {
+	_, err := c.db.ExecContext(ctx, "DROP_REPLICATION_SLOT ?", pg.Ident(name))
+	return err
+}
+
+// CreatePublication creates a publication for the tables
+// or for all tables when no tables are given.
+func (c *Conn) CreatePublication(ctx context.Context, name string, tables ...string) error {
+	if len(tables) == 0 {
+		_, err := c.db.ExecContext(ctx, "CREATE PUBLICATION ? FOR ALL TABLES", pg.Ident(name))
+		return err
+	}
+
+	var b []byte
+	for i, table := range tables {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b = types.AppendIdent(b, table, 1)
+	}
+
+	_, err := c.db.ExecContext(ctx, "CREATE PUBLICATION ? FOR TABLE ?", pg.Ident(name), pg.Safe(b))
+	return err
+}
+
+// DropPublication drops the publication if it exists.
+func (c *Conn) DropPublication(ctx context.Context, name string) error {
+	_, err := c.db.ExecContext(ctx, "DROP PUBLICATION IF EXISTS ?", pg.Ident(name))
+	return err
+}
+
+// StartReplication starts streaming changes from the slot beginning at lsn.
+// Zero lsn starts at the confirmed position of the slot.
+func (c *Conn) StartReplication(
+	ctx context.Context, slot string, lsn LSN, opt *StreamOptions,
+) (*Stream, error) {
+	if opt == nil {
+		opt = new(StreamOptions)
+	}
+	opt.init()
+
+	var names []byte
+	for i, name := range opt.Publications {
+		if i > 0 {
+			names = append(names, ',')
+		}
+		names = types.AppendIdent(names, name, 1)
+	}
+
+	cb, err := c.db.CopyBoth(
+		ctx,
+		"START_REPLICATION SLOT ? LOGICAL ? (proto_version '1', publication_names ?)",
+		pg.Ident(slot), pg.Safe(lsn.String()), string(names),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return newStream(c.db, cb, opt), nil
+}
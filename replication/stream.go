@@ -0,0 +1,190 @@
+package replication
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/internal"
+)
+
+var errStreamClosed = errors.New("pg: replication stream is closed")
+
+// StreamOptions are the options of a replication stream.
+type StreamOptions struct {
+	// Publications to stream changes from.
+	Publications []string
+
+	// How often standby status updates are sent to the server.
+	// Default is 10 seconds.
+	StatusInterval time.Duration
+}
+
+func (opt *StreamOptions) init() {
+	if opt.StatusInterval == 0 {
+		opt.StatusInterval = 10 * time.Second
+	}
+}
+
+// XLogData is a chunk of WAL data that contains a decoded
+// pgoutput message.
+type XLogData struct {
+	WALStart     LSN
+	ServerWALEnd LSN
+	ServerTime   time.Time
+	Message      Message
+}
+
+// Stream streams logical replication changes. Keepalive messages
+// are handled automatically and standby status updates are sent
+// periodically in the background reporting positions acknowledged
+// with Ack.
+// It's NOT safe for concurrent use by multiple goroutines
+// except Ack and Close.
+type Stream struct {
+	// Atomic fields go first to be 64-bit aligned.
+	written uint64 // atomic LSN
+	flushed uint64 // atomic LSN
+
+	db  *pg.DB
+	cb  *pg.CopyBoth
+	dec *Decoder
+
+	exit      chan struct{}
+	closeOnce sync.Once
+}
+
+func newStream(db *pg.DB, cb *pg.CopyBoth, opt *StreamOptions) *Stream {
+	s := &Stream{
+		db:   db,
+		cb:   cb,
+		dec:  NewDecoder(),
+		exit: make(chan struct{}),
+	}
+	go s.statusLoop(opt.StatusInterval)
+	return s
+}
+
+// RegisterModel registers models that changes are scanned into.
+// See Decoder.RegisterModel.
+func (s *Stream) RegisterModel(models ...interface{}) {
+	s.dec.RegisterModel(models...)
+}
+
+// Receive waits for the next pgoutput message. It returns io.EOF
+// when the server ends the stream. To interrupt Receive close the stream.
+func (s *Stream) Receive(ctx context.Context) (*XLogData, error) {
+	for {
+		b, err := s.cb.ReceiveData(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) == 0 {
+			return nil, errShortMessage
+		}
+
+		rd := &msgReader{b: b[1:]}
+		switch b[0] {
+		case 'w':
+			xld := &XLogData{
+				WALStart:     LSN(rd.uint64()),
+				ServerWALEnd: LSN(rd.uint64()),
+				ServerTime:   rd.time(),
+			}
+			if rd.err != nil {
+				return nil, rd.err
+			}
+
+			s.setWritten(xld.WALStart + LSN(len(rd.b)))
+
+			xld.Message, err = s.dec.Decode(rd.b)
+			if err != nil {
+				return nil, err
+			}
+			return xld, nil
+		case 'k':
+			_ = rd.uint64() // server WAL end
+			_ = rd.uint64() // server time
+			reply := rd.uint8()
+			if rd.err != nil {
+				return nil, rd.err
+			}
+			if reply == 1 {
+				if err := s.SendStatus(ctx); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, fmt.Errorf("pg: unknown replication message %q", b[0])
+		}
+	}
+}
+
+// Ack acknowledges that changes up to lsn were processed, e.g. EndLSN
+// of a Commit, so the server can discard WAL older than lsn.
+func (s *Stream) Ack(lsn LSN) {
+	advance(&s.flushed, lsn)
+	advance(&s.written, lsn)
+}
+
+func (s *Stream) setWritten(lsn LSN) {
+	advance(&s.written, lsn)
+}
+
+func advance(addr *uint64, lsn LSN) {
+	for {
+		old := atomic.LoadUint64(addr)
+		if uint64(lsn) <= old || atomic.CompareAndSwapUint64(addr, old, uint64(lsn)) {
+			return
+		}
+	}
+}
+
+// SendStatus sends a standby status update with the received
+// and acknowledged positions.
+func (s *Stream) SendStatus(ctx context.Context) error {
+	written := atomic.LoadUint64(&s.written)
+	flushed := atomic.LoadUint64(&s.flushed)
+
+	b := make([]byte, 34)
+	b[0] = 'r'
+	binary.BigEndian.PutUint64(b[1:], written)
+	binary.BigEndian.PutUint64(b[9:], flushed)
+	binary.BigEndian.PutUint64(b[17:], flushed)
+	binary.BigEndian.PutUint64(b[25:], uint64(pgTimestamp(time.Now())))
+	b[33] = 0
+
+	return s.cb.SendData(ctx, b)
+}
+
+func (s *Stream) statusLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.SendStatus(s.db.Context()); err != nil {
+				internal.Logger.Printf(s.db.Context(), "pg: sending standby status failed: %s", err)
+			}
+		case <-s.exit:
+			return
+		}
+	}
+}
+
+// Close sends the final standby status update and closes the stream.
+func (s *Stream) Close() error {
+	err := errStreamClosed
+	s.closeOnce.Do(func() {
+		close(s.exit)
+		_ = s.SendStatus(s.db.Context())
+		err = s.cb.Close()
+	})
+	return err
+}
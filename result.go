@@ -15,8 +15,13 @@ type Result = orm.Result
 type result struct {
 	model orm.Model
 
+	// command is the command tag, e.g. INSERT 0 1 or ROLLBACK.
+	command string
+
 	affected int
 	returned int
+
+	notices []Notice
 }
 
 var _ Result = (*result)(nil)
@@ -24,6 +29,7 @@ var _ Result = (*result)(nil)
 //nolint
 func (res *result) parse(b []byte) error {
 	res.affected = -1
+	res.command = string(bytes.TrimSuffix(b, []byte{0}))
 
 	ind := bytes.LastIndexByte(b, ' ')
 	if ind == -1 {
@@ -0,0 +1,115 @@
+package pg
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/xdg-go/scram"
+	"mellium.im/sasl"
+)
+
+const (
+	scramSHA256     = "SCRAM-SHA-256"
+	scramSHA256Plus = "SCRAM-SHA-256-PLUS"
+)
+
+// scramClient is the client side of a SCRAM exchange.
+type scramClient interface {
+	// Step returns the response to the server challenge.
+	Step(challenge []byte) ([]byte, error)
+	// Verify checks that the server proved knowledge of the password.
+	Verify() error
+}
+
+// saslClient implements SCRAM-SHA-256 without channel binding.
+type saslClient struct {
+	client *sasl.Negotiator
+}
+
+var _ scramClient = (*saslClient)(nil)
+
+// newSASLClient returns a SCRAM-SHA-256 client. With the TLS state
+// the client sends the gs2 header "y,,", which tells the server that
+// the client supports channel binding, but the server did not offer it
+// (RFC 5802), so the server can detect that SCRAM-SHA-256-PLUS was
+// stripped from its offer.
+func newSASLClient(user, password string, tlsState *tls.ConnectionState) *saslClient {
+	creds := sasl.Credentials(func() (Username, Password, Identity []byte) {
+		return []byte(user), []byte(password), nil
+	})
+	if tlsState == nil {
+		return &saslClient{
+			client: sasl.NewClient(sasl.ScramSha256, creds),
+		}
+	}
+	// mellium sends "y,," from the -PLUS mechanism when the server
+	// did not offer it, without binding to the TLS connection.
+	return &saslClient{
+		client: sasl.NewClient(sasl.ScramSha256Plus, creds, sasl.TLSState(*tlsState)),
+	}
+}
+
+func (c *saslClient) Step(challenge []byte) ([]byte, error) {
+	_, resp, err := c.client.Step(challenge)
+	return resp, err
+}
+
+func (c *saslClient) Verify() error {
+	if c.client.State() != sasl.ValidServerResponse {
+		return fmt.Errorf("pg: SASL: state=%q, wanted %q",
+			c.client.State(), sasl.ValidServerResponse)
+	}
+	return nil
+}
+
+// scramPlusClient implements SCRAM-SHA-256-PLUS with tls-server-end-point
+// channel binding, which is the only channel binding type supported
+// by PostgreSQL.
+type scramPlusClient struct {
+	conv *scram.ClientConversation
+}
+
+var _ scramClient = (*scramPlusClient)(nil)
+
+func newSCRAMPlusClient(user, password string, cb scram.ChannelBinding) (*scramPlusClient, error) {
+	client, err := scram.SHA256.NewClient(user, password, "")
+	if err != nil {
+		return nil, fmt.Errorf("pg: SASL: %s", err)
+	}
+	return &scramPlusClient{
+		conv: client.NewConversationWithChannelBinding(cb),
+	}, nil
+}
+
+func (c *scramPlusClient) Step(challenge []byte) ([]byte, error) {
+	resp, err := c.conv.Step(string(challenge))
+	if err != nil {
+		return nil, fmt.Errorf("pg: SASL: %s", err)
+	}
+	return []byte(resp), nil
+}
+
+func (c *scramPlusClient) Verify() error {
+	if !c.conv.Valid() {
+		return errors.New("pg: SASL: server signature is not verified")
+	}
+	return nil
+}
+
+// tlsServerEndPoint returns the tls-server-end-point channel binding
+// defined in RFC 5929: the hash of the server certificate.
+func tlsServerEndPoint(cn net.Conn) (scram.ChannelBinding, error) {
+	tlsConn, ok := cn.(*tls.Conn)
+	if !ok {
+		return scram.ChannelBinding{}, errors.New("pg: channel binding requires a TLS connection")
+	}
+
+	state := tlsConn.ConnectionState()
+	cb, err := scram.NewTLSServerEndpointBinding(&state)
+	if err != nil {
+		return scram.ChannelBinding{}, fmt.Errorf("pg: channel binding: %s", err)
+	}
+	return cb, nil
+}
@@ -0,0 +1,205 @@
+package pg
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-pg/pg/v10/internal/pool"
+)
+
+func TestNewSCRAMClient(t *testing.T) {
+	ecdsaCert, ecdsaKey := newTestCert(t, nil, nil)
+	ed25519Cert, ed25519Key := newEd25519TestCert(t)
+
+	cases := []struct {
+		name      string
+		conn      func() net.Conn
+		binding   string
+		scramPlus bool
+		mech      string
+		header    string
+		err       string
+	}{
+		{
+			name:      "prefer over TLS",
+			conn:      func() net.Conn { return newTLSTestConn(t, ecdsaCert, ecdsaKey) },
+			binding:   "prefer",
+			scramPlus: true,
+			mech:      scramSHA256Plus,
+			header:    "p=tls-server-end-point,,",
+		},
+		{
+			name:      "disable over TLS",
+			conn:      func() net.Conn { return newTLSTestConn(t, ecdsaCert, ecdsaKey) },
+			binding:   "disable",
+			scramPlus: true,
+			mech:      scramSHA256,
+			header:    "n,,",
+		},
+		{
+			name:    "prefer without PLUS",
+			conn:    func() net.Conn { return newTLSTestConn(t, ecdsaCert, ecdsaKey) },
+			binding: "prefer",
+			mech:    scramSHA256,
+			header:  "y,,",
+		},
+		{
+			name:    "disable without PLUS",
+			conn:    func() net.Conn { return newTLSTestConn(t, ecdsaCert, ecdsaKey) },
+			binding: "disable",
+			mech:    scramSHA256,
+			header:  "n,,",
+		},
+		{
+			name:      "prefer without TLS",
+			conn:      newPlainTestConn,
+			binding:   "prefer",
+			scramPlus: true,
+			mech:      scramSHA256,
+			header:    "n,,",
+		},
+		{
+			name:      "prefer with unsupported certificate",
+			conn:      func() net.Conn { return newTLSTestConn(t, ed25519Cert, ed25519Key) },
+			binding:   "prefer",
+			scramPlus: true,
+			mech:      scramSHA256,
+			header:    "n,,",
+		},
+		{
+			name:      "require with unsupported certificate",
+			conn:      func() net.Conn { return newTLSTestConn(t, ed25519Cert, ed25519Key) },
+			binding:   "require",
+			scramPlus: true,
+			err:       "pg: channel binding: unsupported signature algorithm: Ed25519",
+		},
+		{
+			name:      "require without TLS",
+			conn:      newPlainTestConn,
+			binding:   "require",
+			scramPlus: true,
+			err: "pg: channel binding is required, " +
+				"but server did not offer SCRAM-SHA-256-PLUS over TLS",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			netConn := c.conn()
+			defer netConn.Close()
+
+			db := &baseDB{opt: &Options{ChannelBinding: c.binding}}
+			mech, client, err := db.newSCRAMClient(pool.NewConn(netConn), "user", "pencil", true, c.scramPlus)
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.mech, mech)
+
+			resp, err := client.Step(nil)
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(string(resp), c.header), string(resp))
+		})
+	}
+}
+
+func TestSCRAMPlusClientInvalidNonce(t *testing.T) {
+	cert, key := newTestCert(t, nil, nil)
+	netConn := newTLSTestConn(t, cert, key)
+	defer netConn.Close()
+
+	cb, err := tlsServerEndPoint(netConn)
+	assert.NoError(t, err)
+
+	client, err := newSCRAMPlusClient("user", "pencil", cb)
+	assert.NoError(t, err)
+
+	_, err = client.Step(nil)
+	assert.NoError(t, err)
+
+	_, err = client.Step([]byte("r=foo,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"))
+	assert.EqualError(t, err, "pg: SASL: server nonce did not extend client nonce")
+	assert.Error(t, client.Verify())
+}
+
+func TestSASLClientNoServerChannelBinding(t *testing.T) {
+	cert, key := newTestCert(t, nil, nil)
+	netConn := newTLSTestConn(t, cert, key)
+	defer netConn.Close()
+
+	state := netConn.(*tls.Conn).ConnectionState()
+	client := newSASLClient("user", "pencil", &state)
+
+	resp, err := client.Step(nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(resp), "y,,n=user,r="), string(resp))
+
+	nonce := strings.TrimPrefix(string(resp), "y,,n=user,r=")
+	resp, err = client.Step([]byte("r=" + nonce + "server,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"))
+	assert.NoError(t, err)
+	// c= is the base64 of the gs2 header "y,," without channel binding data.
+	assert.True(t, strings.HasPrefix(string(resp), "c=eSws,r="+nonce+"server,p="), string(resp))
+}
+
+// newTLSTestConn returns the client side of a TLS connection
+// to a server that uses the certificate.
+func newTLSTestConn(t *testing.T, cert *x509.Certificate, key crypto.PrivateKey) net.Conn {
+	clientConn, serverConn := net.Pipe()
+
+	server := tls.Server(serverConn, &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{cert.Raw}, PrivateKey: key}},
+	})
+	go func() {
+		_, _ = io.Copy(io.Discard, server)
+	}()
+	t.Cleanup(func() { server.Close() })
+
+	client := tls.Client(clientConn, &tls.Config{InsecureSkipVerify: true})
+	if err := client.Handshake(); err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func newPlainTestConn() net.Conn {
+	clientConn, serverConn := net.Pipe()
+	serverConn.Close()
+	return clientConn
+}
+
+func newEd25519TestCert(t *testing.T) (*x509.Certificate, ed25519.PrivateKey) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, pub, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
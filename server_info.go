@@ -0,0 +1,87 @@
+package pg
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/go-pg/pg/v10/internal/pool"
+)
+
+// ServerInfo describes the server and the backend process of a connection
+// as reported by the server using ParameterStatus and BackendKeyData
+// messages.
+type ServerInfo struct {
+	// ProcessID is the process ID of the backend serving the connection.
+	ProcessID int32
+
+	// ServerVersion is the server version, e.g. "16.2".
+	ServerVersion string
+	// ServerVersionNum is the server version as a number, e.g. 160002,
+	// or 0 if the version can't be parsed.
+	ServerVersionNum int
+
+	TimeZone                  string
+	IntegerDatetimes          bool
+	StandardConformingStrings bool
+	// InHotStandby reports whether the server is a hot standby.
+	// It is only reported by PostgreSQL 14 and later.
+	InHotStandby bool
+
+	// Params contains all run-time parameters reported by the server.
+	Params map[string]string
+}
+
+func newServerInfo(cn *pool.Conn) *ServerInfo {
+	params := cn.ParameterStatuses()
+	return &ServerInfo{
+		ProcessID:                 cn.ProcessID,
+		ServerVersion:             params["server_version"],
+		ServerVersionNum:          parseServerVersion(params["server_version"]),
+		TimeZone:                  params["TimeZone"],
+		IntegerDatetimes:          params["integer_datetimes"] == "on",
+		StandardConformingStrings: params["standard_conforming_strings"] == "on",
+		InHotStandby:              params["in_hot_standby"] == "on",
+		Params:                    params,
+	}
+}
+
+// parseServerVersion converts the server_version, e.g. "9.6.24" or
+// "16.2 (Debian 16.2-1.pgdg120+2)", into the server_version_num format.
+func parseServerVersion(version string) int {
+	if i := strings.IndexAny(version, " ("); i != -1 {
+		version = version[:i]
+	}
+	// Development versions, e.g. 17beta1.
+	if i := strings.IndexFunc(version, func(r rune) bool {
+		return r != '.' && (r < '0' || r > '9')
+	}); i != -1 {
+		version = version[:i]
+	}
+
+	parts := strings.Split(version, ".")
+	nums := make([]int, 3)
+	for i := 0; i < len(parts) && i < len(nums); i++ {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return 0
+		}
+		nums[i] = n
+	}
+
+	if nums[0] >= 10 {
+		return nums[0]*10000 + nums[1]
+	}
+	return nums[0]*10000 + nums[1]*100 + nums[2]
+}
+
+// ServerInfo returns information about the server and the backend
+// process of a connection from the pool.
+func (db *baseDB) ServerInfo(ctx context.Context) (*ServerInfo, error) {
+	var info *ServerInfo
+	err := db.withConn(ctx, func(ctx context.Context, cn *pool.Conn) error {
+		info = newServerInfo(cn)
+		return nil
+	})
+	return info, err
+}
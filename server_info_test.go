@@ -0,0 +1,24 @@
+package pg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseServerVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		wanted  int
+	}{
+		{"9.6.24", 90624},
+		{"10.23", 100023},
+		{"16.2 (Debian 16.2-1.pgdg120+2)", 160002},
+		{"17beta1", 170000},
+		{"", 0},
+		{"foo", 0},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.wanted, parseServerVersion(test.version), test.version)
+	}
+}
@@ -0,0 +1,179 @@
+package pg
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// setServiceParams adds the params of the connection service selected
+// by the service param or PGSERVICE environment variable. Params that
+// are already set take precedence over the ones from the service file.
+// See https://www.postgresql.org/docs/current/libpq-pgservice.html.
+func setServiceParams(params map[string]string) error {
+	service, ok := params["service"]
+	delete(params, "service")
+	if !ok {
+		service = os.Getenv("PGSERVICE")
+	}
+	if service == "" {
+		return nil
+	}
+
+	serviceParams, err := lookupService(service)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range serviceParams {
+		if key == "service" {
+			return fmt.Errorf("pg: nested service specifications are not supported in service file")
+		}
+		if _, ok := params[key]; !ok {
+			params[key] = value
+		}
+	}
+	return nil
+}
+
+// setServiceDefaults sets the options that are not set explicitly from
+// the connection service selected by the PGSERVICE environment variable.
+func (opt *Options) setServiceDefaults() error {
+	params := make(map[string]string)
+	if err := setServiceParams(params); err != nil {
+		return err
+	}
+	if len(params) == 0 {
+		return nil
+	}
+
+	service, err := newOptions(params)
+	if err != nil {
+		return err
+	}
+
+	if opt.Addr == "" && service.Addr != "" && (opt.Network == "" || opt.Network == service.Network) {
+		opt.Network = service.Network
+		opt.Addr = service.Addr
+	}
+	if opt.User == "" {
+		opt.User = service.User
+	}
+	if opt.Password == "" {
+		opt.Password = service.Password
+	}
+	if opt.Database == "" {
+		opt.Database = service.Database
+	}
+	if opt.passFile == "" {
+		opt.passFile = service.passFile
+	}
+	if opt.TLSConfig == nil && opt.SSLMode == "" {
+		opt.TLSConfig = service.TLSConfig
+		opt.SSLMode = service.SSLMode
+		opt.SSLNegotiation = service.SSLNegotiation
+	}
+	if opt.ApplicationName == "" {
+		opt.ApplicationName = service.ApplicationName
+	}
+	if opt.TargetSessionAttrs == "" {
+		opt.TargetSessionAttrs = service.TargetSessionAttrs
+	}
+	if opt.ChannelBinding == "" {
+		opt.ChannelBinding = service.ChannelBinding
+	}
+	if opt.DialTimeout == 0 {
+		opt.DialTimeout = service.DialTimeout
+	}
+	for key, value := range service.RuntimeParams {
+		if _, ok := opt.RuntimeParams[key]; ok {
+			continue
+		}
+		if opt.RuntimeParams == nil {
+			opt.RuntimeParams = make(map[string]string)
+		}
+		opt.RuntimeParams[key] = value
+	}
+	return nil
+}
+
+// lookupService looks up the service in the user's service file
+// (PGSERVICEFILE, default ~/.pg_service.conf) and then in the
+// system-wide PGSYSCONFDIR/pg_service.conf.
+func lookupService(service string) (map[string]string, error) {
+	var files []string
+	if file := os.Getenv("PGSERVICEFILE"); file != "" {
+		files = append(files, file)
+	} else if file := userConfigFile(".pg_service.conf", ".pg_service.conf"); file != "" {
+		files = append(files, file)
+	}
+	if dir := os.Getenv("PGSYSCONFDIR"); dir != "" {
+		files = append(files, filepath.Join(dir, "pg_service.conf"))
+	}
+
+	for _, file := range files {
+		params, err := readServiceFile(file, service)
+		if err != nil {
+			return nil, err
+		}
+		if params != nil {
+			return params, nil
+		}
+	}
+	return nil, fmt.Errorf("pg: definition of service %q not found", service)
+}
+
+// readServiceFile returns the params of the service or nil
+// if the file does not exist or does not define the service.
+func readServiceFile(file, service string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var params map[string]string
+	var inService bool
+
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if inService {
+				break
+			}
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("pg: syntax error in service file %q, line %d", file, lineNum)
+			}
+			inService = line[1:len(line)-1] == service
+			if inService {
+				params = make(map[string]string)
+			}
+			continue
+		}
+
+		if !inService {
+			continue
+		}
+
+		i := strings.IndexByte(line, '=')
+		if i == -1 {
+			return nil, fmt.Errorf("pg: syntax error in service file %q, line %d", file, lineNum)
+		}
+		params[strings.TrimSpace(line[:i])] = strings.TrimSpace(line[i+1:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
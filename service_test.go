@@ -0,0 +1,124 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseURLService(t *testing.T) {
+	dir := t.TempDir()
+
+	userFile := filepath.Join(dir, "user_service.conf")
+	err := os.WriteFile(userFile, []byte(`# user services
+[mydb]
+host=db.example.com
+port = 5433
+dbname=mydb
+user=vasya
+sslmode=disable
+
+[other]
+dbname=other
+`), 0o600)
+	assert.NoError(t, err)
+
+	err = os.WriteFile(filepath.Join(dir, "pg_service.conf"), []byte(`[sysdb]
+host=sys.example.com
+dbname=sysdb
+`), 0o600)
+	assert.NoError(t, err)
+
+	t.Setenv("PGSERVICEFILE", userFile)
+	t.Setenv("PGSYSCONFDIR", dir)
+	t.Setenv("PGSERVICE", "")
+
+	opt, err := ParseURL("service=mydb user=petya")
+	assert.NoError(t, err)
+	assert.Equal(t, "db.example.com:5433", opt.Addr)
+	assert.Equal(t, "mydb", opt.Database)
+	assert.Equal(t, "petya", opt.User)
+	assert.Nil(t, opt.TLSConfig)
+
+	opt, err = ParseURL("postgres://localhost/db?service=mydb")
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost:5432", opt.Addr)
+	assert.Equal(t, "db", opt.Database)
+	assert.Equal(t, "vasya", opt.User)
+
+	opt, err = ParseURL("service=sysdb")
+	assert.NoError(t, err)
+	assert.Equal(t, "sys.example.com:5432", opt.Addr)
+	assert.Equal(t, "sysdb", opt.Database)
+
+	t.Setenv("PGSERVICE", "other")
+	opt, err = ParseURL("host=localhost")
+	assert.NoError(t, err)
+	assert.Equal(t, "other", opt.Database)
+
+	_, err = ParseURL("service=missing")
+	assert.EqualError(t, err, `pg: definition of service "missing" not found`)
+}
+
+func TestOptionsInitService(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "pg_service.conf")
+	err := os.WriteFile(file, []byte(`[mydb]
+host=db.example.com
+port=5433
+dbname=mydb
+user=vasya
+password=secret
+application_name=myapp
+options=-c search_path=app
+`), 0o600)
+	assert.NoError(t, err)
+
+	t.Setenv("PGSERVICEFILE", file)
+	t.Setenv("PGSERVICE", "mydb")
+	t.Setenv("PGHOST", "")
+	t.Setenv("PGPORT", "")
+	t.Setenv("PGUSER", "")
+	t.Setenv("PGPASSWORD", "")
+	t.Setenv("PGDATABASE", "")
+	t.Setenv("PGAPPNAME", "")
+
+	opt := &Options{User: "petya"}
+	opt.init()
+	assert.Equal(t, "tcp", opt.Network)
+	assert.Equal(t, "db.example.com:5433", opt.Addr)
+	assert.Equal(t, "mydb", opt.Database)
+	assert.Equal(t, "petya", opt.User)
+	assert.Equal(t, "secret", opt.Password)
+	assert.Equal(t, "myapp", opt.ApplicationName)
+	assert.Equal(t, map[string]string{"options": "-c search_path=app"}, opt.RuntimeParams)
+
+	// ParseURL resolves the service itself.
+	opt, err = ParseURL("service=mydb dbname=other")
+	assert.NoError(t, err)
+	t.Setenv("PGSERVICE", "missing")
+	opt.init()
+	assert.Equal(t, "other", opt.Database)
+
+	assert.NoError(t, opt.initErr)
+
+	// Like libpq, an unknown service is an error instead of connecting
+	// with the defaults.
+	opt = &Options{Addr: "localhost:5432"}
+	opt.init()
+	assert.Equal(t, "localhost:5432", opt.Addr)
+	assert.Equal(t, "postgres", opt.Database)
+	_, err = opt.getDialer()(context.Background())
+	assert.EqualError(t, err, `pg: definition of service "missing" not found`)
+
+	err = os.WriteFile(file, []byte("[mydb\nhost=db.example.com\n"), 0o600)
+	assert.NoError(t, err)
+	t.Setenv("PGSERVICE", "mydb")
+	opt = new(Options)
+	opt.init()
+	_, err = opt.getDialer()(context.Background())
+	assert.EqualError(t, err, fmt.Sprintf("pg: syntax error in service file %q, line 1", file))
+}
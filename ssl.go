@@ -0,0 +1,306 @@
+package pg
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// errSSLNotSupported is returned by enableSSL when the server refuses
+// the SSL request.
+var errSSLNotSupported = errors.New("pg: SSL is not enabled on the server")
+
+// sslParams are the libpq connection params that configure TLS.
+// Missing params are read from the corresponding environment variables.
+type sslParams struct {
+	mode        string
+	rootCert    string
+	cert        string
+	key         string
+	crl         string
+	negotiation string
+}
+
+func newSSLParams(params map[string]string) sslParams {
+	get := func(key, envKey string) string {
+		if value, ok := params[key]; ok {
+			return value
+		}
+		return os.Getenv(envKey)
+	}
+	return sslParams{
+		mode:        get("sslmode", "PGSSLMODE"),
+		rootCert:    get("sslrootcert", "PGSSLROOTCERT"),
+		cert:        get("sslcert", "PGSSLCERT"),
+		key:         get("sslkey", "PGSSLKEY"),
+		crl:         get("sslcrl", "PGSSLCRL"),
+		negotiation: get("sslnegotiation", "PGSSLNEGOTIATION"),
+	}
+}
+
+// setSSL sets SSLMode, SSLNegotiation and TLSConfig from the params
+// following libpq semantics. Default sslmode is prefer.
+func (opt *Options) setSSL(p sslParams) error {
+	if p.mode == "" {
+		// Like libpq, sslrootcert=system changes the default to verify-full.
+		if p.rootCert == "system" {
+			p.mode = "verify-full"
+		} else {
+			p.mode = "prefer"
+		}
+	}
+
+	switch p.negotiation {
+	case "", "postgres":
+	case "direct":
+		switch p.mode {
+		case "require", "verify-ca", "verify-full":
+		default:
+			return fmt.Errorf("pg: sslmode '%v' may not be used with sslnegotiation=direct "+
+				"(use 'require', 'verify-ca', or 'verify-full')", p.mode)
+		}
+	default:
+		return fmt.Errorf("pg: sslnegotiation '%v' is not supported", p.negotiation)
+	}
+
+	switch p.mode {
+	case "disable":
+		opt.SSLMode = p.mode
+		opt.SSLNegotiation = ""
+		opt.TLSConfig = nil
+		return nil
+	case "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		return fmt.Errorf("pg: sslmode '%v' is not supported", p.mode)
+	}
+
+	tlsConfig, err := newTLSConfig(p)
+	if err != nil {
+		return err
+	}
+
+	opt.SSLMode = p.mode
+	opt.SSLNegotiation = p.negotiation
+	opt.TLSConfig = tlsConfig
+	return nil
+}
+
+func newTLSConfig(p sslParams) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: true} //nolint
+
+	rootCert := p.rootCert
+	if rootCert == "system" && p.mode != "verify-full" {
+		return nil, fmt.Errorf(
+			"pg: weak sslmode '%v' may not be used with sslrootcert=system (use 'verify-full')", p.mode)
+	}
+	if rootCert == "" {
+		rootCert = defaultSSLFile("root.crt")
+	}
+
+	verify := p.mode == "verify-ca" || p.mode == "verify-full"
+	// Like libpq, require verifies the server certificate
+	// when a root CA file is provided.
+	if p.mode == "require" && rootCert != "" {
+		verify = true
+	}
+
+	if verify {
+		// With sslrootcert=system the system roots are used.
+		var roots *x509.CertPool
+		switch rootCert {
+		case "":
+			return nil, fmt.Errorf("pg: root certificate file %q does not exist; "+
+				"either provide the file, use the system's trusted roots with sslrootcert=system, "+
+				"or change sslmode to disable server certificate verification", sslFilePath("root.crt"))
+		case "system":
+		default:
+			b, err := os.ReadFile(rootCert)
+			if err != nil {
+				return nil, fmt.Errorf("pg: could not read root certificate file: %w", err)
+			}
+			roots = x509.NewCertPool()
+			if !roots.AppendCertsFromPEM(b) {
+				return nil, fmt.Errorf("pg: no certificates found in root certificate file %q", rootCert)
+			}
+		}
+
+		var revoked map[string]struct{}
+		if p.crl != "" {
+			var err error
+			revoked, err = loadCRL(p.crl)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if p.mode == "verify-full" {
+			// ServerName is set to the host name when connecting.
+			tlsConfig.InsecureSkipVerify = false
+			tlsConfig.RootCAs = roots
+			if revoked != nil {
+				tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+					return checkRevoked(rawCerts, revoked)
+				}
+			}
+		} else {
+			tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+				if err := verifyCertChain(rawCerts, roots); err != nil {
+					return err
+				}
+				return checkRevoked(rawCerts, revoked)
+			}
+		}
+	}
+
+	certFile, keyFile := p.cert, p.key
+	if certFile == "" {
+		certFile = defaultSSLFile("postgresql.crt")
+	}
+	if keyFile == "" {
+		keyFile = defaultSSLFile("postgresql.key")
+	}
+	if certFile != "" {
+		if keyFile == "" {
+			return nil, errors.New("pg: certificate present, but not private key file")
+		}
+		if err := checkKeyFilePerm(keyFile); err != nil {
+			return nil, err
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("pg: could not load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// defaultSSLFile returns the path of the file in ~/.postgresql,
+// or %APPDATA%\postgresql on Windows, if it exists.
+func defaultSSLFile(name string) string {
+	file := sslFilePath(name)
+	if file == "" {
+		return ""
+	}
+	if _, err := os.Stat(file); err != nil {
+		return ""
+	}
+	return file
+}
+
+// sslFilePath returns the path of the file in ~/.postgresql,
+// or %APPDATA%\postgresql on Windows.
+func sslFilePath(name string) string {
+	var dir string
+	if runtime.GOOS == "windows" {
+		dir = os.Getenv("APPDATA")
+	} else {
+		dir, _ = os.UserHomeDir()
+	}
+	if dir == "" {
+		return ""
+	}
+
+	dirName := ".postgresql"
+	if runtime.GOOS == "windows" {
+		dirName = "postgresql"
+	}
+	return filepath.Join(dir, dirName, name)
+}
+
+// checkKeyFilePerm rejects private key files that are accessible by
+// others or writable by the group.
+func checkKeyFilePerm(file string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	fi, err := os.Stat(file)
+	if err != nil {
+		return fmt.Errorf("pg: could not open private key file: %w", err)
+	}
+	if fi.Mode().Perm()&0o037 != 0 {
+		return fmt.Errorf(
+			"pg: private key file %q has group or world access; "+
+				"file must have permissions u=rw (0600) or less if owned by the current user, "+
+				"or permissions u=rw,g=r (0640) or less if owned by root", file)
+	}
+	return nil
+}
+
+// verifyCertChain verifies that the server certificate chains to one of
+// the roots without checking the host name as done by sslmode=verify-ca.
+func verifyCertChain(rawCerts [][]byte, roots *x509.CertPool) error {
+	if len(rawCerts) == 0 {
+		return errors.New("pg: server did not send a certificate")
+	}
+
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		certs[i] = cert
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+// loadCRL returns the set of revoked certificates keyed by
+// the issuer and serial number.
+func loadCRL(file string) (map[string]struct{}, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("pg: could not read certificate revocation list: %w", err)
+	}
+	if block, _ := pem.Decode(b); block != nil {
+		b = block.Bytes
+	}
+
+	crl, err := x509.ParseRevocationList(b)
+	if err != nil {
+		return nil, fmt.Errorf("pg: could not parse certificate revocation list: %w", err)
+	}
+
+	revoked := make(map[string]struct{}, len(crl.RevokedCertificates))
+	for _, cert := range crl.RevokedCertificates {
+		revoked[revokedKey(crl.RawIssuer, cert.SerialNumber)] = struct{}{}
+	}
+	return revoked, nil
+}
+
+func revokedKey(issuer []byte, serial *big.Int) string {
+	return string(issuer) + ":" + serial.String()
+}
+
+func checkRevoked(rawCerts [][]byte, revoked map[string]struct{}) error {
+	if len(revoked) == 0 {
+		return nil
+	}
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		if _, ok := revoked[revokedKey(cert.RawIssuer, cert.SerialNumber)]; ok {
+			return fmt.Errorf("pg: server certificate %s is revoked", cert.Subject)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package pg
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseURLSSLMode(t *testing.T) {
+	t.Setenv("PGSSLMODE", "")
+	t.Setenv("PGSSLROOTCERT", "")
+	setTestRootCert(t)
+
+	cases := []struct {
+		url                string
+		mode               string
+		tls                bool
+		insecureSkipVerify bool
+	}{
+		{"postgres://h1/db", "prefer", true, true},
+		{"postgres://h1/db?sslmode=disable", "disable", false, false},
+		{"postgres://h1/db?sslmode=allow", "allow", true, true},
+		{"postgres://h1/db?sslmode=prefer", "prefer", true, true},
+		{"postgres://h1/db?sslmode=require", "require", true, true},
+		{"postgres://h1/db?sslmode=verify-ca", "verify-ca", true, true},
+		{"postgres://h1/db?sslmode=verify-full", "verify-full", true, false},
+		{"host=h1 sslmode=require sslnegotiation=direct", "require", true, true},
+	}
+	for _, c := range cases {
+		opt, err := ParseURL(c.url)
+		assert.NoError(t, err, c.url)
+		assert.Equal(t, c.mode, opt.SSLMode, c.url)
+		if !c.tls {
+			assert.Nil(t, opt.TLSConfig, c.url)
+			continue
+		}
+		assert.Equal(t, c.insecureSkipVerify, opt.TLSConfig.InsecureSkipVerify, c.url)
+	}
+
+	_, err := ParseURL("host=h1 sslmode=prefer sslnegotiation=direct")
+	assert.EqualError(t, err, "pg: sslmode 'prefer' may not be used with sslnegotiation=direct "+
+		"(use 'require', 'verify-ca', or 'verify-full')")
+
+	_, err = ParseURL("host=h1 sslnegotiation=indirect")
+	assert.EqualError(t, err, "pg: sslnegotiation 'indirect' is not supported")
+}
+
+func TestParseURLSSLRootCert(t *testing.T) {
+	t.Setenv("PGSSLMODE", "")
+	t.Setenv("PGSSLROOTCERT", "")
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	for _, mode := range []string{"verify-ca", "verify-full"} {
+		_, err := ParseURL("host=h1 sslmode=" + mode)
+		assert.EqualError(t, err, "pg: root certificate file "+
+			`"`+filepath.Join(home, ".postgresql", "root.crt")+`" does not exist; `+
+			"either provide the file, use the system's trusted roots with sslrootcert=system, "+
+			"or change sslmode to disable server certificate verification")
+	}
+
+	for _, mode := range []string{"prefer", "require", "verify-ca"} {
+		_, err := ParseURL("host=h1 sslrootcert=system sslmode=" + mode)
+		assert.EqualError(t, err, "pg: weak sslmode '"+mode+"' may not be used "+
+			"with sslrootcert=system (use 'verify-full')")
+	}
+
+	opt, err := ParseURL("host=h1 sslrootcert=system")
+	assert.NoError(t, err)
+	assert.Equal(t, "verify-full", opt.SSLMode)
+	assert.False(t, opt.TLSConfig.InsecureSkipVerify)
+	assert.Nil(t, opt.TLSConfig.RootCAs)
+
+	opt, err = ParseURL("host=h1 sslmode=require")
+	assert.NoError(t, err)
+	assert.True(t, opt.TLSConfig.InsecureSkipVerify)
+	assert.Nil(t, opt.TLSConfig.VerifyPeerCertificate)
+}
+
+func TestOptionsInitSSLMode(t *testing.T) {
+	t.Setenv("PGSSLMODE", "")
+	t.Setenv("PGSSLROOTCERT", "")
+	t.Setenv("PGSERVICE", "")
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	rootCertErr := "pg: root certificate file " +
+		`"` + filepath.Join(home, ".postgresql", "root.crt") + `" does not exist; ` +
+		"either provide the file, use the system's trusted roots with sslrootcert=system, " +
+		"or change sslmode to disable server certificate verification"
+
+	dial := func(opt *Options) error {
+		opt.Dialer = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			t.Fatalf("dialed %s", addr)
+			return nil, nil
+		}
+		opt.init()
+		_, err := opt.getDialer()(context.Background())
+		return err
+	}
+
+	t.Setenv("PGSSLMODE", "verify-full")
+	assert.EqualError(t, dial(new(Options)), rootCertErr)
+	t.Setenv("PGSSLMODE", "")
+
+	assert.EqualError(t, dial(&Options{SSLMode: "verify-ca"}), rootCertErr)
+	assert.EqualError(t, dial(&Options{SSLMode: "verify-fulll"}),
+		"pg: sslmode 'verify-fulll' is not supported")
+	assert.EqualError(t, dial(&Options{SSLMode: "prefer", SSLNegotiation: "direct"}),
+		"pg: sslmode 'prefer' may not be used with sslnegotiation=direct "+
+			"(use 'require', 'verify-ca', or 'verify-full')")
+
+	opt := &Options{SSLMode: "require"}
+	opt.init()
+	assert.NoError(t, opt.initErr)
+	assert.NotNil(t, opt.TLSConfig)
+}
+
+func TestParseURLSSLFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+
+	caCert, caKey := newTestCert(t, nil, nil)
+	clientCert, clientKey := newTestCert(t, caCert, caKey)
+
+	rootFile := writePEM(t, dir, "root.crt", "CERTIFICATE", caCert.Raw, 0o600)
+	certFile := writePEM(t, dir, "client.crt", "CERTIFICATE", clientCert.Raw, 0o600)
+	keyDER, err := x509.MarshalECPrivateKey(clientKey)
+	assert.NoError(t, err)
+	keyFile := writePEM(t, dir, "client.key", "EC PRIVATE KEY", keyDER, 0o600)
+
+	opt, err := ParseURL("postgres://h1/db?sslmode=verify-ca&sslrootcert=" + rootFile +
+		"&sslcert=" + certFile + "&sslkey=" + keyFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "verify-ca", opt.SSLMode)
+	assert.Len(t, opt.TLSConfig.Certificates, 1)
+
+	// verify-ca checks the chain but not the host name.
+	verify := opt.TLSConfig.VerifyPeerCertificate
+	assert.NoError(t, verify([][]byte{clientCert.Raw}, nil))
+	otherCert, _ := newTestCert(t, nil, nil)
+	assert.Error(t, verify([][]byte{otherCert.Raw}, nil))
+
+	// require verifies the server certificate when a root CA file is provided.
+	opt, err = ParseURL("host=h1 sslmode=require sslrootcert=" + rootFile)
+	assert.NoError(t, err)
+	assert.NotNil(t, opt.TLSConfig.VerifyPeerCertificate)
+
+	opt, err = ParseURL("host=h1 sslmode=verify-full sslrootcert=" + rootFile)
+	assert.NoError(t, err)
+	assert.False(t, opt.TLSConfig.InsecureSkipVerify)
+	assert.NotNil(t, opt.TLSConfig.RootCAs)
+
+	assert.NoError(t, os.Chmod(keyFile, 0o644))
+	_, err = ParseURL("host=h1 sslcert=" + certFile + " sslkey=" + keyFile)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "has group or world access")
+}
+
+func TestSSLModePrefer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	go serveWithoutSSL(ln)
+
+	opt := &Options{
+		Addr:      ln.Addr().String(),
+		SSLMode:   "prefer",
+		TLSConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+	db := Connect(opt)
+	defer db.Close()
+
+	cn, err := db.getConn(context.Background())
+	assert.NoError(t, err)
+	db.releaseConn(context.Background(), cn, nil)
+
+	opt = &Options{
+		Addr:      ln.Addr().String(),
+		SSLMode:   "require",
+		TLSConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+	db2 := Connect(opt)
+	defer db2.Close()
+
+	_, err = db2.getConn(context.Background())
+	assert.Equal(t, errSSLNotSupported, err)
+}
+
+// serveWithoutSSL refuses SSL requests and accepts any startup message.
+func serveWithoutSSL(ln net.Listener) {
+	for {
+		cn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go func(cn net.Conn) {
+			defer cn.Close()
+			for {
+				var hdr [8]byte
+				if _, err := io.ReadFull(cn, hdr[:]); err != nil {
+					return
+				}
+				size := binary.BigEndian.Uint32(hdr[:4])
+				if binary.BigEndian.Uint32(hdr[4:]) == 80877103 { // SSLRequest
+					if _, err := cn.Write([]byte{'N'}); err != nil {
+						return
+					}
+					continue
+				}
+				if _, err := io.CopyN(io.Discard, cn, int64(size-8)); err != nil {
+					return
+				}
+				// AuthenticationOk and ReadyForQuery.
+				_, _ = cn.Write([]byte{'R', 0, 0, 0, 8, 0, 0, 0, 0, 'Z', 0, 0, 0, 5, 'I'})
+				_, _ = io.Copy(io.Discard, cn)
+				return
+			}
+		}(cn)
+	}
+}
+
+func newTestCert(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		parent, parentKey = tmpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+// setTestRootCert sets HOME to a directory with ~/.postgresql/root.crt,
+// which is required by verify-ca and verify-full.
+func setTestRootCert(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, ".postgresql")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	caCert, _ := newTestCert(t, nil, nil)
+	writePEM(t, dir, "root.crt", "CERTIFICATE", caCert.Raw, 0o600)
+}
+
+func writePEM(t *testing.T, dir, name, typ string, der []byte, perm os.FileMode) string {
+	file := filepath.Join(dir, name)
+	b := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	if err := os.WriteFile(file, b, perm); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
@@ -228,15 +228,18 @@ func (stmt *Stmt) Close() error {
 
 func (stmt *Stmt) extQuery(
 	c context.Context, cn *pool.Conn, name string, params ...interface{},
-) (Result, error) {
-	err := cn.WithWriter(c, stmt.db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
-		return writeBindExecuteMsg(wb, name, params...)
+) (res Result, err error) {
+	defer func() {
+		attachNotices(c, res, err)
+	}()
+
+	err = cn.WithWriter(c, stmt.db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
+		return writeBindExecuteMsg(wb, name, stmt.columns, params...)
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	var res Result
 	err = cn.WithReader(c, stmt.db.opt.ReadTimeout, func(rd *pool.ReaderContext) error {
 		res, err = readExtQuery(rd)
 		return err
@@ -255,15 +258,19 @@ func (stmt *Stmt) extQueryData(
 	model interface{},
 	columns []types.ColumnInfo,
 	params ...interface{},
-) (Result, error) {
-	err := cn.WithWriter(c, stmt.db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
-		return writeBindExecuteMsg(wb, name, params...)
+) (_ Result, err error) {
+	var res *result
+	defer func() {
+		attachNotices(c, res, err)
+	}()
+
+	err = cn.WithWriter(c, stmt.db.opt.WriteTimeout, func(wb *pool.WriteBuffer) error {
+		return writeBindExecuteMsg(wb, name, stmt.columns, params...)
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	var res *result
 	err = cn.WithReader(c, stmt.db.opt.ReadTimeout, func(rd *pool.ReaderContext) error {
 		res, err = readExtQueryData(c, rd, model, columns)
 		return err
@@ -3,7 +3,9 @@ package pg
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"strconv"
 	"sync"
 	"sync/atomic"
 
@@ -16,6 +18,73 @@ import (
 // that has already been committed or rolled back.
 var ErrTxDone = errors.New("pg: transaction has already been committed or rolled back")
 
+// ErrTxRolledBack is returned by Commit when the server rolls back
+// the transaction instead, because a statement in it failed.
+var ErrTxRolledBack = errors.New("pg: transaction was aborted by an earlier error and rolled back")
+
+// IsolationLevel is the transaction isolation level.
+type IsolationLevel int
+
+// Isolation levels supported by PostgreSQL. DefaultIsolation uses
+// the default_transaction_isolation setting.
+const (
+	DefaultIsolation IsolationLevel = iota
+	ReadUncommitted
+	ReadCommitted
+	RepeatableRead
+	Serializable
+)
+
+func (l IsolationLevel) String() string {
+	switch l {
+	case ReadUncommitted:
+		return "READ UNCOMMITTED"
+	case ReadCommitted:
+		return "READ COMMITTED"
+	case RepeatableRead:
+		return "REPEATABLE READ"
+	case Serializable:
+		return "SERIALIZABLE"
+	default:
+		return "DEFAULT"
+	}
+}
+
+// TxOptions holds the transaction modes used by BeginTx.
+type TxOptions struct {
+	Isolation IsolationLevel
+	// ReadOnly starts a READ ONLY transaction.
+	ReadOnly bool
+	// Deferrable starts a DEFERRABLE transaction, which only has an effect
+	// for SERIALIZABLE READ ONLY transactions: they wait for a snapshot
+	// that can't cause serialization failures.
+	Deferrable bool
+}
+
+func (opt *TxOptions) beginQuery() string {
+	if opt == nil {
+		return "BEGIN"
+	}
+
+	b := []byte("BEGIN")
+	sep := " "
+	if opt.Isolation != DefaultIsolation {
+		b = append(b, " ISOLATION LEVEL "...)
+		b = append(b, opt.Isolation.String()...)
+		sep = ", "
+	}
+	if opt.ReadOnly {
+		b = append(b, sep...)
+		b = append(b, "READ ONLY"...)
+		sep = ", "
+	}
+	if opt.Deferrable {
+		b = append(b, sep...)
+		b = append(b, "DEFERRABLE"...)
+	}
+	return string(b)
+}
+
 // Tx is an in-progress database transaction. It is safe for concurrent use
 // by multiple goroutines.
 //
@@ -26,6 +95,10 @@ var ErrTxDone = errors.New("pg: transaction has already been committed or rolled
 //
 // The statements prepared for a transaction by calling the transaction's
 // Prepare or Stmt methods are closed by the call to Commit or Rollback.
+//
+// Transactions started with Tx.Begin are nested transactions backed by
+// savepoints: Commit releases the savepoint and Rollback rolls back
+// to the savepoint leaving the outer transaction usable.
 type Tx struct {
 	db  *baseDB
 	ctx context.Context
@@ -33,6 +106,16 @@ type Tx struct {
 	stmtsMu sync.Mutex
 	stmts   []*Stmt
 
+	// parent and savepoint are set for nested transactions.
+	parent       *Tx
+	savepoint    string
+	savepointSeq uint32
+
+	callbacksMu  sync.Mutex
+	beforeCommit []func(context.Context) error
+	onCommit     []func(context.Context)
+	onRollback   []func(context.Context, error)
+
 	_closed int32
 }
 
@@ -49,12 +132,22 @@ func (db *baseDB) Begin() (*Tx, error) {
 }
 
 func (db *baseDB) BeginContext(ctx context.Context) (*Tx, error) {
+	return db.BeginTx(ctx, nil)
+}
+
+// BeginTx starts a transaction with the options. Nil options start
+// a transaction like Begin.
+func (db *baseDB) BeginTx(ctx context.Context, opt *TxOptions) (*Tx, error) {
+	if opt != nil && (opt.Isolation < DefaultIsolation || opt.Isolation > Serializable) {
+		return nil, fmt.Errorf("pg: unknown isolation level %d", opt.Isolation)
+	}
+
 	tx := &Tx{
 		db:  db.withPool(pool.NewStickyConnPool(db.pool)),
 		ctx: ctx,
 	}
 
-	err := tx.begin(ctx)
+	err := tx.begin(ctx, opt.beginQuery())
 	if err != nil {
 		tx.close()
 		return nil, err
@@ -65,27 +158,102 @@ func (db *baseDB) BeginContext(ctx context.Context) (*Tx, error) {
 
 // RunInTransaction runs a function in a transaction. If function
 // returns an error transaction is rolled back, otherwise transaction
-// is committed.
+// is committed. Serialization failures are retried like
+// RunInTransactionTx does.
 func (db *baseDB) RunInTransaction(ctx context.Context, fn func(*Tx) error) error {
-	tx, err := db.BeginContext(ctx)
-	if err != nil {
-		return err
+	return db.RunInTransactionTx(ctx, nil, fn)
+}
+
+// RunInTransactionTx is like RunInTransaction, but starts the transaction
+// with the options like BeginTx.
+//
+// Functions that fail with one of Options.TxRetryCodes, e.g. because
+// of serialization failures, are rerun on a fresh transaction up to
+// Options.MaxTxRetries times, so they must be safe to run again.
+func (db *baseDB) RunInTransactionTx(ctx context.Context, opt *TxOptions, fn func(*Tx) error) error {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			if err := internal.Sleep(ctx, db.retryBackoff(attempt-1)); err != nil {
+				return err
+			}
+		}
+
+		tx, err := db.BeginTx(ctx, opt)
+		if err != nil {
+			return err
+		}
+
+		err = tx.run(ctx, fn)
+		if attempt >= db.opt.MaxTxRetries || !db.shouldRetryTx(err) {
+			return err
+		}
 	}
-	return tx.RunInTransaction(ctx, fn)
 }
 
-// Begin returns current transaction. It does not start new transaction.
+// shouldRetryTx reports whether the transaction failed with one
+// of Options.TxRetryCodes.
+func (db *baseDB) shouldRetryTx(err error) bool {
+	var pgErr Error
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	code := pgErr.Field('C')
+	for _, retryCode := range db.opt.TxRetryCodes {
+		if code == retryCode {
+			return true
+		}
+	}
+	return false
+}
+
+// Begin starts a nested transaction with SAVEPOINT.
 func (tx *Tx) Begin() (*Tx, error) {
-	return tx, nil
+	return tx.BeginContext(tx.ctx)
 }
 
-// RunInTransaction runs a function in the transaction. If function
-// returns an error transaction is rolled back, otherwise transaction
-// is committed.
+// BeginContext acts like Begin but additionally receives a context.
+func (tx *Tx) BeginContext(ctx context.Context) (*Tx, error) {
+	if tx.closed() {
+		return nil, ErrTxDone
+	}
+
+	root := tx
+	for root.parent != nil {
+		root = root.parent
+	}
+	seq := atomic.AddUint32(&root.savepointSeq, 1)
+
+	nested := &Tx{
+		db:        tx.db,
+		ctx:       ctx,
+		parent:    tx,
+		savepoint: "pg_savepoint_" + strconv.FormatUint(uint64(seq), 10),
+	}
+	if err := tx.SavepointContext(ctx, nested.savepoint); err != nil {
+		return nil, err
+	}
+	return nested, nil
+}
+
+// RunInTransaction runs a function in a nested transaction backed by
+// a savepoint. If function returns an error the nested transaction is
+// rolled back to the savepoint, otherwise the savepoint is released.
 func (tx *Tx) RunInTransaction(ctx context.Context, fn func(*Tx) error) error {
+	nested, err := tx.BeginContext(ctx)
+	if err != nil {
+		return err
+	}
+	return nested.run(ctx, fn)
+}
+
+// run runs a function in the transaction. If function returns an error
+// transaction is rolled back, otherwise transaction is committed.
+func (tx *Tx) run(ctx context.Context, fn func(*Tx) error) error {
 	defer func() {
 		if err := recover(); err != nil {
-			if err := tx.RollbackContext(ctx); err != nil {
+			cause := fmt.Errorf("pg: panic in transaction: %v", err)
+			if err := tx.rollback(ctx, cause); err != nil {
 				internal.Logger.Printf(ctx, "tx.Rollback panicked: %s", err)
 			}
 			panic(err)
@@ -93,7 +261,7 @@ func (tx *Tx) RunInTransaction(ctx context.Context, fn func(*Tx) error) error {
 	}()
 
 	if err := fn(tx); err != nil {
-		if err := tx.RollbackContext(ctx); err != nil {
+		if err := tx.rollback(ctx, err); err != nil {
 			internal.Logger.Printf(ctx, "tx.Rollback failed: %s", err)
 		}
 		return err
@@ -102,6 +270,10 @@ func (tx *Tx) RunInTransaction(ctx context.Context, fn func(*Tx) error) error {
 }
 
 func (tx *Tx) withConn(c context.Context, fn func(context.Context, *pool.Conn) error) error {
+	if tx.parent != nil && tx.closed() {
+		// Nested transactions share the connection with the parent.
+		return ErrTxDone
+	}
 	err := tx.db.withConn(c, fn)
 	if tx.closed() && err == pool.ErrClosed {
 		return ErrTxDone
@@ -153,18 +325,24 @@ func (tx *Tx) exec(ctx context.Context, query interface{}, params ...interface{}
 	wb := pool.GetWriteBuffer()
 	defer pool.PutWriteBuffer(wb)
 
-	if err := writeQueryMsg(wb, tx.db.fmter, query, params...); err != nil {
+	bindParams := tx.db.bindsParams(query, params)
+	fmtedQuery, args, err := tx.db.writeQuery(wb, bindParams, query, params...)
+	if err != nil {
 		return nil, err
 	}
 
-	ctx, evt, err := tx.db.beforeQuery(ctx, tx, nil, query, params, wb.Query())
+	ctx, evt, err := tx.db.beforeQuery(ctx, tx, nil, query, params, fmtedQuery)
 	if err != nil {
 		return nil, err
 	}
 
 	var res Result
 	lastErr := tx.withConn(ctx, func(ctx context.Context, cn *pool.Conn) error {
-		res, err = tx.db.simpleQuery(ctx, cn, wb)
+		if bindParams {
+			res, err = tx.db.extQuery(ctx, cn, fmtedQuery, args)
+		} else {
+			res, err = tx.db.simpleQuery(ctx, cn, wb)
+		}
 		return err
 	})
 
@@ -220,18 +398,24 @@ func (tx *Tx) query(
 	wb := pool.GetWriteBuffer()
 	defer pool.PutWriteBuffer(wb)
 
-	if err := writeQueryMsg(wb, tx.db.fmter, query, params...); err != nil {
+	bindParams := tx.db.bindsParams(query, params)
+	fmtedQuery, args, err := tx.db.writeQuery(wb, bindParams, query, params...)
+	if err != nil {
 		return nil, err
 	}
 
-	ctx, evt, err := tx.db.beforeQuery(ctx, tx, model, query, params, wb.Query())
+	ctx, evt, err := tx.db.beforeQuery(ctx, tx, model, query, params, fmtedQuery)
 	if err != nil {
 		return nil, err
 	}
 
 	var res *result
 	lastErr := tx.withConn(ctx, func(ctx context.Context, cn *pool.Conn) error {
-		res, err = tx.db.simpleQueryData(ctx, cn, model, wb)
+		if bindParams {
+			res, err = tx.db.extQueryData(ctx, cn, model, fmtedQuery, args)
+		} else {
+			res, err = tx.db.simpleQueryData(ctx, cn, model, wb)
+		}
 		return err
 	})
 
@@ -311,7 +495,7 @@ func (tx *Tx) Formatter() orm.QueryFormatter {
 	return tx.db.Formatter()
 }
 
-func (tx *Tx) begin(ctx context.Context) error {
+func (tx *Tx) begin(ctx context.Context, query string) error {
 	var lastErr error
 	for attempt := 0; attempt <= tx.db.opt.MaxRetries; attempt++ {
 		if attempt > 0 {
@@ -325,7 +509,7 @@ func (tx *Tx) begin(ctx context.Context) error {
 			}
 		}
 
-		_, lastErr = tx.ExecContext(ctx, "BEGIN")
+		_, lastErr = tx.ExecContext(ctx, query)
 		if !tx.db.shouldRetry(lastErr) {
 			break
 		}
@@ -337,21 +521,212 @@ func (tx *Tx) Commit() error {
 	return tx.CommitContext(tx.ctx)
 }
 
-// Commit commits the transaction.
+// Commit commits the transaction. Nested transactions release the savepoint.
+//
+// BeforeCommit callbacks run before COMMIT and an error from them rolls
+// back the transaction. OnCommit callbacks run after COMMIT succeeds,
+// and OnRollback callbacks run if it fails. When a statement in
+// the transaction failed, the server rolls it back on COMMIT and
+// ErrTxRolledBack is returned.
 func (tx *Tx) CommitContext(ctx context.Context) error {
-	_, err := tx.ExecContext(internal.UndoContext(ctx), "COMMIT")
+	if tx.parent != nil {
+		if tx.closed() {
+			return ErrTxDone
+		}
+		err := tx.parent.ReleaseSavepointContext(ctx, tx.savepoint)
+		if err != nil {
+			tx.close()
+			tx.runOnRollback(ctx, err)
+			return err
+		}
+		tx.close()
+		// Callbacks run when the outermost transaction ends.
+		tx.parent.inheritCallbacks(tx)
+		return nil
+	}
+
+	if tx.closed() {
+		return ErrTxDone
+	}
+
+	for _, fn := range tx.beforeCommitCallbacks() {
+		if err := fn(ctx); err != nil {
+			if rollbackErr := tx.rollback(ctx, err); rollbackErr != nil {
+				internal.Logger.Printf(ctx, "tx.Rollback failed: %s", rollbackErr)
+			}
+			return err
+		}
+	}
+
+	res, err := tx.ExecContext(internal.UndoContext(ctx), "COMMIT")
 	tx.close()
-	return err
+	if err == nil && isRollback(res) {
+		err = ErrTxRolledBack
+	}
+	if err != nil {
+		tx.runOnRollback(ctx, err)
+		return err
+	}
+	tx.runOnCommit(ctx)
+	return nil
+}
+
+// isRollback reports whether the command rolled back the transaction,
+// which COMMIT does without an error when the transaction is aborted.
+func isRollback(res Result) bool {
+	r, ok := res.(*result)
+	return ok && r.command == "ROLLBACK"
 }
 
 func (tx *Tx) Rollback() error {
 	return tx.RollbackContext(tx.ctx)
 }
 
-// Rollback aborts the transaction.
+// Rollback aborts the transaction. Nested transactions roll back
+// to the savepoint and release it. OnRollback callbacks run with nil error.
 func (tx *Tx) RollbackContext(ctx context.Context) error {
+	return tx.rollback(ctx, nil)
+}
+
+// rollback aborts the transaction and runs OnRollback callbacks
+// with the error that caused the rollback.
+func (tx *Tx) rollback(ctx context.Context, cause error) error {
+	if tx.parent != nil {
+		if tx.closed() {
+			return ErrTxDone
+		}
+		err := tx.parent.RollbackToContext(ctx, tx.savepoint)
+		if err == nil {
+			err = tx.parent.ReleaseSavepointContext(ctx, tx.savepoint)
+		}
+		tx.close()
+		tx.runOnRollback(ctx, cause)
+		return err
+	}
+
+	if tx.closed() {
+		return ErrTxDone
+	}
+
 	_, err := tx.ExecContext(internal.UndoContext(ctx), "ROLLBACK")
 	tx.close()
+	tx.runOnRollback(ctx, cause)
+	return err
+}
+
+// BeforeCommit registers a function that is called before the transaction
+// is committed. The function can run queries in the transaction, and
+// an error aborts the commit and rolls back the transaction.
+//
+// Functions registered on a nested transaction are called before
+// the outermost transaction is committed if the savepoint is released.
+// Functions are called in the registration order.
+func (tx *Tx) BeforeCommit(fn func(ctx context.Context) error) {
+	tx.callbacksMu.Lock()
+	tx.beforeCommit = append(tx.beforeCommit, fn)
+	tx.callbacksMu.Unlock()
+}
+
+// OnCommit registers a function that is called after the transaction
+// is committed. Functions registered on a nested transaction are called
+// only if the outermost transaction is committed.
+// Functions are called in the registration order.
+func (tx *Tx) OnCommit(fn func(ctx context.Context)) {
+	tx.callbacksMu.Lock()
+	tx.onCommit = append(tx.onCommit, fn)
+	tx.callbacksMu.Unlock()
+}
+
+// OnRollback registers a function that is called after the transaction
+// is rolled back, including failed commits. The error is the reason
+// of the rollback, e.g. the error returned by the RunInTransaction
+// function or by COMMIT, and is nil for explicit Rollback calls.
+//
+// Functions registered on a nested transaction are called when
+// it is rolled back to the savepoint or when the outermost transaction
+// is rolled back. Functions are called in the registration order.
+func (tx *Tx) OnRollback(fn func(ctx context.Context, err error)) {
+	tx.callbacksMu.Lock()
+	tx.onRollback = append(tx.onRollback, fn)
+	tx.callbacksMu.Unlock()
+}
+
+func (tx *Tx) beforeCommitCallbacks() []func(context.Context) error {
+	tx.callbacksMu.Lock()
+	defer tx.callbacksMu.Unlock()
+	return tx.beforeCommit
+}
+
+// inheritCallbacks appends the callbacks of the released nested transaction.
+func (tx *Tx) inheritCallbacks(nested *Tx) {
+	nested.callbacksMu.Lock()
+	beforeCommit, onCommit, onRollback := nested.beforeCommit, nested.onCommit, nested.onRollback
+	nested.beforeCommit, nested.onCommit, nested.onRollback = nil, nil, nil
+	nested.callbacksMu.Unlock()
+
+	tx.callbacksMu.Lock()
+	tx.beforeCommit = append(tx.beforeCommit, beforeCommit...)
+	tx.onCommit = append(tx.onCommit, onCommit...)
+	tx.onRollback = append(tx.onRollback, onRollback...)
+	tx.callbacksMu.Unlock()
+}
+
+func (tx *Tx) runOnCommit(ctx context.Context) {
+	tx.callbacksMu.Lock()
+	callbacks := tx.onCommit
+	tx.beforeCommit, tx.onCommit, tx.onRollback = nil, nil, nil
+	tx.callbacksMu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(ctx)
+	}
+}
+
+func (tx *Tx) runOnRollback(ctx context.Context, err error) {
+	tx.callbacksMu.Lock()
+	callbacks := tx.onRollback
+	tx.beforeCommit, tx.onCommit, tx.onRollback = nil, nil, nil
+	tx.callbacksMu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(ctx, err)
+	}
+}
+
+// Savepoint establishes a new savepoint with the name within
+// the transaction.
+func (tx *Tx) Savepoint(name string) error {
+	return tx.SavepointContext(tx.ctx, name)
+}
+
+// SavepointContext acts like Savepoint but additionally receives a context.
+func (tx *Tx) SavepointContext(ctx context.Context, name string) error {
+	_, err := tx.ExecContext(ctx, "SAVEPOINT ?", Ident(name))
+	return err
+}
+
+// RollbackTo rolls back all commands executed after the savepoint
+// was established. The savepoint remains valid.
+func (tx *Tx) RollbackTo(name string) error {
+	return tx.RollbackToContext(tx.ctx, name)
+}
+
+// RollbackToContext acts like RollbackTo but additionally receives a context.
+func (tx *Tx) RollbackToContext(ctx context.Context, name string) error {
+	_, err := tx.ExecContext(internal.UndoContext(ctx), "ROLLBACK TO SAVEPOINT ?", Ident(name))
+	return err
+}
+
+// ReleaseSavepoint destroys the savepoint keeping the effects of commands
+// executed after it was established.
+func (tx *Tx) ReleaseSavepoint(name string) error {
+	return tx.ReleaseSavepointContext(tx.ctx, name)
+}
+
+// ReleaseSavepointContext acts like ReleaseSavepoint but additionally
+// receives a context.
+func (tx *Tx) ReleaseSavepointContext(ctx context.Context, name string) error {
+	_, err := tx.ExecContext(internal.UndoContext(ctx), "RELEASE SAVEPOINT ?", Ident(name))
 	return err
 }
 
@@ -380,7 +755,9 @@ func (tx *Tx) close() {
 	}
 	tx.stmts = nil
 
-	_ = tx.db.Close()
+	if tx.parent == nil {
+		_ = tx.db.Close()
+	}
 }
 
 func (tx *Tx) closed() bool {
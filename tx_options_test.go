@@ -0,0 +1,62 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-pg/pg/v10/internal"
+)
+
+func TestTxOptionsBeginQuery(t *testing.T) {
+	cases := []struct {
+		opt   *TxOptions
+		query string
+	}{
+		{nil, "BEGIN"},
+		{&TxOptions{}, "BEGIN"},
+		{&TxOptions{Isolation: RepeatableRead}, "BEGIN ISOLATION LEVEL REPEATABLE READ"},
+		{&TxOptions{ReadOnly: true}, "BEGIN READ ONLY"},
+		{&TxOptions{Deferrable: true}, "BEGIN DEFERRABLE"},
+		{
+			&TxOptions{Isolation: Serializable, ReadOnly: true, Deferrable: true},
+			"BEGIN ISOLATION LEVEL SERIALIZABLE, READ ONLY, DEFERRABLE",
+		},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.query, c.opt.beginQuery())
+	}
+}
+
+func TestBeginTxUnknownIsolation(t *testing.T) {
+	db := Connect(&Options{})
+	defer db.Close()
+
+	_, err := db.BeginTx(context.Background(), &TxOptions{Isolation: Serializable + 1})
+	assert.EqualError(t, err, "pg: unknown isolation level 5")
+}
+
+func TestShouldRetryTx(t *testing.T) {
+	db := Connect(&Options{})
+	defer db.Close()
+
+	serializationFailure := internal.NewPGError(map[byte]string{'C': "40001"})
+	deadlock := internal.NewPGError(map[byte]string{'C': "40P01"})
+	uniqueViolation := internal.NewPGError(map[byte]string{'C': "23505"})
+
+	assert.True(t, db.shouldRetryTx(serializationFailure))
+	assert.True(t, db.shouldRetryTx(deadlock))
+	assert.True(t, db.shouldRetryTx(fmt.Errorf("transfer failed: %w", serializationFailure)))
+	assert.False(t, db.shouldRetryTx(uniqueViolation))
+	assert.False(t, db.shouldRetryTx(errors.New("40001")))
+	assert.False(t, db.shouldRetryTx(nil))
+
+	db = Connect(&Options{TxRetryCodes: []string{"23505"}})
+	defer db.Close()
+
+	assert.True(t, db.shouldRetryTx(uniqueViolation))
+	assert.False(t, db.shouldRetryTx(serializationFailure))
+}
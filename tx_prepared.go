@@ -0,0 +1,112 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-pg/pg/v10/internal"
+)
+
+var errEmptyGID = errors.New("pg: transaction identifier (gid) is empty")
+
+// PreparedTx is a transaction prepared for two-phase commit
+// as listed in pg_prepared_xacts.
+type PreparedTx struct {
+	// Transaction is the numeric transaction identifier.
+	Transaction uint32 `pg:"transaction"`
+	// GID is the global transaction identifier passed to Prepare2PC.
+	GID      string    `pg:"gid"`
+	Prepared time.Time `pg:"prepared"`
+	Owner    string    `pg:"owner"`
+	Database string    `pg:"database"`
+}
+
+// Prepare2PC prepares the transaction for two-phase commit with
+// PREPARE TRANSACTION. The transaction is dissociated from the session
+// and the Tx is ended, so it must be committed or rolled back later
+// with CommitPrepared or RollbackPrepared using the same gid,
+// possibly from another connection.
+//
+// BeforeCommit callbacks run before the transaction is prepared.
+// OnCommit and OnRollback callbacks are discarded when the transaction
+// is prepared, because the outcome is decided later, and OnRollback
+// callbacks run if it can't be prepared. ErrTxRolledBack is returned when
+// a statement in the transaction failed. The server must be configured
+// with max_prepared_transactions > 0.
+func (tx *Tx) Prepare2PC(ctx context.Context, gid string) error {
+	if tx.parent != nil {
+		return errors.New("pg: Prepare2PC is not supported for nested transactions")
+	}
+	if gid == "" {
+		return errEmptyGID
+	}
+	if tx.closed() {
+		return ErrTxDone
+	}
+
+	for _, fn := range tx.beforeCommitCallbacks() {
+		if err := fn(ctx); err != nil {
+			if rollbackErr := tx.rollback(ctx, err); rollbackErr != nil {
+				internal.Logger.Printf(ctx, "tx.Rollback failed: %s", rollbackErr)
+			}
+			return err
+		}
+	}
+
+	// PREPARE TRANSACTION ends the transaction block even if it fails,
+	// and in that case the transaction is rolled back. An aborted
+	// transaction is rolled back without an error.
+	res, err := tx.ExecContext(internal.UndoContext(ctx), "PREPARE TRANSACTION ?", gid)
+	tx.close()
+	if err == nil && isRollback(res) {
+		err = ErrTxRolledBack
+	}
+	if err != nil {
+		tx.runOnRollback(ctx, err)
+		return err
+	}
+
+	tx.callbacksMu.Lock()
+	tx.beforeCommit, tx.onCommit, tx.onRollback = nil, nil, nil
+	tx.callbacksMu.Unlock()
+	return nil
+}
+
+// CommitPrepared commits the transaction prepared with Prepare2PC
+// using COMMIT PREPARED.
+func (db *baseDB) CommitPrepared(ctx context.Context, gid string) error {
+	if gid == "" {
+		return errEmptyGID
+	}
+	_, err := db.ExecContext(ctx, "COMMIT PREPARED ?", gid)
+	return err
+}
+
+// RollbackPrepared rolls back the transaction prepared with Prepare2PC
+// using ROLLBACK PREPARED.
+func (db *baseDB) RollbackPrepared(ctx context.Context, gid string) error {
+	if gid == "" {
+		return errEmptyGID
+	}
+	_, err := db.ExecContext(ctx, "ROLLBACK PREPARED ?", gid)
+	return err
+}
+
+// ListPrepared returns the transactions that are prepared for two-phase
+// commit in the current database ordered by the preparation time.
+// A coordinator uses it to commit or roll back transactions left
+// after a crash.
+func (db *baseDB) ListPrepared(ctx context.Context) ([]PreparedTx, error) {
+	var txs []PreparedTx
+	_, err := db.QueryContext(ctx, &txs, `
+		SELECT transaction, gid, prepared, owner, database
+		FROM pg_prepared_xacts
+		WHERE database = current_database()
+		ORDER BY prepared, gid
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
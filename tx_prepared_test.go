@@ -0,0 +1,22 @@
+package pg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrepare2PCValidation(t *testing.T) {
+	ctx := context.Background()
+
+	tx := &Tx{ctx: ctx}
+	assert.Equal(t, errEmptyGID, tx.Prepare2PC(ctx, ""))
+
+	nested := &Tx{ctx: ctx, parent: tx, savepoint: "pg_savepoint_1"}
+	assert.EqualError(t, nested.Prepare2PC(ctx, "gid"),
+		"pg: Prepare2PC is not supported for nested transactions")
+
+	tx._closed = 1
+	assert.Equal(t, ErrTxDone, tx.Prepare2PC(ctx, "gid"))
+}
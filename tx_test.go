@@ -2,6 +2,7 @@ package pg_test
 
 import (
 	"context"
+	"errors"
 	"strings"
 
 	. "github.com/onsi/ginkgo"
@@ -160,4 +161,364 @@ var _ = Describe("Tx", func() {
 		_, err := db.Exec("select 1")
 		Expect(err).NotTo(HaveOccurred())
 	})
+
+	It("supports transaction options", func() {
+		tx, err := db.BeginTx(ctx, &pg.TxOptions{
+			Isolation:  pg.Serializable,
+			ReadOnly:   true,
+			Deferrable: true,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		var isolation, readOnly, deferrable string
+		_, err = tx.QueryOne(pg.Scan(&isolation, &readOnly, &deferrable), `
+			SELECT current_setting('transaction_isolation'),
+				current_setting('transaction_read_only'),
+				current_setting('transaction_deferrable')`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(isolation).To(Equal("serializable"))
+		Expect(readOnly).To(Equal("on"))
+		Expect(deferrable).To(Equal("on"))
+
+		Expect(tx.Rollback()).NotTo(HaveOccurred())
+	})
+
+	It("runs function in read only transaction", func() {
+		err := db.RunInTransactionTx(ctx, &pg.TxOptions{ReadOnly: true}, func(tx *pg.Tx) error {
+			_, err := tx.Exec("CREATE TEMP TABLE test_read_only (id int)")
+			return err
+		})
+		Expect(err).To(MatchError(ContainSubstring("read-only transaction")))
+	})
+
+	It("retries serialization failures", func() {
+		opt := pgOptions()
+		opt.MaxTxRetries = 2
+		opt.MinRetryBackoff = -1
+		db := pg.Connect(opt)
+		defer db.Close()
+
+		serializationFailure := `
+			DO $$ BEGIN
+				RAISE EXCEPTION 'could not serialize access' USING ERRCODE = '40001';
+			END $$`
+
+		var attempts int
+		err := db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+			attempts++
+			if attempts < 3 {
+				_, err := tx.Exec(serializationFailure)
+				return err
+			}
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(attempts).To(Equal(3))
+
+		attempts = 0
+		err = db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+			attempts++
+			_, err := tx.Exec(serializationFailure)
+			return err
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(err.(pg.Error).Field('C')).To(Equal("40001"))
+		Expect(attempts).To(Equal(3))
+	})
+
+	Describe("nested transactions", func() {
+		var tx *pg.Tx
+
+		count := func() int {
+			var n int
+			_, err := tx.QueryOne(pg.Scan(&n), "SELECT count(*) FROM test_nested_tx")
+			Expect(err).NotTo(HaveOccurred())
+			return n
+		}
+
+		BeforeEach(func() {
+			var err error
+			tx, err = db.Begin()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = tx.Exec("CREATE TEMP TABLE test_nested_tx (id int)")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			Expect(tx.Rollback()).NotTo(HaveOccurred())
+		})
+
+		It("rolls back to savepoint on error", func() {
+			err := tx.RunInTransaction(ctx, func(tx *pg.Tx) error {
+				_, err := tx.Exec("INSERT INTO test_nested_tx VALUES (1)")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = tx.Exec("invalid statement")
+				return err
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(count()).To(Equal(0))
+
+			err = tx.RunInTransaction(ctx, func(tx *pg.Tx) error {
+				_, err := tx.Exec("INSERT INTO test_nested_tx VALUES (2)")
+				return err
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(count()).To(Equal(1))
+		})
+
+		It("supports nesting with Begin", func() {
+			nested1, err := tx.Begin()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = nested1.Exec("INSERT INTO test_nested_tx VALUES (1)")
+			Expect(err).NotTo(HaveOccurred())
+
+			nested2, err := nested1.Begin()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = nested2.Exec("INSERT INTO test_nested_tx VALUES (2)")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(nested2.Rollback()).NotTo(HaveOccurred())
+			Expect(nested1.Commit()).NotTo(HaveOccurred())
+			Expect(count()).To(Equal(1))
+
+			_, err = nested1.Exec("SELECT 1")
+			Expect(err).To(Equal(pg.ErrTxDone))
+			Expect(nested1.Commit()).To(Equal(pg.ErrTxDone))
+		})
+
+		It("supports explicit savepoints", func() {
+			Expect(tx.Savepoint("before_insert")).NotTo(HaveOccurred())
+
+			_, err := tx.Exec("INSERT INTO test_nested_tx VALUES (1)")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(tx.RollbackTo("before_insert")).NotTo(HaveOccurred())
+			Expect(count()).To(Equal(0))
+
+			Expect(tx.ReleaseSavepoint("before_insert")).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("callbacks", func() {
+		var events []string
+
+		record := func(event string) func(context.Context) {
+			return func(context.Context) {
+				events = append(events, event)
+			}
+		}
+
+		recordRollback := func(event string) func(context.Context, error) {
+			return func(_ context.Context, err error) {
+				if err != nil {
+					event += ": " + err.Error()
+				}
+				events = append(events, event)
+			}
+		}
+
+		BeforeEach(func() {
+			events = nil
+		})
+
+		It("runs OnCommit callbacks in registration order", func() {
+			err := db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+				tx.BeforeCommit(func(c context.Context) error {
+					_, err := tx.ExecContext(c, "SELECT 1")
+					events = append(events, "before commit")
+					return err
+				})
+				tx.OnCommit(record("commit 1"))
+				tx.OnCommit(record("commit 2"))
+				tx.OnRollback(recordRollback("rollback"))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(Equal([]string{"before commit", "commit 1", "commit 2"}))
+		})
+
+		It("runs OnRollback callbacks with the error", func() {
+			err := db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+				tx.OnCommit(record("commit"))
+				tx.OnRollback(recordRollback("rollback"))
+				return errors.New("fail")
+			})
+			Expect(err).To(MatchError("fail"))
+			Expect(events).To(Equal([]string{"rollback: fail"}))
+		})
+
+		It("runs OnRollback callbacks when COMMIT rolls back an aborted transaction", func() {
+			err := db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+				tx.OnCommit(record("commit"))
+				tx.OnRollback(recordRollback("rollback"))
+
+				// The error is swallowed, but the transaction is aborted.
+				_, err := tx.Exec("SELECT 1/0")
+				Expect(err).To(HaveOccurred())
+				return nil
+			})
+			Expect(err).To(Equal(pg.ErrTxRolledBack))
+			Expect(events).To(Equal([]string{"rollback: " + pg.ErrTxRolledBack.Error()}))
+		})
+
+		It("rolls back when BeforeCommit fails", func() {
+			err := db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+				tx.BeforeCommit(func(context.Context) error {
+					return errors.New("validation failed")
+				})
+				tx.BeforeCommit(func(context.Context) error {
+					events = append(events, "not called")
+					return nil
+				})
+				tx.OnCommit(record("commit"))
+				tx.OnRollback(recordRollback("rollback"))
+				return nil
+			})
+			Expect(err).To(MatchError("validation failed"))
+			Expect(events).To(Equal([]string{"rollback: validation failed"}))
+		})
+
+		It("runs callbacks of nested transactions with the outermost transaction", func() {
+			err := db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+				tx.OnCommit(record("outer commit"))
+
+				err := tx.RunInTransaction(ctx, func(nested *pg.Tx) error {
+					nested.OnCommit(record("released commit"))
+					return nil
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(events).To(BeEmpty())
+
+				err = tx.RunInTransaction(ctx, func(nested *pg.Tx) error {
+					nested.OnCommit(record("rolled back commit"))
+					nested.OnRollback(recordRollback("nested rollback"))
+					return errors.New("fail")
+				})
+				Expect(err).To(MatchError("fail"))
+				Expect(events).To(Equal([]string{"nested rollback: fail"}))
+
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(Equal([]string{
+				"nested rollback: fail",
+				"outer commit",
+				"released commit",
+			}))
+		})
+
+		It("runs OnRollback callbacks of released savepoints on rollback", func() {
+			tx, err := db.Begin()
+			Expect(err).NotTo(HaveOccurred())
+
+			nested, err := tx.Begin()
+			Expect(err).NotTo(HaveOccurred())
+			nested.OnCommit(record("commit"))
+			nested.OnRollback(recordRollback("rollback"))
+			Expect(nested.Commit()).NotTo(HaveOccurred())
+
+			Expect(tx.Rollback()).NotTo(HaveOccurred())
+			Expect(events).To(Equal([]string{"rollback"}))
+		})
+	})
+
+	Describe("two-phase commit", func() {
+		BeforeEach(func() {
+			var max int
+			_, err := db.QueryOne(pg.Scan(&max), "SHOW max_prepared_transactions")
+			Expect(err).NotTo(HaveOccurred())
+			if max == 0 {
+				Skip("max_prepared_transactions is 0")
+			}
+
+			_, err = db.Exec("CREATE TABLE IF NOT EXISTS test_2pc (id int)")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = db.Exec("TRUNCATE test_2pc")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			_, err := db.Exec("DROP TABLE IF EXISTS test_2pc")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		count := func() int {
+			var n int
+			_, err := db.QueryOne(pg.Scan(&n), "SELECT count(*) FROM test_2pc")
+			Expect(err).NotTo(HaveOccurred())
+			return n
+		}
+
+		prepare := func(gid string) {
+			tx, err := db.Begin()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = tx.Exec("INSERT INTO test_2pc VALUES (1)")
+			Expect(err).NotTo(HaveOccurred())
+
+			var committed bool
+			tx.OnCommit(func(context.Context) { committed = true })
+
+			Expect(tx.Prepare2PC(ctx, gid)).NotTo(HaveOccurred())
+			Expect(committed).To(BeFalse())
+
+			_, err = tx.Exec("SELECT 1")
+			Expect(err).To(Equal(pg.ErrTxDone))
+		}
+
+		It("commits prepared transaction", func() {
+			prepare("test_2pc_commit")
+			Expect(count()).To(Equal(0))
+
+			txs, err := db.ListPrepared(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(txs).To(HaveLen(1))
+			Expect(txs[0].GID).To(Equal("test_2pc_commit"))
+			Expect(txs[0].Transaction).NotTo(BeZero())
+			Expect(txs[0].Prepared).NotTo(BeZero())
+
+			Expect(db.CommitPrepared(ctx, "test_2pc_commit")).NotTo(HaveOccurred())
+			Expect(count()).To(Equal(1))
+
+			txs, err = db.ListPrepared(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(txs).To(BeEmpty())
+		})
+
+		It("rolls back prepared transaction", func() {
+			prepare("test_2pc_rollback")
+
+			Expect(db.RollbackPrepared(ctx, "test_2pc_rollback")).NotTo(HaveOccurred())
+			Expect(count()).To(Equal(0))
+
+			err := db.CommitPrepared(ctx, "test_2pc_rollback")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("returns an error when preparing an aborted transaction", func() {
+			tx, err := db.Begin()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = tx.Exec("INSERT INTO test_2pc VALUES (1)")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = tx.Exec("SELECT 1/0")
+			Expect(err).To(HaveOccurred())
+
+			var rollbackErr error
+			tx.OnRollback(func(_ context.Context, err error) { rollbackErr = err })
+
+			err = tx.Prepare2PC(ctx, "test_2pc_aborted")
+			Expect(err).To(Equal(pg.ErrTxRolledBack))
+			Expect(rollbackErr).To(Equal(pg.ErrTxRolledBack))
+
+			txs, err := db.ListPrepared(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(txs).To(BeEmpty())
+			Expect(count()).To(Equal(0))
+		})
+	})
 })
@@ -0,0 +1,742 @@
+package types
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tmthrgd/go-hex"
+
+	"github.com/go-pg/pg/v10/internal/pool"
+)
+
+// Format codes used by PostgreSQL for parameters and result columns.
+const (
+	TextFormat   int16 = 0
+	BinaryFormat int16 = 1
+)
+
+const pgFloat4Array = 1021
+
+// pgEpoch is the PostgreSQL epoch (2000-01-01) used by binary timestamps.
+const pgEpoch = 946684800
+
+const secondsPerDay = 24 * 60 * 60
+
+// SupportsBinaryFormat reports whether values of the PostgreSQL type
+// can be decoded from the binary format.
+func SupportsBinaryFormat(dataType int32) bool {
+	switch dataType {
+	case pgBool,
+		pgInt2, pgInt4, pgInt8,
+		pgFloat4, pgFloat8,
+		pgBytea,
+		pgTimestamp, pgTimestamptz,
+		pgUUID,
+		pgInt32Array, pgInt8Array, pgFloat8Array, pgStringArray:
+		return true
+	default:
+		return false
+	}
+}
+
+// ParseBinaryBool decodes bool in the binary format.
+func ParseBinaryBool(b []byte) (bool, error) {
+	if len(b) != 1 {
+		return false, fmt.Errorf("pg: can't parse binary bool: %d bytes", len(b))
+	}
+	return b[0] != 0, nil
+}
+
+// ParseBinaryInt decodes int2, int4 or int8 in the binary format.
+func ParseBinaryInt(b []byte) (int64, error) {
+	switch len(b) {
+	case 2:
+		return int64(int16(binary.BigEndian.Uint16(b))), nil
+	case 4:
+		return int64(int32(binary.BigEndian.Uint32(b))), nil
+	case 8:
+		return int64(binary.BigEndian.Uint64(b)), nil
+	default:
+		return 0, fmt.Errorf("pg: can't parse binary int: %d bytes", len(b))
+	}
+}
+
+// ParseBinaryFloat decodes float4 or float8 in the binary format.
+func ParseBinaryFloat(b []byte) (float64, error) {
+	switch len(b) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	default:
+		return 0, fmt.Errorf("pg: can't parse binary float: %d bytes", len(b))
+	}
+}
+
+// ParseBinaryTime decodes timestamp or timestamptz in the binary format.
+// The returned time is in UTC. Unlike the text format, the binary format
+// does not include the offset of the session time zone, so timestamptz
+// values scanned from prepared statements are the same instants as the
+// ones parsed by ParseTime, but their location is UTC instead of the
+// session time zone.
+func ParseBinaryTime(b []byte) (time.Time, error) {
+	if len(b) != 8 {
+		return time.Time{}, fmt.Errorf("pg: can't parse binary timestamp: %d bytes", len(b))
+	}
+	usec := int64(binary.BigEndian.Uint64(b))
+	switch usec {
+	case math.MaxInt64, math.MinInt64:
+		return time.Time{}, fmt.Errorf("pg: can't parse infinite timestamp")
+	}
+	return time.Unix(pgEpoch+usec/1e6, (usec%1e6)*1e3).UTC(), nil
+}
+
+// ParseBinaryDate decodes date in the binary format.
+// The returned time is in UTC.
+func ParseBinaryDate(b []byte) (time.Time, error) {
+	if len(b) != 4 {
+		return time.Time{}, fmt.Errorf("pg: can't parse binary date: %d bytes", len(b))
+	}
+	days := int32(binary.BigEndian.Uint32(b))
+	switch days {
+	case math.MaxInt32, math.MinInt32:
+		return time.Time{}, fmt.Errorf("pg: can't parse infinite date")
+	}
+	return time.Unix(pgEpoch+int64(days)*secondsPerDay, 0).UTC(), nil
+}
+
+// ParseBinaryUUID decodes uuid in the binary format.
+func ParseBinaryUUID(b []byte) ([16]byte, error) {
+	var uuid [16]byte
+	if len(b) != len(uuid) {
+		return uuid, fmt.Errorf("pg: can't parse binary uuid: %d bytes", len(b))
+	}
+	copy(uuid[:], b)
+	return uuid, nil
+}
+
+//------------------------------------------------------------------------------
+
+type binaryArray struct {
+	elemType int32
+	dims     []int
+	elems    [][]byte // nil elements are NULLs
+}
+
+func parseBinaryArray(b []byte) (*binaryArray, error) {
+	if len(b) < 12 {
+		return nil, fmt.Errorf("pg: can't parse binary array: %d bytes", len(b))
+	}
+
+	ndim := int(int32(binary.BigEndian.Uint32(b)))
+	arr := &binaryArray{
+		elemType: int32(binary.BigEndian.Uint32(b[8:])),
+	}
+	b = b[12:]
+
+	if ndim < 0 || len(b) < 8*ndim {
+		return nil, fmt.Errorf("pg: can't parse binary array: ndim=%d", ndim)
+	}
+
+	numElem := 0
+	if ndim > 0 {
+		numElem = 1
+	}
+	arr.dims = make([]int, ndim)
+	for i := range arr.dims {
+		dim := int(int32(binary.BigEndian.Uint32(b)))
+		arr.dims[i] = dim
+		numElem *= dim
+		b = b[8:] // Skip the dimension length and lower bound.
+	}
+
+	arr.elems = make([][]byte, 0, numElem)
+	for i := 0; i < numElem; i++ {
+		if len(b) < 4 {
+			return nil, fmt.Errorf("pg: can't parse binary array: unexpected end")
+		}
+		n := int(int32(binary.BigEndian.Uint32(b)))
+		b = b[4:]
+
+		if n == -1 {
+			arr.elems = append(arr.elems, nil)
+			continue
+		}
+		if n < 0 || len(b) < n {
+			return nil, fmt.Errorf("pg: can't parse binary array: unexpected end")
+		}
+		arr.elems = append(arr.elems, b[:n:n])
+		b = b[n:]
+	}
+
+	return arr, nil
+}
+
+func (arr *binaryArray) appendText(b []byte) ([]byte, error) {
+	if len(arr.dims) == 0 {
+		return append(b, "{}"...), nil
+	}
+	elems := arr.elems
+	return arr.appendDim(b, 0, &elems)
+}
+
+func (arr *binaryArray) appendDim(b []byte, dim int, elems *[][]byte) ([]byte, error) {
+	b = append(b, '{')
+	for i := 0; i < arr.dims[dim]; i++ {
+		if i > 0 {
+			b = append(b, ',')
+		}
+
+		if dim+1 < len(arr.dims) {
+			var err error
+			b, err = arr.appendDim(b, dim+1, elems)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		elem := (*elems)[0]
+		*elems = (*elems)[1:]
+
+		if elem == nil {
+			b = append(b, "NULL"...)
+			continue
+		}
+
+		start := len(b)
+		var err error
+		b, err = AppendBinaryAsText(b, arr.elemType, elem)
+		if err != nil {
+			return nil, err
+		}
+		b = quoteArrayElem(b, start)
+	}
+	return append(b, '}'), nil
+}
+
+// quoteArrayElem quotes the array element that starts at b[start:].
+func quoteArrayElem(b []byte, start int) []byte {
+	elem := make([]byte, len(b)-start)
+	copy(elem, b[start:])
+
+	b = append(b[:start], '"')
+	for _, c := range elem {
+		if c == '"' || c == '\\' {
+			b = append(b, '\\')
+		}
+		b = append(b, c)
+	}
+	return append(b, '"')
+}
+
+//------------------------------------------------------------------------------
+
+// AppendBinaryAsText converts a value of the PostgreSQL type in the binary format
+// to the text format used by PostgreSQL and appends it to b.
+func AppendBinaryAsText(b []byte, dataType int32, src []byte) ([]byte, error) {
+	switch dataType {
+	case pgBool:
+		flag, err := ParseBinaryBool(src)
+		if err != nil {
+			return nil, err
+		}
+		if flag {
+			return append(b, 't'), nil
+		}
+		return append(b, 'f'), nil
+	case pgInt2, pgInt4, pgInt8:
+		num, err := ParseBinaryInt(src)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.AppendInt(b, num, 10), nil
+	case pgFloat4, pgFloat8:
+		num, err := ParseBinaryFloat(src)
+		if err != nil {
+			return nil, err
+		}
+		return appendFloatText(b, num, len(src)*8), nil
+	case pgBytea:
+		b = append(b, `\x`...)
+		s := len(b)
+		b = append(b, make([]byte, hex.EncodedLen(len(src)))...)
+		hex.Encode(b[s:], src)
+		return b, nil
+	case pgTimestamp, pgTimestamptz:
+		if len(src) == 8 {
+			switch int64(binary.BigEndian.Uint64(src)) {
+			case math.MaxInt64:
+				return append(b, "infinity"...), nil
+			case math.MinInt64:
+				return append(b, "-infinity"...), nil
+			}
+		}
+		tm, err := ParseBinaryTime(src)
+		if err != nil {
+			return nil, err
+		}
+		if dataType == pgTimestamp {
+			return tm.AppendFormat(b, timestampFormat), nil
+		}
+		return tm.AppendFormat(b, timestamptzFormat2), nil
+	case pgUUID:
+		uuid, err := ParseBinaryUUID(src)
+		if err != nil {
+			return nil, err
+		}
+		return appendUUID(b, uuid), nil
+	case pgDate:
+		if len(src) == 4 {
+			switch int32(binary.BigEndian.Uint32(src)) {
+			case math.MaxInt32:
+				return append(b, "infinity"...), nil
+			case math.MinInt32:
+				return append(b, "-infinity"...), nil
+			}
+		}
+		tm, err := ParseBinaryDate(src)
+		if err != nil {
+			return nil, err
+		}
+		return tm.AppendFormat(b, dateFormat), nil
+	case pgInt2Array, pgInt32Array, pgInt8Array, pgFloat4Array, pgFloat8Array,
+		pgBoolArray, pgByteaArray, pgStringArray, pgVarcharArray, pgBpcharArray,
+		pgDateArray, pgTimestampArray, pgTimestamptzArray,
+		pgUUIDArray, pgJSONArray, pgJSONBArray:
+		arr, err := parseBinaryArray(src)
+		if err != nil {
+			return nil, err
+		}
+		return arr.appendText(b)
+	case pgText, pgVarchar, pgBpchar, pgJSON:
+		return append(b, src...), nil
+	case pgJSONB:
+		if len(src) == 0 || src[0] != 1 {
+			return nil, fmt.Errorf("pg: can't parse binary jsonb: unsupported version")
+		}
+		return append(b, src[1:]...), nil
+	default:
+		return nil, fmt.Errorf("pg: can't convert binary value of type=%d to text", dataType)
+	}
+}
+
+func appendFloatText(b []byte, num float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(num):
+		return append(b, "NaN"...)
+	case math.IsInf(num, 1):
+		return append(b, "Infinity"...)
+	case math.IsInf(num, -1):
+		return append(b, "-Infinity"...)
+	default:
+		return strconv.AppendFloat(b, num, 'g', -1, bitSize)
+	}
+}
+
+func appendUUID(b []byte, uuid [16]byte) []byte {
+	var buf [36]byte
+	hex.Encode(buf[0:8], uuid[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], uuid[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], uuid[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], uuid[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], uuid[10:])
+	return append(b, buf[:]...)
+}
+
+// ReadBinaryAsText reads a column value in the binary format and returns
+// a reader over its text representation together with the new length.
+func ReadBinaryAsText(col ColumnInfo, rd Reader, n int) (Reader, int, error) {
+	if n == -1 {
+		return rd, n, nil
+	}
+
+	src, err := rd.ReadFullTemp()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	b, err := AppendBinaryAsText(nil, col.DataType, src)
+	if err != nil {
+		return nil, 0, err
+	}
+	return pool.NewBytesReader(b), len(b), nil
+}
+
+//------------------------------------------------------------------------------
+
+// BinaryScannerFunc scans a column value sent in the binary format.
+type BinaryScannerFunc func(v reflect.Value, col ColumnInfo, rd Reader, n int) error
+
+var binaryScannersMap sync.Map
+
+// BinaryScanner returns a BinaryScannerFunc for the type. Values that can't
+// be decoded directly are converted to the text format and scanned
+// with the Scanner for the type.
+func BinaryScanner(typ reflect.Type) BinaryScannerFunc {
+	if v, ok := binaryScannersMap.Load(typ); ok {
+		return v.(BinaryScannerFunc)
+	}
+	fn := binaryScanner(typ)
+	_, _ = binaryScannersMap.LoadOrStore(typ, fn)
+	return fn
+}
+
+func binaryScanner(typ reflect.Type) BinaryScannerFunc {
+	if typ == timeType {
+		return scanBinaryTimeValue
+	}
+
+	kind := typ.Kind()
+	if kind == reflect.Ptr {
+		return ptrBinaryScannerFunc(typ)
+	}
+
+	// Named types may have custom scanners.
+	if typ.PkgPath() == "" {
+		switch kind {
+		case reflect.Bool:
+			return scanBinaryBoolValue
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return scanBinaryIntValue
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return scanBinaryUintValue
+		case reflect.Float32, reflect.Float64:
+			return scanBinaryFloatValue
+		case reflect.Slice:
+			if typ.Elem().Kind() == reflect.Uint8 {
+				return scanBinaryBytesValue
+			}
+		}
+	}
+
+	return TextBinaryScanner(Scanner(typ))
+}
+
+// TextBinaryScanner returns a BinaryScannerFunc that converts values
+// to the text format and scans them with fn.
+func TextBinaryScanner(fn ScannerFunc) BinaryScannerFunc {
+	return func(v reflect.Value, col ColumnInfo, rd Reader, n int) error {
+		if fn == nil {
+			return fmt.Errorf("pg: Scan(unsupported %s)", v.Type())
+		}
+		rd, n, err := ReadBinaryAsText(col, rd, n)
+		if err != nil {
+			return err
+		}
+		return fn(v, rd, n)
+	}
+}
+
+func ptrBinaryScannerFunc(typ reflect.Type) BinaryScannerFunc {
+	scanner := BinaryScanner(typ.Elem())
+	return func(v reflect.Value, col ColumnInfo, rd Reader, n int) error {
+		if n == -1 {
+			if v.IsNil() {
+				return nil
+			}
+			if !v.CanSet() {
+				return fmt.Errorf("pg: Scan(non-settable %s)", v.Type())
+			}
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+
+		if v.IsNil() {
+			if !v.CanSet() {
+				return fmt.Errorf("pg: Scan(non-settable %s)", v.Type())
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		return scanner(v.Elem(), col, rd, n)
+	}
+}
+
+// ScanBinaryValue is like ScanValue, but scans a column value sent
+// in the binary format.
+func ScanBinaryValue(v reflect.Value, col ColumnInfo, rd Reader, n int) error {
+	if !v.IsValid() {
+		return fmt.Errorf("pg: Scan(nil)")
+	}
+	return BinaryScanner(v.Type())(v, col, rd, n)
+}
+
+// ScanBinary is like Scan, but scans a column value sent in the binary format.
+func ScanBinary(v interface{}, col ColumnInfo, rd Reader, n int) error {
+	if n != -1 {
+		switch v := v.(type) {
+		case *int64:
+			num, err := scanBinaryInt(col, rd)
+			if err == nil {
+				*v = num
+			}
+			return err
+		case *float64:
+			num, err := scanBinaryFloat(col, rd)
+			if err == nil {
+				*v = num
+			}
+			return err
+		case *time.Time:
+			tm, err := scanBinaryTime(col, rd)
+			if err == nil {
+				*v = tm
+			}
+			return err
+		}
+	}
+
+	vv := reflect.ValueOf(v)
+	if !vv.IsValid() || vv.Kind() != reflect.Ptr || vv.IsNil() {
+		// Let Scan report the error.
+		return Scan(v, rd, n)
+	}
+
+	vv = vv.Elem()
+	if vv.Kind() == reflect.Interface {
+		rd, n, err := ReadBinaryAsText(col, rd, n)
+		if err != nil {
+			return err
+		}
+		return Scan(v, rd, n)
+	}
+
+	return ScanBinaryValue(vv, col, rd, n)
+}
+
+func scanBinaryInt(col ColumnInfo, rd Reader) (int64, error) {
+	switch col.DataType {
+	case pgInt2, pgInt4, pgInt8:
+		b, err := rd.ReadFullTemp()
+		if err != nil {
+			return 0, err
+		}
+		return ParseBinaryInt(b)
+	}
+	return 0, fmt.Errorf("pg: can't scan binary value of type=%d into int", col.DataType)
+}
+
+func scanBinaryFloat(col ColumnInfo, rd Reader) (float64, error) {
+	switch col.DataType {
+	case pgFloat4, pgFloat8:
+		b, err := rd.ReadFullTemp()
+		if err != nil {
+			return 0, err
+		}
+		return ParseBinaryFloat(b)
+	case pgInt2, pgInt4, pgInt8:
+		num, err := scanBinaryInt(col, rd)
+		return float64(num), err
+	}
+	return 0, fmt.Errorf("pg: can't scan binary value of type=%d into float", col.DataType)
+}
+
+func scanBinaryTime(col ColumnInfo, rd Reader) (time.Time, error) {
+	switch col.DataType {
+	case pgTimestamp, pgTimestamptz:
+		b, err := rd.ReadFullTemp()
+		if err != nil {
+			return time.Time{}, err
+		}
+		return ParseBinaryTime(b)
+	case pgDate:
+		b, err := rd.ReadFullTemp()
+		if err != nil {
+			return time.Time{}, err
+		}
+		return ParseBinaryDate(b)
+	}
+	return time.Time{}, fmt.Errorf("pg: can't scan binary value of type=%d into time", col.DataType)
+}
+
+func scanBinaryBoolValue(v reflect.Value, col ColumnInfo, rd Reader, n int) error {
+	if n == -1 || col.DataType != pgBool {
+		return TextBinaryScanner(scanBoolValue)(v, col, rd, n)
+	}
+
+	b, err := rd.ReadFullTemp()
+	if err != nil {
+		return err
+	}
+
+	flag, err := ParseBinaryBool(b)
+	if err != nil {
+		return err
+	}
+
+	v.SetBool(flag)
+	return nil
+}
+
+func scanBinaryIntValue(v reflect.Value, col ColumnInfo, rd Reader, n int) error {
+	switch col.DataType {
+	case pgInt2, pgInt4, pgInt8:
+	default:
+		return TextBinaryScanner(scanInt64Value)(v, col, rd, n)
+	}
+
+	if n == -1 {
+		v.SetInt(0)
+		return nil
+	}
+
+	num, err := scanBinaryInt(col, rd)
+	if err != nil {
+		return err
+	}
+
+	if v.OverflowInt(num) {
+		return fmt.Errorf("pg: value %d overflows %s", num, v.Type())
+	}
+	v.SetInt(num)
+	return nil
+}
+
+func scanBinaryUintValue(v reflect.Value, col ColumnInfo, rd Reader, n int) error {
+	switch col.DataType {
+	case pgInt2, pgInt4, pgInt8:
+	default:
+		return TextBinaryScanner(scanUint64Value)(v, col, rd, n)
+	}
+
+	if n == -1 {
+		v.SetUint(0)
+		return nil
+	}
+
+	num, err := scanBinaryInt(col, rd)
+	if err != nil {
+		return err
+	}
+
+	// PostgreSQL does not natively support uint64 - only int64.
+	// Be nice and accept negative int64.
+	v.SetUint(uint64(num))
+	return nil
+}
+
+func scanBinaryFloatValue(v reflect.Value, col ColumnInfo, rd Reader, n int) error {
+	switch col.DataType {
+	case pgFloat4, pgFloat8, pgInt2, pgInt4, pgInt8:
+	default:
+		return TextBinaryScanner(scanFloat64Value)(v, col, rd, n)
+	}
+
+	if n == -1 {
+		v.SetFloat(0)
+		return nil
+	}
+
+	num, err := scanBinaryFloat(col, rd)
+	if err != nil {
+		return err
+	}
+
+	v.SetFloat(num)
+	return nil
+}
+
+func scanBinaryBytesValue(v reflect.Value, col ColumnInfo, rd Reader, n int) error {
+	if col.DataType != pgBytea {
+		return TextBinaryScanner(scanBytesValue)(v, col, rd, n)
+	}
+
+	if n == -1 {
+		v.SetBytes(nil)
+		return nil
+	}
+
+	b, err := rd.ReadFull()
+	if err != nil {
+		return err
+	}
+
+	v.SetBytes(b)
+	return nil
+}
+
+func scanBinaryTimeValue(v reflect.Value, col ColumnInfo, rd Reader, n int) error {
+	switch col.DataType {
+	case pgTimestamp, pgTimestamptz, pgDate:
+	default:
+		return TextBinaryScanner(scanTimeValue)(v, col, rd, n)
+	}
+
+	ptr := v.Addr().Interface().(*time.Time)
+	if n == -1 {
+		*ptr = time.Time{}
+		return nil
+	}
+
+	tm, err := scanBinaryTime(col, rd)
+	if err != nil {
+		return err
+	}
+
+	*ptr = tm
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// ReadBinaryColumnValue is like ReadColumnValue, but reads a column value
+// sent in the binary format.
+func ReadBinaryColumnValue(col ColumnInfo, rd Reader, n int) (interface{}, error) {
+	if n == -1 {
+		return ReadColumnValue(col, rd, n)
+	}
+
+	b, err := rd.ReadFullTemp()
+	if err != nil {
+		return nil, err
+	}
+
+	switch col.DataType {
+	case pgBool:
+		return ParseBinaryBool(b)
+
+	case pgInt2:
+		n, err := ParseBinaryInt(b)
+		return int16(n), err
+	case pgInt4:
+		n, err := ParseBinaryInt(b)
+		return int32(n), err
+	case pgInt8:
+		return ParseBinaryInt(b)
+
+	case pgFloat4:
+		n, err := ParseBinaryFloat(b)
+		return float32(n), err
+	case pgFloat8:
+		return ParseBinaryFloat(b)
+
+	case pgBytea:
+		return append([]byte{}, b...), nil
+
+	case pgTimestamp, pgTimestamptz:
+		return ParseBinaryTime(b)
+
+	case pgUUID:
+		uuid, err := ParseBinaryUUID(b)
+		if err != nil {
+			return nil, err
+		}
+		return string(appendUUID(nil, uuid)), nil
+	}
+
+	text, err := AppendBinaryAsText(nil, col.DataType, b)
+	if err != nil {
+		return nil, err
+	}
+	return ReadColumnValue(col, pool.NewBytesReader(text), len(text))
+}
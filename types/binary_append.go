@@ -0,0 +1,328 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/tmthrgd/go-hex"
+)
+
+// binaryArrayElemTypes maps array types to the types of their elements.
+var binaryArrayElemTypes = map[int32]int32{
+	pgBoolArray:        pgBool,
+	pgByteaArray:       pgBytea,
+	pgInt2Array:        pgInt2,
+	pgInt32Array:       pgInt4,
+	pgInt8Array:        pgInt8,
+	pgFloat4Array:      pgFloat4,
+	pgFloat8Array:      pgFloat8,
+	pgStringArray:      pgText,
+	pgVarcharArray:     pgVarchar,
+	pgBpcharArray:      pgBpchar,
+	pgDateArray:        pgDate,
+	pgTimestampArray:   pgTimestamp,
+	pgTimestamptzArray: pgTimestamptz,
+	pgUUIDArray:        pgUUID,
+	pgJSONArray:        pgJSON,
+	pgJSONBArray:       pgJSONB,
+}
+
+// SupportsBinaryEncoding reports whether values of the PostgreSQL type
+// can be encoded in the binary format with AppendBinary.
+func SupportsBinaryEncoding(dataType int32) bool {
+	switch dataType {
+	case pgBool,
+		pgInt2, pgInt4, pgInt8,
+		pgFloat4, pgFloat8,
+		pgText, pgVarchar, pgBpchar, pgBytea,
+		pgJSON, pgJSONB,
+		pgDate, pgTimestamp, pgTimestamptz,
+		pgUUID:
+		return true
+	}
+	_, ok := binaryArrayElemTypes[dataType]
+	return ok
+}
+
+// AppendBinary appends v encoded in the binary format of the PostgreSQL
+// type to b. It returns an error for NULL values, which don't have
+// a binary representation.
+//
+// Values of text and json types can be of any type supported by Append.
+func AppendBinary(b []byte, dataType int32, v interface{}) ([]byte, error) {
+	b, null, err := appendBinaryValue(b, dataType, reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	if null {
+		return nil, fmt.Errorf("pg: can't append NULL in the binary format")
+	}
+	return b, nil
+}
+
+// indirectBinaryValue dereferences pointers and interfaces and calls
+// driver.Valuer. It reports whether the value is NULL: nil, a nil pointer,
+// slice or map, or a driver.Valuer that returns nil.
+func indirectBinaryValue(v reflect.Value) (reflect.Value, bool, error) {
+	for {
+		if !v.IsValid() {
+			return v, true, nil
+		}
+
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+			if v.IsNil() {
+				return v, true, nil
+			}
+		}
+
+		if v.Type() != timeType && v.Type().Implements(driverValuerType) {
+			value, err := v.Interface().(driver.Valuer).Value()
+			if err != nil {
+				return v, false, err
+			}
+			v = reflect.ValueOf(value)
+			continue
+		}
+
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			v = v.Elem()
+		default:
+			return v, false, nil
+		}
+	}
+}
+
+func appendBinaryValue(b []byte, dataType int32, v reflect.Value) ([]byte, bool, error) {
+	v, null, err := indirectBinaryValue(v)
+	if err != nil || null {
+		return b, null, err
+	}
+
+	switch dataType {
+	case pgBool:
+		if v.Kind() == reflect.Bool {
+			if v.Bool() {
+				return append(b, 1), false, nil
+			}
+			return append(b, 0), false, nil
+		}
+	case pgInt2, pgInt4, pgInt8:
+		if num, ok := binaryIntValue(v); ok {
+			b, err := appendBinaryInt(b, dataType, num)
+			return b, false, err
+		}
+	case pgFloat4, pgFloat8:
+		var num float64
+		switch v.Kind() {
+		case reflect.Float32, reflect.Float64:
+			num = v.Float()
+		default:
+			n, ok := binaryIntValue(v)
+			if !ok {
+				return nil, false, errBinaryValue(v, dataType)
+			}
+			num = float64(n)
+		}
+		if dataType == pgFloat4 {
+			return appendUint32(b, math.Float32bits(float32(num))), false, nil
+		}
+		return appendUint64(b, math.Float64bits(num)), false, nil
+	case pgBytea:
+		switch {
+		case v.Kind() == reflect.String:
+			return append(b, v.String()...), false, nil
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			return append(b, v.Bytes()...), false, nil
+		}
+	case pgText, pgVarchar, pgBpchar, pgJSON:
+		return appendBinaryText(b, v)
+	case pgJSONB:
+		// The jsonb binary format is the version number followed by the text.
+		return appendBinaryText(append(b, 1), v)
+	case pgDate:
+		if v.Type() == timeType {
+			tm := v.Interface().(time.Time)
+			days := floorDiv(tm.Unix()-pgEpoch, secondsPerDay)
+			return appendUint32(b, uint32(int32(days))), false, nil
+		}
+	case pgTimestamp, pgTimestamptz:
+		if v.Type() == timeType {
+			// Timestamps without time zone get UTC time like AppendTime sends.
+			tm := v.Interface().(time.Time)
+			usec := (tm.Unix()-pgEpoch)*1e6 + int64(tm.Nanosecond())/1e3
+			return appendUint64(b, uint64(usec)), false, nil
+		}
+	case pgUUID:
+		uuid, ok, err := binaryUUIDValue(v)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			return append(b, uuid[:]...), false, nil
+		}
+	default:
+		if elemType, ok := binaryArrayElemTypes[dataType]; ok {
+			return appendBinaryArray(b, elemType, v)
+		}
+		return nil, false, fmt.Errorf("pg: can't append binary value of type=%d", dataType)
+	}
+
+	return nil, false, errBinaryValue(v, dataType)
+}
+
+func errBinaryValue(v reflect.Value, dataType int32) error {
+	return fmt.Errorf("pg: can't append %s as binary value of type=%d", v.Type(), dataType)
+}
+
+func binaryIntValue(v reflect.Value) (int64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		// PostgreSQL does not natively support uint64 - only int64.
+		return int64(v.Uint()), true
+	}
+	return 0, false
+}
+
+func appendBinaryInt(b []byte, dataType int32, num int64) ([]byte, error) {
+	switch dataType {
+	case pgInt2:
+		if num < math.MinInt16 || num > math.MaxInt16 {
+			return nil, fmt.Errorf("pg: value %d overflows int2", num)
+		}
+		return append(b, byte(num>>8), byte(num)), nil
+	case pgInt4:
+		if num < math.MinInt32 || num > math.MaxInt32 {
+			return nil, fmt.Errorf("pg: value %d overflows int4", num)
+		}
+		return appendUint32(b, uint32(num)), nil
+	default:
+		return appendUint64(b, uint64(num)), nil
+	}
+}
+
+// appendBinaryText appends the value in the text format, which is also
+// the binary format of text and json types.
+func appendBinaryText(b []byte, v reflect.Value) ([]byte, bool, error) {
+	switch {
+	case v.Kind() == reflect.String:
+		return append(b, v.String()...), false, nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		return append(b, v.Bytes()...), false, nil
+	}
+
+	value := Appender(v.Type())(b, v, 0)
+	if value == nil {
+		// Appenders return nil for NULL when values are not quoted.
+		return b, true, nil
+	}
+	return value, false, nil
+}
+
+// binaryUUIDValue converts [16]byte, 16 bytes or the text representation
+// of a UUID.
+func binaryUUIDValue(v reflect.Value) ([16]byte, bool, error) {
+	var uuid [16]byte
+	switch {
+	case v.Kind() == reflect.Array && v.Len() == 16 && v.Type().Elem().Kind() == reflect.Uint8:
+		reflect.Copy(reflect.ValueOf(uuid[:]), v)
+		return uuid, true, nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 && v.Len() == 16:
+		copy(uuid[:], v.Bytes())
+		return uuid, true, nil
+	case v.Kind() == reflect.String:
+		uuid, err := parseUUID(v.String())
+		return uuid, true, err
+	}
+	return uuid, false, nil
+}
+
+func parseUUID(s string) ([16]byte, error) {
+	var uuid [16]byte
+	src := make([]byte, 0, 32)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '-', '{', '}':
+		default:
+			src = append(src, c)
+		}
+	}
+	if len(src) != 32 {
+		return uuid, fmt.Errorf("pg: can't parse uuid %q", s)
+	}
+	if _, err := hex.Decode(uuid[:], src); err != nil {
+		return uuid, fmt.Errorf("pg: can't parse uuid %q", s)
+	}
+	return uuid, nil
+}
+
+// appendBinaryArray appends a one-dimensional array with the lower bound 1.
+func appendBinaryArray(b []byte, elemType int32, v reflect.Value) ([]byte, bool, error) {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+	default:
+		return nil, false, errBinaryValue(v, elemType)
+	}
+
+	ndim := 1
+	if v.Len() == 0 {
+		ndim = 0
+	}
+
+	start := len(b)
+	b = appendUint32(b, uint32(ndim))
+	b = appendUint32(b, 0) // has nulls
+	b = appendUint32(b, uint32(elemType))
+	if ndim == 1 {
+		b = appendUint32(b, uint32(v.Len()))
+		b = appendUint32(b, 1) // lower bound
+	}
+
+	var hasNulls bool
+	for i := 0; i < v.Len(); i++ {
+		elemStart := len(b)
+		b = appendUint32(b, 0)
+
+		var null bool
+		var err error
+		b, null, err = appendBinaryValue(b, elemType, v.Index(i))
+		if err != nil {
+			return nil, false, err
+		}
+
+		if null {
+			hasNulls = true
+			b = appendUint32(b[:elemStart], math.MaxUint32)
+			continue
+		}
+		binary.BigEndian.PutUint32(b[elemStart:], uint32(len(b)-elemStart-4))
+	}
+
+	if hasNulls {
+		binary.BigEndian.PutUint32(b[start+4:], 1)
+	}
+	return b, false, nil
+}
+
+func appendUint32(b []byte, n uint32) []byte {
+	return append(b, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+func appendUint64(b []byte, n uint64) []byte {
+	return appendUint32(appendUint32(b, uint32(n>>32)), uint32(n))
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
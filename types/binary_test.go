@@ -0,0 +1,200 @@
+package types_test
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v10/internal/pool"
+	"github.com/go-pg/pg/v10/types"
+)
+
+const (
+	oidBool        = 16
+	oidInt2        = 21
+	oidInt4        = 23
+	oidInt8        = 20
+	oidFloat8      = 701
+	oidBytea       = 17
+	oidText        = 25
+	oidTimestamptz = 1184
+	oidUUID        = 2950
+	oidInt8Array   = 1016
+	oidTextArray   = 1009
+)
+
+func binaryInt(size int, n int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(n))
+	return b[8-size:]
+}
+
+func binaryArray(elemType int32, elems ...[]byte) []byte {
+	b := make([]byte, 20)
+	binary.BigEndian.PutUint32(b[0:], 1)
+	binary.BigEndian.PutUint32(b[8:], uint32(elemType))
+	binary.BigEndian.PutUint32(b[12:], uint32(len(elems)))
+	binary.BigEndian.PutUint32(b[16:], 1)
+	for _, elem := range elems {
+		if elem == nil {
+			b = append(b, 0xff, 0xff, 0xff, 0xff)
+			continue
+		}
+		b = append(b, binaryInt(4, int64(len(elem)))...)
+		b = append(b, elem...)
+	}
+	return b
+}
+
+func TestAppendBinaryAsText(t *testing.T) {
+	tm := time.Date(2020, time.March, 4, 5, 6, 7, 123456000, time.UTC)
+	usec := tm.Sub(time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)).Microseconds()
+
+	tests := []struct {
+		dataType int32
+		src      []byte
+		wanted   string
+	}{
+		{oidBool, []byte{1}, "t"},
+		{oidBool, []byte{0}, "f"},
+		{oidInt2, binaryInt(2, -2), "-2"},
+		{oidInt4, binaryInt(4, math.MaxInt32), "2147483647"},
+		{oidInt8, binaryInt(8, math.MinInt64), "-9223372036854775808"},
+		{oidFloat8, binaryInt(8, int64(math.Float64bits(1.5))), "1.5"},
+		{oidFloat8, binaryInt(8, int64(math.Float64bits(math.Inf(-1)))), "-Infinity"},
+		{oidBytea, []byte{0xde, 0xad}, `\xdead`},
+		{oidTimestamptz, binaryInt(8, usec), "2020-03-04 05:06:07.123456+00:00"},
+		{oidTimestamptz, binaryInt(8, math.MaxInt64), "infinity"},
+		{
+			oidUUID,
+			[]byte{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0, 0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0},
+			"12345678-9abc-def0-1234-56789abcdef0",
+		},
+		{oidInt8Array, binaryArray(oidInt8, binaryInt(8, 1), nil, binaryInt(8, 3)), `{"1",NULL,"3"}`},
+		{oidTextArray, binaryArray(oidText, []byte(`a"b`), []byte(`c\`)), `{"a\"b","c\\"}`},
+	}
+	for _, test := range tests {
+		b, err := types.AppendBinaryAsText(nil, test.dataType, test.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != test.wanted {
+			t.Fatalf("got %q, wanted %q", b, test.wanted)
+		}
+	}
+}
+
+func TestParseBinaryTimeLocation(t *testing.T) {
+	text, err := types.ParseTimeString("2020-03-04 08:06:07.123456+03")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usec := text.Sub(time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)).Microseconds()
+	tm, err := types.ParseBinaryTime(binaryInt(8, usec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The binary format has no offset, so the same instant is returned in UTC.
+	if !tm.Equal(text) {
+		t.Fatalf("got %s, wanted %s", tm, text)
+	}
+	if tm.Location() != time.UTC {
+		t.Fatalf("got location %s, wanted UTC", tm.Location())
+	}
+	if _, offset := text.Zone(); offset != 3*3600 {
+		t.Fatalf("got text offset %d, wanted %d", offset, 3*3600)
+	}
+}
+
+func TestScanBinaryValue(t *testing.T) {
+	// Zero time is too far from the PostgreSQL epoch for time.Duration.
+	tm := time.Time{}
+	usec := (tm.Unix() - 946684800) * 1e6
+
+	tests := []struct {
+		dataType int32
+		src      []byte
+		dst      interface{}
+		wanted   interface{}
+	}{
+		{oidBool, []byte{1}, new(bool), true},
+		{oidInt4, binaryInt(4, -7), new(int), -7},
+		{oidInt2, binaryInt(2, 7), new(uint16), uint16(7)},
+		{oidInt8, binaryInt(8, 42), new(float64), float64(42)},
+		{oidFloat8, binaryInt(8, int64(math.Float64bits(0.25))), new(float32), float32(0.25)},
+		{oidInt8, binaryInt(8, 42), new(string), "42"},
+		{oidInt8, binaryInt(8, 42), new(*int64), int64(42)},
+		{oidBytea, []byte("hello"), new([]byte), []byte("hello")},
+		{oidTimestamptz, binaryInt(8, usec), new(time.Time), tm},
+		{oidInt8Array, binaryArray(oidInt8, binaryInt(8, 1), binaryInt(8, 2)), types.NewArray(new([]int64)), []int64{1, 2}},
+	}
+	for _, test := range tests {
+		col := types.ColumnInfo{DataType: test.dataType, Format: types.BinaryFormat}
+		err := types.ScanBinary(test.dst, col, pool.NewBytesReader(test.src), len(test.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got interface{}
+		if arr, ok := test.dst.(*types.Array); ok {
+			got = reflect.ValueOf(arr.Value()).Elem().Interface()
+		} else {
+			v := reflect.ValueOf(test.dst).Elem()
+			for v.Kind() == reflect.Ptr {
+				v = v.Elem()
+			}
+			got = v.Interface()
+		}
+		if !reflect.DeepEqual(got, test.wanted) {
+			t.Fatalf("got %#v, wanted %#v", got, test.wanted)
+		}
+	}
+}
+
+func TestScanBinaryNull(t *testing.T) {
+	col := types.ColumnInfo{DataType: oidInt8, Format: types.BinaryFormat}
+
+	num := int64(1)
+	if err := types.ScanBinary(&num, col, pool.NewBytesReader(nil), -1); err != nil {
+		t.Fatal(err)
+	}
+	if num != 0 {
+		t.Fatalf("got %d, wanted 0", num)
+	}
+
+	ptr := &num
+	if err := types.ScanBinary(&ptr, col, pool.NewBytesReader(nil), -1); err != nil {
+		t.Fatal(err)
+	}
+	if ptr != nil {
+		t.Fatalf("got %v, wanted nil", ptr)
+	}
+}
+
+func TestReadBinaryColumnValue(t *testing.T) {
+	tests := []struct {
+		dataType int32
+		src      []byte
+		wanted   interface{}
+	}{
+		{oidInt2, binaryInt(2, 1), int16(1)},
+		{oidInt4, binaryInt(4, 1), int32(1)},
+		{oidInt8, binaryInt(8, 1), int64(1)},
+		{oidInt8Array, binaryArray(oidInt8, binaryInt(8, 1), binaryInt(8, 2)), []int64{1, 2}},
+		{oidTextArray, binaryArray(oidText, []byte("foo"), []byte("bar")), []string{"foo", "bar"}},
+	}
+	for _, test := range tests {
+		col := types.ColumnInfo{DataType: test.dataType, Format: types.BinaryFormat}
+		got, err := types.ReadBinaryColumnValue(col, pool.NewBytesReader(test.src), len(test.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, test.wanted) {
+			t.Fatalf("got %#v, wanted %#v", got, test.wanted)
+		}
+	}
+}
@@ -19,18 +19,30 @@ const (
 
 	pgText    = 25
 	pgVarchar = 1043
+	pgBpchar  = 1042
 	pgBytea   = 17
 	pgJSON    = 114
 	pgJSONB   = 3802
 
+	pgDate        = 1082
 	pgTimestamp   = 1114
 	pgTimestamptz = 1184
 
-	// pgInt2Array = 1005
-	pgInt32Array  = 1007
-	pgInt8Array   = 1016
-	pgFloat8Array = 1022
-	pgStringArray = 1009
+	pgBoolArray        = 1000
+	pgByteaArray       = 1001
+	pgInt2Array        = 1005
+	pgInt32Array       = 1007
+	pgInt8Array        = 1016
+	pgFloat8Array      = 1022
+	pgStringArray      = 1009
+	pgVarcharArray     = 1015
+	pgBpcharArray      = 1014
+	pgDateArray        = 1182
+	pgTimestampArray   = 1115
+	pgTimestamptzArray = 1185
+	pgUUIDArray        = 2951
+	pgJSONArray        = 199
+	pgJSONBArray       = 3807
 
 	pgUUID = 2950
 )
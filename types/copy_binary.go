@@ -0,0 +1,337 @@
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+
+	"github.com/go-pg/pg/v10/internal/pool"
+)
+
+// copyBinarySignature starts the PGCOPY binary format.
+const copyBinarySignature = "PGCOPY\n\377\r\n\000"
+
+// copyBinaryHeaderLen is the length of the signature, flags
+// and header extension length.
+const copyBinaryHeaderLen = len(copyBinarySignature) + 8
+
+const copyBinaryBufferSize = 32 << 10
+
+var errCopyBinaryWriterClosed = errors.New("pg: CopyBinaryWriter is closed")
+
+// AppendCopyBinaryHeader appends the header of the COPY binary format to b.
+func AppendCopyBinaryHeader(b []byte) []byte {
+	b = append(b, copyBinarySignature...)
+	b = appendUint32(b, 0) // flags
+	b = appendUint32(b, 0) // header extension length
+	return b
+}
+
+// AppendCopyBinaryTrailer appends the trailer of the COPY binary format to b.
+func AppendCopyBinaryTrailer(b []byte) []byte {
+	return append(b, 0xff, 0xff)
+}
+
+// AppendCopyBinaryRow appends a row in the COPY binary format to b encoding
+// values with AppendBinary for the corresponding data types.
+// NULL values are sent as NULLs.
+func AppendCopyBinaryRow(b []byte, dataTypes []int32, values ...interface{}) ([]byte, error) {
+	if len(values) != len(dataTypes) {
+		return nil, fmt.Errorf("pg: got %d values, wanted %d", len(values), len(dataTypes))
+	}
+
+	b = append(b, byte(len(values)>>8), byte(len(values)))
+	for i, v := range values {
+		var err error
+		b, err = appendCopyBinaryField(b, dataTypes[i], reflect.ValueOf(v))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func appendCopyBinaryField(b []byte, dataType int32, v reflect.Value) ([]byte, error) {
+	start := len(b)
+	b = appendUint32(b, 0)
+
+	b, null, err := appendBinaryValue(b, dataType, v)
+	if err != nil {
+		return nil, err
+	}
+
+	if null {
+		return appendUint32(b[:start], math.MaxUint32), nil
+	}
+	binary.BigEndian.PutUint32(b[start:], uint32(len(b)-start-4))
+	return b, nil
+}
+
+//------------------------------------------------------------------------------
+
+// CopyBinaryWriter writes rows in the COPY binary format, for example
+// to the reader passed to CopyFrom with a query like
+// COPY table (columns) FROM STDIN WITH (FORMAT binary).
+// The header is written before the first row.
+type CopyBinaryWriter struct {
+	w         io.Writer
+	dataTypes []int32
+
+	buf    []byte
+	closed bool
+}
+
+// NewCopyBinaryWriter returns a CopyBinaryWriter that writes rows
+// with columns of the data types (OIDs) to w.
+func NewCopyBinaryWriter(w io.Writer, dataTypes []int32) *CopyBinaryWriter {
+	return &CopyBinaryWriter{
+		w:         w,
+		dataTypes: dataTypes,
+		buf:       AppendCopyBinaryHeader(make([]byte, 0, copyBinaryBufferSize)),
+	}
+}
+
+// WriteRow writes a row with a value for each column. Values are buffered
+// and written in chunks.
+func (w *CopyBinaryWriter) WriteRow(values ...interface{}) error {
+	if w.closed {
+		return errCopyBinaryWriterClosed
+	}
+
+	b, err := AppendCopyBinaryRow(w.buf, w.dataTypes, values...)
+	if err != nil {
+		return err
+	}
+	w.buf = b
+
+	if len(w.buf) >= copyBinaryBufferSize {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush writes buffered rows to the underlying writer.
+func (w *CopyBinaryWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.w.Write(w.buf)
+	w.buf = w.buf[:0]
+	return err
+}
+
+// Close writes the trailer and flushes buffered rows.
+// It does not close the underlying writer.
+func (w *CopyBinaryWriter) Close() error {
+	if w.closed {
+		return errCopyBinaryWriterClosed
+	}
+	w.closed = true
+	w.buf = AppendCopyBinaryTrailer(w.buf)
+	return w.Flush()
+}
+
+//------------------------------------------------------------------------------
+
+// ParseCopyBinaryHeader parses the header of the COPY binary format
+// at the start of b and returns its length. It returns 0 when b
+// does not contain the complete header.
+func ParseCopyBinaryHeader(b []byte) (int, error) {
+	n := len(copyBinarySignature)
+	if len(b) < n {
+		if !bytes.HasPrefix([]byte(copyBinarySignature), b) {
+			return 0, errors.New("pg: invalid COPY binary signature")
+		}
+		return 0, nil
+	}
+	if string(b[:n]) != copyBinarySignature {
+		return 0, errors.New("pg: invalid COPY binary signature")
+	}
+
+	if len(b) < copyBinaryHeaderLen {
+		return 0, nil
+	}
+
+	flags := binary.BigEndian.Uint32(b[n:])
+	// Bits 16-31 are critical, e.g. bit 16 means that rows include OIDs.
+	// Bits 0-15 can be ignored.
+	if flags&^0xffff != 0 {
+		return 0, fmt.Errorf("pg: unsupported COPY binary flags=%#x", flags)
+	}
+
+	ext := int(binary.BigEndian.Uint32(b[n+4:]))
+	if len(b) < copyBinaryHeaderLen+ext {
+		return 0, nil
+	}
+	return copyBinaryHeaderLen + ext, nil
+}
+
+// ParseCopyBinaryRow parses the row in the COPY binary format at the
+// start of b. It appends the fields to fields and returns them together
+// with the row length. NULL fields are nil. Fields refer to b.
+//
+// It returns 0 when b does not contain the complete row and io.EOF
+// with the length 2 for the trailer.
+func ParseCopyBinaryRow(b []byte, fields [][]byte) ([][]byte, int, error) {
+	if len(b) < 2 {
+		return fields, 0, nil
+	}
+
+	numField := int(int16(binary.BigEndian.Uint16(b)))
+	if numField == -1 {
+		return fields, 2, io.EOF
+	}
+	if numField < 0 {
+		return fields, 0, fmt.Errorf("pg: invalid COPY binary field count %d", numField)
+	}
+
+	pos := 2
+	for i := 0; i < numField; i++ {
+		if len(b)-pos < 4 {
+			return fields, 0, nil
+		}
+		n := int(int32(binary.BigEndian.Uint32(b[pos:])))
+		pos += 4
+
+		if n == -1 {
+			fields = append(fields, nil)
+			continue
+		}
+		if n < 0 {
+			return fields, 0, fmt.Errorf("pg: invalid COPY binary field length %d", n)
+		}
+		if len(b)-pos < n {
+			return fields, 0, nil
+		}
+		fields = append(fields, b[pos:pos+n:pos+n])
+		pos += n
+	}
+	return fields, pos, nil
+}
+
+//------------------------------------------------------------------------------
+
+// CopyBinaryReader reads rows in the COPY binary format, for example
+// from the writer passed to CopyTo with a query like
+// COPY table (columns) TO STDOUT WITH (FORMAT binary).
+type CopyBinaryReader struct {
+	r io.Reader
+
+	buf    []byte
+	pos    int
+	fields [][]byte
+
+	header bool
+	err    error
+}
+
+// NewCopyBinaryReader returns a CopyBinaryReader that reads from r.
+func NewCopyBinaryReader(r io.Reader) *CopyBinaryReader {
+	return &CopyBinaryReader{
+		r: r,
+	}
+}
+
+// ReadRow reads the next row and returns its fields. NULL fields are nil.
+// The fields are valid until the next call. It returns io.EOF after
+// the trailer.
+func (r *CopyBinaryReader) ReadRow() ([][]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	for !r.header {
+		n, err := ParseCopyBinaryHeader(r.buf[r.pos:])
+		if err != nil {
+			r.err = err
+			return nil, err
+		}
+		if n > 0 {
+			r.pos += n
+			r.header = true
+			break
+		}
+		if err := r.fill(); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		fields, n, err := ParseCopyBinaryRow(r.buf[r.pos:], r.fields[:0])
+		if err != nil {
+			r.err = err
+			return nil, err
+		}
+		if n > 0 {
+			r.pos += n
+			r.fields = fields
+			return fields, nil
+		}
+		if err := r.fill(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Scan reads the next row and scans its fields into values like Scan
+// does for columns of the data types (OIDs).
+func (r *CopyBinaryReader) Scan(dataTypes []int32, values ...interface{}) error {
+	if len(values) != len(dataTypes) {
+		return fmt.Errorf("pg: got %d values, wanted %d", len(values), len(dataTypes))
+	}
+
+	fields, err := r.ReadRow()
+	if err != nil {
+		return err
+	}
+	if len(fields) != len(values) {
+		return fmt.Errorf("pg: got %d values, but row has %d fields", len(values), len(fields))
+	}
+
+	for i, field := range fields {
+		col := ColumnInfo{
+			Index:    int16(i),
+			DataType: dataTypes[i],
+			Format:   BinaryFormat,
+		}
+		n := len(field)
+		if field == nil {
+			n = -1
+		}
+		if err := ScanBinary(values[i], col, pool.NewBytesReader(field), n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fill reads more data keeping the unread data.
+func (r *CopyBinaryReader) fill() error {
+	if r.pos > 0 {
+		r.buf = r.buf[:copy(r.buf, r.buf[r.pos:])]
+		r.pos = 0
+	}
+	if len(r.buf) == cap(r.buf) {
+		buf := make([]byte, len(r.buf), 2*cap(r.buf)+copyBinaryBufferSize)
+		copy(buf, r.buf)
+		r.buf = buf
+	}
+
+	n, err := r.r.Read(r.buf[len(r.buf):cap(r.buf)])
+	r.buf = r.buf[:len(r.buf)+n]
+	if n > 0 {
+		return nil
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err == nil {
+		return nil
+	}
+	r.err = err
+	return err
+}
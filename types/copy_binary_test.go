@@ -0,0 +1,191 @@
+package types_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/go-pg/pg/v10/types"
+)
+
+const (
+	oidFloat4    = 700
+	oidJSONB     = 3802
+	oidDate      = 1082
+	oidInt4Array = 1007
+)
+
+func TestCopyBinaryWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := types.NewCopyBinaryWriter(&buf, []int32{oidInt4, oidText})
+	if err := w.WriteRow(1, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow(nil, (*string)(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	wanted := "PGCOPY\n\377\r\n\000" + "\x00\x00\x00\x00" + "\x00\x00\x00\x00" +
+		"\x00\x02" + "\x00\x00\x00\x04\x00\x00\x00\x01" + "\x00\x00\x00\x01a" +
+		"\x00\x02" + "\xff\xff\xff\xff" + "\xff\xff\xff\xff" +
+		"\xff\xff"
+	if got := buf.String(); got != wanted {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+
+	if err := w.WriteRow(2, "b"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+type copyBinaryJSON struct {
+	Foo string `json:"foo"`
+}
+
+func TestCopyBinaryRoundTrip(t *testing.T) {
+	tm := time.Date(2020, time.March, 4, 5, 6, 7, 123456000, time.UTC)
+	date := time.Date(1999, time.December, 31, 0, 0, 0, 0, time.UTC)
+	uuid := "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+
+	dataTypes := []int32{
+		oidBool, oidInt2, oidInt4, oidInt8, oidFloat4, oidFloat8,
+		oidText, oidBytea, oidTimestamptz, oidDate, oidUUID, oidJSONB,
+		oidInt4Array, oidTextArray, oidText,
+	}
+	values := []interface{}{
+		true, int16(-2), 3, uint64(4), float32(1.5), 2.25,
+		"hello", []byte{0, 1, 0xff}, tm, date, uuid, copyBinaryJSON{Foo: "bar"},
+		[]int{1, 2, 3}, []string{`"quoted"`, uuid}, nil,
+	}
+
+	var buf bytes.Buffer
+	w := types.NewCopyBinaryWriter(&buf, dataTypes)
+	for i := 0; i < 3; i++ {
+		if err := w.WriteRow(values...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := types.NewCopyBinaryReader(iotest.OneByteReader(&buf))
+	for i := 0; i < 3; i++ {
+		var (
+			flag      bool
+			i2, i4    int
+			i8        uint64
+			f4, f8    float64
+			text      string
+			bytea     []byte
+			ts, dt    time.Time
+			gotUUID   string
+			jsonb     copyBinaryJSON
+			ints      []int
+			strs      []string
+			null      *string
+			arrayInts = types.NewArray(&ints)
+			arrayStrs = types.NewArray(&strs)
+		)
+		err := r.Scan(dataTypes,
+			&flag, &i2, &i4, &i8, &f4, &f8,
+			&text, &bytea, &ts, &dt, &gotUUID, &jsonb,
+			arrayInts, arrayStrs, &null)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := []interface{}{
+			flag, i2, i4, i8, f4, f8,
+			text, bytea, ts, dt, gotUUID, jsonb,
+			ints, strs, null,
+		}
+		wanted := []interface{}{
+			true, -2, 3, uint64(4), 1.5, 2.25,
+			"hello", []byte{0, 1, 0xff}, tm, date, uuid, copyBinaryJSON{Foo: "bar"},
+			[]int{1, 2, 3}, []string{`"quoted"`, uuid}, (*string)(nil),
+		}
+		for j := range wanted {
+			if !reflect.DeepEqual(got[j], wanted[j]) {
+				t.Fatalf("column %d: got %#v, wanted %#v", j, got[j], wanted[j])
+			}
+		}
+	}
+
+	if _, err := r.ReadRow(); err != io.EOF {
+		t.Fatalf("got %v, wanted io.EOF", err)
+	}
+}
+
+func TestAppendBinary(t *testing.T) {
+	tests := []struct {
+		dataType int32
+		value    interface{}
+		wanted   []byte
+	}{
+		{oidInt2, 258, []byte{1, 2}},
+		{oidFloat8, 1, []byte{0x3f, 0xf0, 0, 0, 0, 0, 0, 0}},
+		{oidDate, time.Date(1999, time.December, 31, 23, 0, 0, 0, time.UTC), []byte{0xff, 0xff, 0xff, 0xff}},
+		{oidUUID, [16]byte{15: 1}, append(make([]byte, 15), 1)},
+		{oidJSONB, json.RawMessage(`{}`), []byte("\x01{}")},
+		{oidInt4Array, []int{}, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 23}},
+		{oidTextArray, []*string{nil}, []byte{
+			0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 25, 0, 0, 0, 1, 0, 0, 0, 1, 0xff, 0xff, 0xff, 0xff,
+		}},
+		{oidText, types.Safe("safe"), []byte("safe")},
+	}
+	for _, test := range tests {
+		got, err := types.AppendBinary(nil, test.dataType, test.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, test.wanted) {
+			t.Fatalf("%T: got %x, wanted %x", test.value, got, test.wanted)
+		}
+	}
+}
+
+func TestAppendBinaryErrors(t *testing.T) {
+	tests := []struct {
+		dataType int32
+		value    interface{}
+		wanted   string
+	}{
+		{oidInt2, 1 << 20, "pg: value 1048576 overflows int2"},
+		{oidInt8, "1", "pg: can't append string as binary value of type=20"},
+		{oidUUID, "not-a-uuid", `pg: can't parse uuid "not-a-uuid"`},
+		{1700, 1, "pg: can't append binary value of type=1700"},
+		{oidInt8, nil, "pg: can't append NULL in the binary format"},
+	}
+	for _, test := range tests {
+		_, err := types.AppendBinary(nil, test.dataType, test.value)
+		if err == nil || err.Error() != test.wanted {
+			t.Fatalf("got %v, wanted %q", err, test.wanted)
+		}
+	}
+}
+
+func TestCopyBinaryReaderErrors(t *testing.T) {
+	tests := []struct {
+		data   string
+		wanted string
+	}{
+		{"PGCOPY\nX", "pg: invalid COPY binary signature"},
+		{"PGCOPY\n\377\r\n\000\x00\x01\x00\x00\x00\x00\x00\x00", "pg: unsupported COPY binary flags=0x10000"},
+		{"PGCOPY\n\377\r\n\000\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01\x00\x00", io.ErrUnexpectedEOF.Error()},
+	}
+	for _, test := range tests {
+		r := types.NewCopyBinaryReader(bytes.NewReader([]byte(test.data)))
+		_, err := r.ReadRow()
+		if err == nil || err.Error() != test.wanted {
+			t.Fatalf("got %v, wanted %q", err, test.wanted)
+		}
+	}
+}